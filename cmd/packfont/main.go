@@ -1,52 +1,168 @@
+// Command packfont compiles a font family into darktile. The font files of each style are copied into the packed
+// package, optionally subset to the characters a terminal needs, along with a Go file which embeds them and
+// registers the family:
+//
+//	go run ./cmd/packfont -name "MesloLGS NF" -subset \
+//		-regular MesloLGS-NF-Regular.ttf -bold MesloLGS-NF-Bold.ttf \
+//		-italic MesloLGS-NF-Italic.ttf -bolditalic MesloLGS-NF-Bold-Italic.ttf
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"go/format"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"text/template"
+	"unicode"
 )
 
+type style struct {
+	name string // used in file and variable names
+	path string
+}
+
 func main() {
 
-	if len(os.Args) != 3 {
-		fmt.Println("Please specify font path and name")
+	name := flag.String("name", "", "Family name the font is selected by, e.g. \"MesloLGS NF\"")
+	out := flag.String("out", "./internal/app/darktile/packed", "Directory of the packed package")
+	regular := flag.String("regular", "", "Path to the regular style")
+	bold := flag.String("bold", "", "Path to the bold style (optional - synthesised from the regular style if missing)")
+	italic := flag.String("italic", "", "Path to the italic style (optional)")
+	boldItalic := flag.String("bolditalic", "", "Path to the bold italic style (optional)")
+	subsetFonts := flag.Bool("subset", false, "Remove the outlines of characters a terminal is unlikely to need")
+	keep := flag.String("keep", "", "Ranges of characters to keep as well when subsetting, e.g. \"E000-F8FF,1F300-1F5FF\"")
+	flag.Parse()
+
+	if *name == "" || *regular == "" || flag.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Please specify the family name with -name and the font files with -regular, -bold, -italic and -bolditalic")
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	path := os.Args[1]
-	name := os.Args[2]
+	ranges := terminalRanges
+	if *keep != "" {
+		extra, err := parseRanges(*keep)
+		if err != nil {
+			fail(err)
+		}
+		ranges = append(ranges, extra...)
+	}
 
-	fontBytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		panic(err)
+	ident := identifier(*name)
+	if ident == "" {
+		fail(fmt.Errorf("family name '%s' has no letters or digits to name the files with", *name))
 	}
 
-	packed, err := os.OpenFile(fmt.Sprintf("./internal/app/darktile/packed/%s.go", strings.ToLower(name)), os.O_WRONLY|os.O_CREATE, 0744)
-	if err != nil {
-		panic(err)
+	styles := []style{
+		{name: "Regular", path: *regular},
+		{name: "Bold", path: *bold},
+		{name: "Italic", path: *italic},
+		{name: "BoldItalic", path: *boldItalic},
 	}
-	defer packed.Close()
 
-	if _, err := packed.WriteString(fmt.Sprintf(`package packed
+	data := templateData{Name: *name}
+	for _, s := range styles {
+		filename := strings.ToLower(ident) + "-" + strings.ToLower(s.name) + ".ttf"
+		target := filepath.Join(*out, filename)
 
-var %sTTF = []byte{
-`, name)); err != nil {
-		panic(err)
-	}
+		if s.path == "" {
+			// remove the file from any previous run, so that it isn't left behind unused
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				fail(err)
+			}
+			continue
+		}
 
-	for i, b := range fontBytes {
-		if _, err := packed.WriteString(fmt.Sprintf(" 0x%x,", b)); err != nil {
-			panic(err)
+		fontBytes, err := ioutil.ReadFile(s.path)
+		if err != nil {
+			fail(err)
 		}
-		if i > 0 && i%16 == 0 {
-			if _, err := packed.WriteString("\n"); err != nil {
-				panic(err)
+		if *subsetFonts {
+			before := len(fontBytes)
+			fontBytes, err = subset(fontBytes, ranges)
+			if err != nil {
+				fail(fmt.Errorf("failed to subset %s: %w", s.path, err))
 			}
+			fmt.Printf("%s: subset from %d to %d bytes\n", s.path, before, len(fontBytes))
+		}
+		if err := ioutil.WriteFile(target, fontBytes, 0644); err != nil {
+			fail(err)
+		}
+
+		data.Styles = append(data.Styles, templateStyle{
+			Field:    s.name,
+			Variable: lowerFirst(ident) + s.name,
+			Filename: filename,
+		})
+	}
+
+	var source bytes.Buffer
+	if err := sourceTemplate.Execute(&source, data); err != nil {
+		fail(err)
+	}
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		fail(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(*out, strings.ToLower(ident)+".go"), formatted, 0644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// identifier turns a family name into a Go identifier, e.g. "MesloLGS NF" into "MesloLGSNF"
+func identifier(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
 		}
 	}
-	if _, err := packed.WriteString("}\n"); err != nil {
-		panic(err)
+	ident := b.String()
+	if ident != "" && unicode.IsDigit(rune(ident[0])) {
+		ident = "Font" + ident
 	}
+	return ident
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+type templateData struct {
+	Name   string
+	Styles []templateStyle
+}
+
+type templateStyle struct {
+	Field    string
+	Variable string
+	Filename string
+}
+
+var sourceTemplate = template.Must(template.New("packed").Parse(`// Code generated by packfont. DO NOT EDIT.
+
+package packed
 
+import _ "embed"
+{{range .Styles}}
+//go:embed {{.Filename}}
+var {{.Variable}} []byte
+{{end}}
+func init() {
+	register(Family{
+		Name: {{printf "%q" .Name}},
+		{{- range .Styles}}
+		{{.Field}}: {{.Variable}},
+		{{- end}}
+	})
 }
+`))
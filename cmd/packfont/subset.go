@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	gotext "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+)
+
+type runeRange struct {
+	first, last rune
+}
+
+// terminalRanges are the characters kept when subsetting - text in European scripts, symbols, box drawing and
+// the Powerline separators
+var terminalRanges = []runeRange{
+	{0x0020, 0x007E},   // Basic Latin
+	{0x00A0, 0x036F},   // Latin-1 Supplement to Combining Diacritical Marks
+	{0x0370, 0x052F},   // Greek and Cyrillic
+	{0x1E00, 0x1EFF},   // Latin Extended Additional
+	{0x2000, 0x2BFF},   // punctuation, symbols, arrows, maths, box drawing, blocks, shapes, dingbats and braille
+	{0xE0A0, 0xE0D7},   // Powerline
+	{0xFFFD, 0xFFFD},   // replacement character
+	{0x1FB00, 0x1FBFF}, // Symbols for Legacy Computing
+}
+
+// parseRanges parses a comma separated list of hexadecimal code points and ranges, e.g. "E000-F8FF,U+1F600"
+func parseRanges(s string) ([]runeRange, error) {
+	var ranges []runeRange
+	for _, part := range strings.Split(s, ",") {
+		first, last := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			first, last = part[:i], part[i+1:]
+		}
+		from, err := parseCodePoint(first)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseCodePoint(last)
+		if err != nil {
+			return nil, err
+		}
+		if to < from {
+			return nil, fmt.Errorf("invalid range '%s': the end is before the start", part)
+		}
+		ranges = append(ranges, runeRange{from, to})
+	}
+	return ranges, nil
+}
+
+func parseCodePoint(s string) (rune, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "U+")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || v > 0x10FFFF {
+		return 0, fmt.Errorf("invalid code point '%s' - expected hexadecimal e.g. E0B0", s)
+	}
+	return rune(v), nil
+}
+
+func inRanges(r rune, ranges []runeRange) bool {
+	for _, rr := range ranges {
+		if r >= rr.first && r <= rr.last {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	tagCmap = ot.MustNewTag("cmap")
+	tagDSIG = ot.MustNewTag("DSIG")
+	tagGlyf = ot.MustNewTag("glyf")
+	tagHead = ot.MustNewTag("head")
+	tagLoca = ot.MustNewTag("loca")
+	tagMaxp = ot.MustNewTag("maxp")
+)
+
+// subset removes the outlines of glyphs for characters outside the ranges, and their entries in the character map.
+// Glyph ids are unchanged, so that the metrics, kerning and layout tables stay valid without being rewritten.
+// Glyphs which no character maps to are kept, as they may be ligatures, alternates or parts of other glyphs.
+func subset(data []byte, ranges []runeRange) ([]byte, error) {
+
+	ld, err := ot.NewLoader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if !ld.HasTable(tagGlyf) || !ld.HasTable(tagLoca) {
+		return nil, fmt.Errorf("only fonts with TrueType outlines can be subset")
+	}
+	fnt, err := gotext.NewFont(ld)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := ld.RawTable(tagHead)
+	if err != nil || len(head) < 54 {
+		return nil, fmt.Errorf("invalid head table")
+	}
+	maxp, err := ld.RawTable(tagMaxp)
+	if err != nil || len(maxp) < 6 {
+		return nil, fmt.Errorf("invalid maxp table")
+	}
+	glyf, _ := ld.RawTable(tagGlyf)
+	loca, _ := ld.RawTable(tagLoca)
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	offsets, err := parseLoca(loca, numGlyphs, int16(binary.BigEndian.Uint16(head[50:])) == 1, len(glyf))
+	if err != nil {
+		return nil, err
+	}
+
+	// choose the glyphs to keep - the notdef glyph, glyphs for the characters in range and unmapped glyphs
+	keep := make([]bool, numGlyphs)
+	mapped := make([]bool, numGlyphs)
+	var mappings []mapping
+	iter := fnt.Cmap.Iter()
+	for iter.Next() {
+		r, gid := iter.Char()
+		if int(gid) >= numGlyphs {
+			continue
+		}
+		mapped[gid] = true
+		if inRanges(r, ranges) {
+			keep[gid] = true
+			mappings = append(mappings, mapping{r: r, gid: uint16(gid)})
+		}
+	}
+	keep[0] = true
+	var pending []int
+	for gid := range keep {
+		if !mapped[gid] {
+			keep[gid] = true
+		}
+		if keep[gid] {
+			pending = append(pending, gid)
+		}
+	}
+
+	// composite glyphs are drawn from other glyphs, which must be kept too
+	for len(pending) > 0 {
+		gid := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, component := range components(glyf[offsets[gid]:offsets[gid+1]]) {
+			if int(component) < numGlyphs && !keep[component] {
+				keep[component] = true
+				pending = append(pending, int(component))
+			}
+		}
+	}
+
+	// glyphs are padded to four bytes, and the long loca format is always used
+	var newGlyf []byte
+	newLoca := make([]byte, 4*(numGlyphs+1))
+	for gid := 0; gid < numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(newLoca[4*gid:], uint32(len(newGlyf)))
+		if keep[gid] {
+			newGlyf = append(newGlyf, glyf[offsets[gid]:offsets[gid+1]]...)
+			for len(newGlyf)%4 != 0 {
+				newGlyf = append(newGlyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*numGlyphs:], uint32(len(newGlyf)))
+
+	newHead := append([]byte(nil), head...)
+	binary.BigEndian.PutUint16(newHead[50:], 1)
+	binary.BigEndian.PutUint32(newHead[8:], 0) // checkSumAdjustment, set once the file is written
+
+	newCmap, err := buildCmap(mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []ot.Table
+	for _, tag := range ld.Tables() {
+		var content []byte
+		switch tag {
+		case tagDSIG:
+			// the signature no longer matches
+			continue
+		case tagGlyf:
+			content = newGlyf
+		case tagLoca:
+			content = newLoca
+		case tagHead:
+			content = newHead
+		case tagCmap:
+			content = newCmap
+		default:
+			if content, err = ld.RawTable(tag); err != nil {
+				return nil, err
+			}
+		}
+		tables = append(tables, ot.Table{Tag: tag, Content: content})
+	}
+
+	return writeFont(tables), nil
+}
+
+// parseLoca returns the offset of each glyph in the glyf table, with the end of the last glyph at the end
+func parseLoca(loca []byte, numGlyphs int, long bool, glyfLength int) ([]int, error) {
+	offsets := make([]int, numGlyphs+1)
+	for i := range offsets {
+		if long {
+			if len(loca) < 4*(i+1) {
+				return nil, fmt.Errorf("invalid loca table")
+			}
+			offsets[i] = int(binary.BigEndian.Uint32(loca[4*i:]))
+		} else {
+			if len(loca) < 2*(i+1) {
+				return nil, fmt.Errorf("invalid loca table")
+			}
+			offsets[i] = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+		if offsets[i] > glyfLength || (i > 0 && offsets[i] < offsets[i-1]) {
+			return nil, fmt.Errorf("invalid loca table")
+		}
+	}
+	return offsets, nil
+}
+
+// components returns the glyphs which a composite glyph is made from, or nothing for a simple glyph
+func components(glyph []byte) []uint16 {
+	const (
+		argsAreWords    = 0x0001
+		haveScale       = 0x0008
+		moreComponents  = 0x0020
+		haveXYScale     = 0x0040
+		haveTwoByTwo    = 0x0080
+		headerSize      = 10
+		componentHeader = 4
+	)
+	if len(glyph) < headerSize || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+	var gids []uint16
+	for p := headerSize; p+componentHeader <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[p:])
+		gids = append(gids, binary.BigEndian.Uint16(glyph[p+2:]))
+		p += componentHeader
+		if flags&argsAreWords != 0 {
+			p += 4
+		} else {
+			p += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			p += 2
+		case flags&haveXYScale != 0:
+			p += 4
+		case flags&haveTwoByTwo != 0:
+			p += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return gids
+}
+
+type mapping struct {
+	r   rune
+	gid uint16
+}
+
+// buildCmap creates a character map with a format 4 subtable for the Basic Multilingual Plane and a format 12
+// subtable for all characters
+func buildCmap(mappings []mapping) ([]byte, error) {
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].r < mappings[j].r })
+
+	var bmp []mapping
+	for _, m := range mappings {
+		if m.r <= 0xFFFF {
+			bmp = append(bmp, m)
+		}
+	}
+	format4 := buildFormat4(bmp)
+	if len(format4) > 0xFFFF {
+		return nil, fmt.Errorf("too many characters are kept for the character map")
+	}
+	format12 := buildFormat12(mappings)
+
+	const headerSize = 4 + 2*8
+	cmap := make([]byte, headerSize)
+	binary.BigEndian.PutUint16(cmap[2:], 2) // version 0, two subtables
+	binary.BigEndian.PutUint16(cmap[4:], 3) // Windows, Unicode BMP
+	binary.BigEndian.PutUint16(cmap[6:], 1)
+	binary.BigEndian.PutUint32(cmap[8:], headerSize)
+	binary.BigEndian.PutUint16(cmap[12:], 3) // Windows, Unicode full repertoire
+	binary.BigEndian.PutUint16(cmap[14:], 10)
+	binary.BigEndian.PutUint32(cmap[16:], uint32(headerSize+len(format4)))
+	cmap = append(cmap, format4...)
+	return append(cmap, format12...), nil
+}
+
+type segment struct {
+	start, end rune
+	delta      uint16
+}
+
+func buildFormat4(mappings []mapping) []byte {
+
+	// consecutive characters mapped to consecutive glyphs share a segment
+	var segments []segment
+	for _, m := range mappings {
+		delta := uint16(m.gid - uint16(m.r))
+		if n := len(segments); n > 0 && segments[n-1].end == m.r-1 && segments[n-1].delta == delta {
+			segments[n-1].end = m.r
+			continue
+		}
+		segments = append(segments, segment{start: m.r, end: m.r, delta: delta})
+	}
+	// the last segment must map 0xFFFF to the notdef glyph
+	segments = append(segments, segment{start: 0xFFFF, end: 0xFFFF, delta: 1})
+
+	segCount := len(segments)
+	searchRange := 2
+	entrySelector := 0
+	for searchRange*2 <= 2*segCount {
+		searchRange *= 2
+		entrySelector++
+	}
+
+	length := 16 + 8*segCount
+	b := make([]byte, length)
+	binary.BigEndian.PutUint16(b[0:], 4)
+	binary.BigEndian.PutUint16(b[2:], uint16(length))
+	binary.BigEndian.PutUint16(b[6:], uint16(2*segCount))
+	binary.BigEndian.PutUint16(b[8:], uint16(searchRange))
+	binary.BigEndian.PutUint16(b[10:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(b[12:], uint16(2*segCount-searchRange))
+	ends := 14
+	starts := ends + 2*segCount + 2 // after the reserved pad
+	deltas := starts + 2*segCount
+	// the range offsets which follow are all zero, as glyphs are found with the deltas alone
+	for i, s := range segments {
+		binary.BigEndian.PutUint16(b[ends+2*i:], uint16(s.end))
+		binary.BigEndian.PutUint16(b[starts+2*i:], uint16(s.start))
+		binary.BigEndian.PutUint16(b[deltas+2*i:], s.delta)
+	}
+	return b
+}
+
+func buildFormat12(mappings []mapping) []byte {
+	type group struct {
+		start, end rune
+		gid        uint16
+	}
+	var groups []group
+	for _, m := range mappings {
+		if n := len(groups); n > 0 {
+			g := &groups[n-1]
+			if g.end == m.r-1 && rune(g.gid)+(m.r-g.start) == rune(m.gid) {
+				g.end = m.r
+				continue
+			}
+		}
+		groups = append(groups, group{start: m.r, end: m.r, gid: m.gid})
+	}
+
+	length := 16 + 12*len(groups)
+	b := make([]byte, length)
+	binary.BigEndian.PutUint16(b[0:], 12)
+	binary.BigEndian.PutUint32(b[4:], uint32(length))
+	binary.BigEndian.PutUint32(b[12:], uint32(len(groups)))
+	for i, g := range groups {
+		p := 16 + 12*i
+		binary.BigEndian.PutUint32(b[p:], uint32(g.start))
+		binary.BigEndian.PutUint32(b[p+4:], uint32(g.end))
+		binary.BigEndian.PutUint32(b[p+8:], uint32(g.gid))
+	}
+	return b
+}
+
+// writeFont writes the tables, which must be sorted by tag, as a TrueType font file. Tables are padded to four
+// bytes, and the checksum adjustment of the head table is set so that the file sums to the magic number.
+func writeFont(tables []ot.Table) []byte {
+	headerSize := 12 + 16*len(tables)
+	out := make([]byte, headerSize)
+
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= len(tables) {
+		searchRange *= 2
+		entrySelector++
+	}
+	binary.BigEndian.PutUint32(out[0:], 0x00010000)
+	binary.BigEndian.PutUint16(out[4:], uint16(len(tables)))
+	binary.BigEndian.PutUint16(out[6:], uint16(searchRange*16))
+	binary.BigEndian.PutUint16(out[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(out[10:], uint16(len(tables)*16-searchRange*16))
+
+	headOffset := -1
+	for i, table := range tables {
+		record := out[12+16*i:]
+		binary.BigEndian.PutUint32(record[0:], uint32(table.Tag))
+		binary.BigEndian.PutUint32(record[4:], checksum(table.Content))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table.Content)))
+		if table.Tag == tagHead {
+			headOffset = len(out)
+		}
+		out = append(out, table.Content...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+
+	if headOffset >= 0 {
+		binary.BigEndian.PutUint32(out[headOffset+8:], 0xB1B0AFBA-checksum(out))
+	}
+	return out
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
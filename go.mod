@@ -1,21 +1,39 @@
 module github.com/liamg/darktile
 
-go 1.16
+go 1.17
 
 require (
+	github.com/BurntSushi/xgb v0.0.0-20200324125942-20f126ea2843
 	github.com/creack/pty v1.1.12
 	github.com/d-tsuji/clipboard v0.0.3
-	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20210727001814-0db043d8d5be // indirect
+	github.com/go-text/typesetting v0.2.1
 	github.com/hajimehoshi/ebiten/v2 v2.2.0-alpha.11.0.20210724070913-1706d9436a78
 	github.com/liamg/fontinfo v0.1.3
-	github.com/mvdan/xurls v1.1.0 // indirect
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 	github.com/spf13/cobra v1.1.3
 	github.com/stretchr/testify v1.7.0
-	golang.org/x/exp v0.0.0-20210729172720-737cce5152fc // indirect
-	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
-	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
-	golang.org/x/term v0.0.0-20201126162022-7de9c90e9dd1
+	golang.org/x/image v0.3.0
+	golang.org/x/sys v0.7.0
+	golang.org/x/term v0.7.0
+	golang.org/x/text v0.9.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 	mvdan.cc/xurls v1.1.0
 )
+
+require (
+	git.wow.st/gmp/clip v0.0.0-20191001134149-1458ba6a7cf5 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20210727001814-0db043d8d5be // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/lxn/walk v0.0.0-20191128110447-55ccb3a9f5c1 // indirect
+	github.com/lxn/win v0.0.0-20191128105842-2da648fda5b4 // indirect
+	github.com/mvdan/xurls v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/exp v0.0.0-20210729172720-737cce5152fc // indirect
+	golang.org/x/mobile v0.0.0-20210716004757-34ab1303b554 // indirect
+	golang.org/x/sync v0.1.0 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
+)
@@ -0,0 +1,98 @@
+package clipboard
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Selection identifies which system selection to read from or write to
+type Selection uint8
+
+const (
+	// Clipboard is used by explicit copy and paste actions
+	Clipboard Selection = iota
+	// Primary is set by selecting text and pasted with a middle-click
+	Primary
+)
+
+const (
+	BackendAuto    = "auto"
+	BackendX11     = "x11"
+	BackendWayland = "wl-clipboard"
+	BackendXClip   = "xclip"
+)
+
+// Content is offered to other applications in each of the formats which are non-empty
+type Content struct {
+	Text string
+	HTML string
+}
+
+type backend interface {
+	set(selection Selection, content Content) error
+	get(selection Selection) (string, error)
+	// close releases any connection held by the backend once it is no longer used
+	close() error
+}
+
+var (
+	mu         sync.Mutex
+	active     backend
+	activeName = BackendAuto
+)
+
+// UseBackend chooses how the system clipboard is accessed - the backend is created when the clipboard is first used.
+// Choosing a different backend closes the current one, while choosing the same backend again keeps it.
+func UseBackend(name string) error {
+	switch name {
+	case "":
+		name = BackendAuto
+	case BackendAuto, BackendX11, BackendWayland, BackendXClip:
+	default:
+		return fmt.Errorf("unknown clipboard backend '%s'", name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if name == activeName {
+		return nil
+	}
+	activeName = name
+	if active == nil {
+		return nil
+	}
+	err := active.close()
+	active = nil
+	return err
+}
+
+func current() (backend, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if active == nil {
+		b, err := newBackend(activeName)
+		if err != nil {
+			return nil, err
+		}
+		active = b
+	}
+	return active, nil
+}
+
+// Set places the given content on the given selection
+func Set(selection Selection, content Content) error {
+	b, err := current()
+	if err != nil {
+		return err
+	}
+	return b.set(selection, content)
+}
+
+// Get returns the text content of the given selection
+func Get(selection Selection) (string, error) {
+	b, err := current()
+	if err != nil {
+		return "", err
+	}
+	return b.get(selection)
+}
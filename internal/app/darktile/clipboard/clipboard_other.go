@@ -0,0 +1,37 @@
+//go:build !linux && !freebsd && !openbsd && !netbsd && !dragonfly
+
+package clipboard
+
+import (
+	"fmt"
+
+	"github.com/d-tsuji/clipboard"
+)
+
+func newBackend(name string) (backend, error) {
+	if name != BackendAuto {
+		return nil, fmt.Errorf("clipboard backend '%s' is not supported on this platform", name)
+	}
+	return &systemBackend{}, nil
+}
+
+// systemBackend supports plain text only, and there is no primary selection on non-X11 platforms
+type systemBackend struct{}
+
+func (s *systemBackend) set(selection Selection, content Content) error {
+	if selection != Clipboard {
+		return nil
+	}
+	return clipboard.Set(content.Text)
+}
+
+func (s *systemBackend) get(selection Selection) (string, error) {
+	if selection != Clipboard {
+		return "", nil
+	}
+	return clipboard.Get()
+}
+
+func (s *systemBackend) close() error {
+	return nil
+}
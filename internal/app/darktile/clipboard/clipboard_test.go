@@ -0,0 +1,63 @@
+package clipboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	closed int
+}
+
+func (f *fakeBackend) set(selection Selection, content Content) error { return nil }
+func (f *fakeBackend) get(selection Selection) (string, error)        { return "", nil }
+func (f *fakeBackend) close() error {
+	f.closed++
+	return nil
+}
+
+// useFakeBackend makes a fake the active backend for the given backend name
+func useFakeBackend(t *testing.T, name string) *fakeBackend {
+	fake := &fakeBackend{}
+	mu.Lock()
+	active, activeName = fake, name
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		active, activeName = nil, BackendAuto
+		mu.Unlock()
+	})
+	return fake
+}
+
+func TestUseSameBackendKeepsIt(t *testing.T) {
+	fake := useFakeBackend(t, BackendXClip)
+
+	require.NoError(t, UseBackend(BackendXClip))
+	assert.Zero(t, fake.closed)
+	assert.Same(t, fake, active)
+
+	fake = useFakeBackend(t, BackendAuto)
+	require.NoError(t, UseBackend(""))
+	assert.Zero(t, fake.closed)
+	assert.Same(t, fake, active)
+}
+
+func TestUseDifferentBackendClosesCurrent(t *testing.T) {
+	fake := useFakeBackend(t, BackendX11)
+
+	require.NoError(t, UseBackend(BackendXClip))
+	assert.Equal(t, 1, fake.closed)
+	assert.Nil(t, active)
+	assert.Equal(t, BackendXClip, activeName)
+}
+
+func TestUseUnknownBackendKeepsCurrent(t *testing.T) {
+	fake := useFakeBackend(t, BackendX11)
+
+	require.Error(t, UseBackend("pbcopy"))
+	assert.Zero(t, fake.closed)
+	assert.Same(t, fake, active)
+}
@@ -0,0 +1,29 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+
+package clipboard
+
+import "os"
+
+func newBackend(name string) (backend, error) {
+	switch name {
+	case BackendX11:
+		return newX11()
+	case BackendWayland:
+		return newCommandBackend(wlClipboard)
+	case BackendXClip:
+		return newCommandBackend(xclip)
+	}
+
+	// prefer talking to the X server directly, which supports rich content
+	if os.Getenv("DISPLAY") != "" {
+		if x, err := newX11(); err == nil {
+			return x, nil
+		}
+	}
+
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return newCommandBackend(wlClipboard)
+	}
+
+	return newX11()
+}
@@ -0,0 +1,90 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// commandSpec describes the command lines used to write and read a selection with an external tool
+type commandSpec struct {
+	set func(selection Selection) []string
+	get func(selection Selection) []string
+}
+
+var wlClipboard = commandSpec{
+	set: func(selection Selection) []string {
+		if selection == Primary {
+			return []string{"wl-copy", "--primary"}
+		}
+		return []string{"wl-copy"}
+	},
+	get: func(selection Selection) []string {
+		if selection == Primary {
+			return []string{"wl-paste", "--no-newline", "--primary"}
+		}
+		return []string{"wl-paste", "--no-newline"}
+	},
+}
+
+var xclip = commandSpec{
+	set: func(selection Selection) []string {
+		return []string{"xclip", "-selection", xclipSelection(selection), "-in"}
+	},
+	get: func(selection Selection) []string {
+		return []string{"xclip", "-selection", xclipSelection(selection), "-out"}
+	},
+}
+
+func xclipSelection(selection Selection) string {
+	if selection == Primary {
+		return "primary"
+	}
+	return "clipboard"
+}
+
+// commandBackend uses external tools to access selections - only plain text is supported
+type commandBackend struct {
+	spec commandSpec
+}
+
+func newCommandBackend(spec commandSpec) (*commandBackend, error) {
+	for _, args := range [][]string{spec.set(Clipboard), spec.get(Clipboard)} {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			return nil, fmt.Errorf("clipboard tool '%s' is not installed", args[0])
+		}
+	}
+	return &commandBackend{spec: spec}, nil
+}
+
+func (c *commandBackend) set(selection Selection, content Content) error {
+	args := c.spec.set(selection)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(content.Text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+func (c *commandBackend) get(selection Selection) (string, error) {
+	args := c.spec.get(selection)
+	var stdout bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		// the tools exit non-zero when the selection is empty
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil
+		}
+		return "", fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+func (c *commandBackend) close() error {
+	return nil
+}
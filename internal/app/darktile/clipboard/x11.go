@@ -0,0 +1,433 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+
+package clipboard
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// how long to wait for the selection owner to respond to a request for its content
+const selectionTimeout = time.Second
+
+const (
+	atomClipboard  = "CLIPBOARD"
+	atomPrimary    = "PRIMARY"
+	atomTargets    = "TARGETS"
+	atomUTF8       = "UTF8_STRING"
+	atomString     = "STRING"
+	atomText       = "TEXT"
+	atomTextPlain  = "text/plain"
+	atomTextUTF8   = "text/plain;charset=utf-8"
+	atomHTML       = "text/html"
+	atomIncr       = "INCR"
+	atomAtom       = "ATOM"
+	atomTransferTo = "DARKTILE_SELECTION"
+)
+
+var textTargets = []string{atomUTF8, atomString, atomText, atomTextPlain, atomTextUTF8}
+
+// x11 owns selections on behalf of darktile and responds to requests for their content from other applications
+type x11 struct {
+	mu         sync.Mutex
+	conn       *xgb.Conn
+	window     xproto.Window
+	atoms      map[string]xproto.Atom
+	contents   map[xproto.Atom]Content
+	notify     chan xproto.SelectionNotifyEvent
+	properties chan xproto.PropertyNotifyEvent // changes to the properties of our window, for incremental reads
+	transfers  map[transferKey]*transfer       // only used by handleEvents
+	maxBytes   int
+}
+
+// transfer is content being sent to another application in chunks with the INCR protocol, as it is too large to
+// fit in a single property
+type transfer struct {
+	dataType xproto.Atom
+	data     []byte
+	updated  time.Time
+}
+
+type transferKey struct {
+	window   xproto.Window
+	property xproto.Atom
+}
+
+func newX11() (*x11, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to X server: %w", err)
+	}
+
+	window, err := xproto.NewWindowId(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	if err := xproto.CreateWindowChecked(
+		conn, 0, window, screen.Root, 0, 0, 1, 1, 0, xproto.WindowClassInputOnly, 0,
+		xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange},
+	).Check(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create selection window: %w", err)
+	}
+
+	x := &x11{
+		conn:       conn,
+		window:     window,
+		atoms:      make(map[string]xproto.Atom),
+		contents:   make(map[xproto.Atom]Content),
+		notify:     make(chan xproto.SelectionNotifyEvent, 1),
+		properties: make(chan xproto.PropertyNotifyEvent, 16),
+		transfers:  make(map[transferKey]*transfer),
+		// leave some room for the request header - larger content is transferred incrementally
+		maxBytes: int(xproto.Setup(conn).MaximumRequestLength)*4 - 64,
+	}
+
+	for _, name := range append(
+		[]string{atomClipboard, atomPrimary, atomTargets, atomHTML, atomIncr, atomAtom, atomTransferTo},
+		textTargets...,
+	) {
+		reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to intern atom '%s': %w", name, err)
+		}
+		x.atoms[name] = reply.Atom
+	}
+
+	go x.handleEvents()
+
+	return x, nil
+}
+
+func (x *x11) set(selection Selection, content Content) error {
+	return x.own(selectionName(selection), content)
+}
+
+func (x *x11) get(selection Selection) (string, error) {
+	return x.read(selectionName(selection))
+}
+
+// close destroys the selection window and disconnects from the X server, which stops handleEvents
+func (x *x11) close() error {
+	err := xproto.DestroyWindowChecked(x.conn, x.window).Check()
+	x.conn.Close()
+	return err
+}
+
+func selectionName(selection Selection) string {
+	if selection == Primary {
+		return atomPrimary
+	}
+	return atomClipboard
+}
+
+func (x *x11) own(selection string, content Content) error {
+	selectionAtom := x.atoms[selection]
+
+	x.mu.Lock()
+	x.contents[selectionAtom] = content
+	x.mu.Unlock()
+
+	if err := x.takeOwnership(selectionAtom); err != nil {
+		x.mu.Lock()
+		delete(x.contents, selectionAtom)
+		x.mu.Unlock()
+		return fmt.Errorf("failed to take ownership of %s: %w", selection, err)
+	}
+
+	return nil
+}
+
+func (x *x11) takeOwnership(selectionAtom xproto.Atom) error {
+	if err := xproto.SetSelectionOwnerChecked(x.conn, x.window, selectionAtom, xproto.TimeCurrentTime).Check(); err != nil {
+		return err
+	}
+	reply, err := xproto.GetSelectionOwner(x.conn, selectionAtom).Reply()
+	if err != nil {
+		return err
+	}
+	if reply.Owner != x.window {
+		return fmt.Errorf("another application owns the selection")
+	}
+	return nil
+}
+
+func (x *x11) read(selection string) (string, error) {
+	selectionAtom := x.atoms[selection]
+
+	// no need to ask the X server if we own the selection
+	x.mu.Lock()
+	content, owned := x.contents[selectionAtom]
+	x.mu.Unlock()
+	if owned {
+		return content.Text, nil
+	}
+
+	// discard any stale notifications from a request which previously timed out
+	select {
+	case <-x.notify:
+	default:
+	}
+	for len(x.properties) > 0 {
+		<-x.properties
+	}
+
+	if err := xproto.ConvertSelectionChecked(
+		x.conn, x.window, selectionAtom, x.atoms[atomUTF8], x.atoms[atomTransferTo], xproto.TimeCurrentTime,
+	).Check(); err != nil {
+		return "", fmt.Errorf("failed to request %s content: %w", selection, err)
+	}
+
+	select {
+	case event := <-x.notify:
+		if event.Property == xproto.AtomNone {
+			// the owner could not provide the content as text
+			return "", nil
+		}
+	case <-time.After(selectionTimeout):
+		return "", fmt.Errorf("timed out waiting for %s content", selection)
+	}
+
+	dataType, data, err := x.takeProperty()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s content: %w", selection, err)
+	}
+	if dataType == x.atoms[atomIncr] {
+		// deleting the property has asked the owner to start sending the content in chunks
+		if data, err = x.readIncrementally(selection); err != nil {
+			return "", err
+		}
+	}
+
+	return string(data), nil
+}
+
+// takeProperty reads and deletes the property of our window which selection content is transferred to. The type
+// is xproto.AtomNone if there is no such property.
+func (x *x11) takeProperty() (xproto.Atom, []byte, error) {
+	var data []byte
+	for {
+		reply, err := xproto.GetProperty(
+			x.conn, true, x.window, x.atoms[atomTransferTo], xproto.GetPropertyTypeAny,
+			uint32(len(data)/4), uint32(x.maxBytes/4),
+		).Reply()
+		if err != nil {
+			return 0, nil, err
+		}
+		data = append(data, reply.Value...)
+		// the property is only deleted once the last of it has been read
+		if reply.BytesAfter == 0 {
+			return reply.Type, data, nil
+		}
+	}
+}
+
+// readIncrementally reads content sent with the INCR protocol. The owner writes each chunk to the property once
+// the previous one has been deleted, and an empty chunk marks the end.
+func (x *x11) readIncrementally(selection string) ([]byte, error) {
+	var content []byte
+	for {
+		select {
+		case e := <-x.properties:
+			if e.Atom != x.atoms[atomTransferTo] || e.State != xproto.PropertyNewValue {
+				continue
+			}
+		case <-time.After(selectionTimeout):
+			return nil, fmt.Errorf("timed out waiting for %s content", selection)
+		}
+
+		dataType, chunk, err := x.takeProperty()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s content: %w", selection, err)
+		}
+		if dataType == xproto.AtomNone {
+			// the event was for a chunk which has already been read
+			continue
+		}
+		if len(chunk) == 0 {
+			return content, nil
+		}
+		content = append(content, chunk...)
+	}
+}
+
+func (x *x11) handleEvents() {
+	for {
+		event, err := x.conn.WaitForEvent()
+		if event == nil && err == nil {
+			// connection closed
+			return
+		}
+		switch e := event.(type) {
+		case xproto.SelectionRequestEvent:
+			x.respond(e)
+		case xproto.SelectionClearEvent:
+			x.mu.Lock()
+			delete(x.contents, e.Selection)
+			x.mu.Unlock()
+		case xproto.SelectionNotifyEvent:
+			select {
+			case x.notify <- e:
+			default:
+			}
+		case xproto.PropertyNotifyEvent:
+			if e.Window == x.window {
+				select {
+				case x.properties <- e:
+				default:
+				}
+			} else if e.State == xproto.PropertyDelete {
+				x.continueTransfer(transferKey{window: e.Window, property: e.Atom})
+			}
+		}
+	}
+}
+
+// respond provides selection content to another application in the requested format
+func (x *x11) respond(e xproto.SelectionRequestEvent) {
+
+	property := e.Property
+	if property == xproto.AtomNone {
+		// obsolete clients expect the target to be used as the property
+		property = e.Target
+	}
+
+	x.mu.Lock()
+	content, owned := x.contents[e.Selection]
+	x.mu.Unlock()
+
+	var data []byte
+	var dataType xproto.Atom
+	var format byte = 8
+	var count int
+
+	switch {
+	case !owned:
+	case e.Target == x.atoms[atomTargets]:
+		targets := []xproto.Atom{x.atoms[atomTargets]}
+		for _, name := range textTargets {
+			targets = append(targets, x.atoms[name])
+		}
+		if content.HTML != "" {
+			targets = append(targets, x.atoms[atomHTML])
+		}
+		data = make([]byte, len(targets)*4)
+		for i, target := range targets {
+			xgb.Put32(data[i*4:], uint32(target))
+		}
+		dataType, format, count = x.atoms[atomAtom], 32, len(targets)
+	case e.Target == x.atoms[atomHTML] && content.HTML != "":
+		data, dataType = []byte(content.HTML), e.Target
+		count = len(data)
+	case x.isTextTarget(e.Target):
+		data, dataType = []byte(content.Text), e.Target
+		count = len(data)
+	}
+
+	if data == nil {
+		property = xproto.AtomNone
+	} else if len(data) > x.maxBytes && format == 8 {
+		if err := x.startTransfer(e.Requestor, property, dataType, data); err != nil {
+			property = xproto.AtomNone
+		}
+	} else if err := xproto.ChangePropertyChecked(
+		x.conn, xproto.PropModeReplace, e.Requestor, property, dataType, format, uint32(count), data,
+	).Check(); err != nil {
+		property = xproto.AtomNone
+	}
+
+	notify := xproto.SelectionNotifyEvent{
+		Time:      e.Time,
+		Requestor: e.Requestor,
+		Selection: e.Selection,
+		Target:    e.Target,
+		Property:  property,
+	}
+	_ = xproto.SendEventChecked(x.conn, false, e.Requestor, 0, string(notify.Bytes())).Check()
+}
+
+// startTransfer begins sending content which is too large for a single property with the INCR protocol. The
+// property is set to the size of the content, and each time the requestor deletes it the next chunk is written.
+func (x *x11) startTransfer(requestor xproto.Window, property xproto.Atom, dataType xproto.Atom, data []byte) error {
+
+	// forget transfers which the requestor has given up on
+	for key, t := range x.transfers {
+		if time.Since(t.updated) > selectionTimeout {
+			x.endTransfer(key)
+		}
+	}
+
+	if err := xproto.ChangeWindowAttributesChecked(
+		x.conn, requestor, xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange},
+	).Check(); err != nil {
+		return err
+	}
+
+	size := make([]byte, 4)
+	xgb.Put32(size, uint32(len(data)))
+	if err := xproto.ChangePropertyChecked(
+		x.conn, xproto.PropModeReplace, requestor, property, x.atoms[atomIncr], 32, 1, size,
+	).Check(); err != nil {
+		return err
+	}
+
+	x.transfers[transferKey{window: requestor, property: property}] = &transfer{
+		dataType: dataType,
+		data:     data,
+		updated:  time.Now(),
+	}
+	return nil
+}
+
+// continueTransfer writes the next chunk of a transfer once the requestor has deleted the previous one
+func (x *x11) continueTransfer(key transferKey) {
+	t, ok := x.transfers[key]
+	if !ok {
+		return
+	}
+
+	chunk := t.data
+	if len(chunk) > x.maxBytes {
+		chunk = chunk[:x.maxBytes]
+	}
+	t.data = t.data[len(chunk):]
+	t.updated = time.Now()
+
+	err := xproto.ChangePropertyChecked(
+		x.conn, xproto.PropModeReplace, key.window, key.property, t.dataType, 8, uint32(len(chunk)), chunk,
+	).Check()
+
+	// the empty chunk which marks the end has been written, or the requestor has gone away
+	if len(chunk) == 0 || err != nil {
+		x.endTransfer(key)
+	}
+}
+
+// endTransfer forgets a transfer, and stops listening for property changes on the requestor's window if it has
+// no other transfers in progress
+func (x *x11) endTransfer(key transferKey) {
+	delete(x.transfers, key)
+	for other := range x.transfers {
+		if other.window == key.window {
+			return
+		}
+	}
+	_ = xproto.ChangeWindowAttributesChecked(x.conn, key.window, xproto.CwEventMask, []uint32{0}).Check()
+}
+
+func (x *x11) isTextTarget(target xproto.Atom) bool {
+	for _, name := range textTargets {
+		if x.atoms[name] == target {
+			return true
+		}
+	}
+	return false
+}
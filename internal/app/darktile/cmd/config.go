@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/liamg/darktile/internal/app/darktile/config"
+	"github.com/liamg/darktile/internal/app/darktile/gui/keybinding"
+	"github.com/spf13/cobra"
+)
+
+var validateConfigPath string
+
+func init() {
+	validateConfigCmd.Flags().StringVar(&validateConfigPath, "config", validateConfigPath, "Path to the config file to validate - defaults to the config file in your config directory")
+	validateConfigCmd.Flags().StringVar(&themePath, "theme-path", themePath, "Path to the theme file to validate - defaults to the theme file in your config directory")
+	configCmd.AddCommand(validateConfigCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage darktile configuration",
+}
+
+var validateConfigCmd = &cobra.Command{
+	Use:          "validate",
+	Short:        "Check the config and theme files for unknown keys and invalid values",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+
+		var problems []config.Problem
+		var fileNotFound *config.ErrorFileNotFound
+
+		for _, err := range []error{
+			config.ValidateConfigFile(validateConfigPath, checkKeyBindings),
+			config.ValidateThemeFile(themePath),
+		} {
+			var validationErr *config.ValidationError
+			switch {
+			case err == nil:
+			case errors.As(err, &validationErr):
+				problems = append(problems, validationErr.Problems...)
+			case errors.As(err, &fileNotFound):
+				fmt.Printf("%s does not exist, defaults will be used\n", fileNotFound.Path)
+			default:
+				return err
+			}
+		}
+
+		for _, problem := range problems {
+			fmt.Println(problem.Error())
+		}
+
+		if len(problems) > 0 {
+			return fmt.Errorf("found %d problem(s)", len(problems))
+		}
+
+		fmt.Println("No problems found.")
+		return nil
+	},
+}
+
+func checkKeyBindings(conf *config.Config, report config.Report) {
+	_, errs := keybinding.Parse(conf.KeyBindings)
+	for _, err := range errs {
+		var bindingErr *keybinding.Error
+		if errors.As(err, &bindingErr) {
+			report(bindingErr.Error(), "keybindings", bindingErr.Chord)
+			continue
+		}
+		report(err.Error(), "keybindings")
+	}
+}
@@ -2,13 +2,34 @@ package cmd
 
 import (
 	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/liamg/darktile/internal/app/darktile/config"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/gui/render/software"
+	"github.com/liamg/darktile/internal/app/darktile/packed"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
 	"github.com/liamg/fontinfo"
 	"github.com/spf13/cobra"
 )
 
+var fontsSize float64
+var fontsDPI float64
+var previewOut string
+
 func init() {
 	rootCmd.AddCommand(listFontsCmd)
+
+	fontsCmd.PersistentFlags().Float64Var(&fontsSize, "size", 0, "Font size - defaults to the size in your config")
+	fontsCmd.PersistentFlags().Float64Var(&fontsDPI, "dpi", 0, "DPI - defaults to the DPI in your config")
+	previewFontCmd.Flags().StringVarP(&previewOut, "out", "o", "font-preview.png", "PNG file to write the sample sheet to")
+	fontsCmd.AddCommand(inspectFontCmd)
+	fontsCmd.AddCommand(previewFontCmd)
+	rootCmd.AddCommand(fontsCmd)
 }
 
 var listFontsCmd = &cobra.Command{
@@ -25,6 +46,243 @@ var listFontsCmd = &cobra.Command{
 		for _, font := range fonts {
 			fmt.Println(font.Family)
 		}
+		for _, family := range packed.Families() {
+			fmt.Printf("%s (built in)\n", family)
+		}
+		return nil
+	},
+}
+
+var fontsCmd = &cobra.Command{
+	Use:   "fonts",
+	Short: "Inspect and preview fonts",
+}
+
+var inspectFontCmd = &cobra.Command{
+	Use:          "inspect <family>",
+	Short:        "Show the styles, cell size, Unicode coverage and OpenType features of a font family",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+
+		family := args[0]
+		fonts, err := fontinfo.Match(fontinfo.MatchFamily(family))
+		if err != nil {
+			return err
+		}
+
+		_, builtIn := packed.Lookup(family)
+		if len(fonts) == 0 && !builtIn {
+			return fmt.Errorf("could not find font with family '%s' - run 'darktile list-fonts' to see the installed families", family)
+		}
+
+		// the styles are shown before the family is loaded, as they explain why it can't be
+		fmt.Printf("Family:     %s\n", family)
+		fmt.Println("Styles:")
+		if len(fonts) == 0 {
+			fmt.Printf("  %-14s built in\n", font.StyleRegular)
+		}
+		for _, f := range fonts {
+			note := ""
+			switch font.StyleName(f.Style) {
+			case font.StyleRegular, font.StyleBold, font.StyleItalic, font.StyleBoldItalic:
+			default:
+				note = " (not used)"
+			}
+			fmt.Printf("  %-14s %s%s\n", f.Style, f.Path, note)
+		}
+
+		fontManager, _, err := familyFontManager(family)
+		if err != nil {
+			return err
+		}
+		for _, style := range []struct {
+			style font.Style
+			name  font.StyleName
+		}{
+			{font.Bold, font.StyleBold},
+			{font.Italic, font.StyleItalic},
+			{font.BoldItalic, font.StyleBoldItalic},
+		} {
+			switch {
+			case fontManager.Synthesised(style.style):
+				fmt.Printf("  %-14s synthesised\n", style.name)
+			case len(fonts) == 0:
+				fmt.Printf("  %-14s built in\n", style.name)
+			}
+		}
+
+		cellSize := fontManager.CharSize()
+		fmt.Printf("Cell size:  %dx%d pixels at size %g and %g DPI\n", cellSize.X, cellSize.Y, fontManager.Size(), fontManager.DPI())
+
+		fmt.Println("Coverage:")
+		for _, block := range fontManager.Coverage() {
+			fmt.Printf("  %-40s %5d/%d\n", block.Name, block.Covered, block.Size())
+		}
+
+		var enabled, optional []string
+		for _, tag := range fontManager.Features() {
+			if font.EnabledByDefault(tag) {
+				enabled = append(enabled, tag)
+			} else {
+				optional = append(optional, tag)
+			}
+		}
+		fmt.Printf("Features:   %s\n", joinOrNone(enabled))
+		fmt.Printf("Optional:   %s\n", joinOrNone(optional))
 		return nil
 	},
 }
+
+var previewFontCmd = &cobra.Command{
+	Use:          "preview <family>",
+	Short:        "Draw a sample sheet of ASCII, box drawing, Powerline and ligatures in a font family to a PNG file",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+
+		fontManager, conf, err := familyFontManager(args[0])
+		if err != nil {
+			return err
+		}
+		if err := fontManager.SetFeatures(conf.Font.Features); err != nil {
+			return err
+		}
+
+		variants, errs, err := resolveTheme(conf, "")
+		if err != nil {
+			return fmt.Errorf("failed to load theme: %w", err)
+		}
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+
+		sample := previewSample(args[0], fontManager.Size())
+		lines := strings.Split(sample, "\r\n")
+		var cols int
+		for _, line := range lines {
+			if width := utf8.RuneCountInString(stripSGR(line)); width > cols {
+				cols = width
+			}
+		}
+		cols += 2
+		rows := len(lines) + 1
+
+		terminal := termutil.New(termutil.WithTheme(variants[0].Theme))
+		terminal.Resize(uint16(rows), uint16(cols))
+		terminal.Process([]byte("\x1b[?25l\r\n" + indent(sample)))
+
+		// ligatures are always shown, as they are part of what is being previewed
+		options := software.Options{
+			Opacity:   1,
+			Ligatures: true,
+			Glyphs: software.GlyphOptions{
+				Procedural:    conf.Font.BoxDrawing,
+				LineThickness: conf.Font.LineThickness,
+			},
+			Focused: true,
+		}
+
+		cellSize := fontManager.CharSize()
+		img := image.NewRGBA(image.Rect(0, 0, cols*cellSize.X, rows*cellSize.Y))
+		software.Draw(img, terminal, fontManager, options)
+
+		f, err := os.Create(previewOut)
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to write %s: %w", previewOut, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", previewOut, err)
+		}
+		fmt.Printf("Saved preview of %s to %s\n", args[0], previewOut)
+		return nil
+	},
+}
+
+// familyFontManager loads a font family at the size and DPI given with --size and --dpi, or those in the config.
+// Fallback fonts aren't loaded, so that characters missing from the family are visible.
+func familyFontManager(family string) (*font.Manager, *config.Config, error) {
+	conf, errs := loadConfig()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+	}
+
+	size, dpi := conf.Font.Size, conf.Font.DPI
+	if fontsSize != 0 {
+		size = fontsSize
+	}
+	if fontsDPI != 0 {
+		dpi = fontsDPI
+	}
+
+	fontManager := font.NewManager()
+	if err := fontManager.SetDPI(dpi); err != nil {
+		return nil, nil, err
+	}
+	if err := fontManager.SetSize(size); err != nil {
+		return nil, nil, err
+	}
+	if err := fontManager.SetFontByFamilyName(family); err != nil {
+		return nil, nil, err
+	}
+	return fontManager, conf, nil
+}
+
+// previewSample is the text of the sample sheet, with headings in bold yellow
+func previewSample(family string, size float64) string {
+	heading := func(text string) string {
+		return "\x1b[1;33m" + text + "\x1b[0m"
+	}
+	return strings.Join([]string{
+		heading(fmt.Sprintf("%s %g", family, size)),
+		"",
+		heading("ASCII"),
+		` !"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNO`,
+		"PQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~",
+		"Regular \x1b[1mBold\x1b[0m \x1b[3mItalic\x1b[0m \x1b[1;3mBold Italic\x1b[0m \x1b[4mUnderline\x1b[0m",
+		"",
+		heading("Box drawing"),
+		"┌─┬─┐ ╔═╦═╗ ╭─┬─╮ ┏━┳━┓ ░▒▓█ ▀▄▌▐",
+		"│ │ │ ║ ║ ║ │ ╎ │ ┃ ┃ ┃ ▁▂▃▄▅▆▇█",
+		"├─┼─┤ ╠═╬═╣ ├╌┼╌┤ ┣━╋━┫ ⣿⡇⢸⠉⣀ ⠁⠂⠄",
+		"└─┴─┘ ╚═╩═╝ ╰─┴─╯ ┗━┻━┛ ◢◣◤◥ ▖▗▘▝",
+		"",
+		heading("Powerline"),
+		"\x1b[30;44m main \x1b[34;42m\ue0b0\x1b[30m ~/src \x1b[32;49m\ue0b0\x1b[0m \ue0b1 \ue0b3 \x1b[35m\ue0b2\x1b[30;45m 12:00 \x1b[0m ",
+		"",
+		heading("Ligatures"),
+		"-> => <- <= >= == != === !== :: ... && || |> <| ++ -- ** //",
+		"/* */ <!-- --> <> </> www ## 0xFF 1e10 a*b :=  .. ?. ?? ;;",
+	}, "\r\n")
+}
+
+// indent moves each line of the sample one column in from the left of the image
+func indent(sample string) string {
+	return " " + strings.ReplaceAll(sample, "\r\n", "\r\n ")
+}
+
+// stripSGR removes colour and style escape sequences, so that the width of a line of the sample can be measured
+func stripSGR(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == 0x1b {
+			for i < len(line) && line[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, " ")
+}
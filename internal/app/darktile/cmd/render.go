@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/liamg/darktile/internal/app/darktile/config"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/format"
+	"github.com/liamg/darktile/internal/app/darktile/gui/render/software"
+	"github.com/liamg/darktile/internal/app/darktile/headless"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	"github.com/spf13/cobra"
+)
+
+var renderCols int
+var renderRows int
+var renderThemePath string
+var renderThemeVariant string
+var renderOut string
+var renderQuietMS int
+var renderTimeoutMS int
+
+func init() {
+	renderCmd.Flags().IntVar(&renderCols, "cols", 100, "Width of the terminal in columns")
+	renderCmd.Flags().IntVar(&renderRows, "rows", 30, "Height of the terminal in rows")
+	renderCmd.Flags().StringVar(&renderThemePath, "theme", "", "Path to a theme file to use instead of the default")
+	renderCmd.Flags().StringVar(&renderThemeVariant, "theme-variant", "", "Name of the theme variant to use, e.g. light")
+	renderCmd.Flags().StringVarP(&renderOut, "out", "o", "", "File to write - the format is chosen by the .png, .svg, .html or .ans extension")
+	renderCmd.Flags().IntVar(&renderQuietMS, "quiet-ms", 500, "Render once the program has produced no output for this many milliseconds")
+	renderCmd.Flags().IntVar(&renderTimeoutMS, "timeout-ms", 10000, "Render after this many milliseconds even if the program is still producing output")
+	rootCmd.AddCommand(renderCmd)
+}
+
+var renderCmd = &cobra.Command{
+	Use:          "render [flags] -- command [args...]",
+	Short:        "Run a program in a headless terminal and save an image of the screen once it exits or goes quiet",
+	SilenceUsage: true,
+	Args:         cobra.MinimumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+
+		if renderOut == "" {
+			return fmt.Errorf("an output file must be given with --out")
+		}
+		outputFormat, err := renderFormatFromFilename(renderOut)
+		if err != nil {
+			return err
+		}
+		if renderCols < 1 || renderRows < 1 || renderCols > 0xffff || renderRows > 0xffff {
+			return fmt.Errorf("the terminal size must be between 1 and 65535 columns and rows")
+		}
+
+		conf, errs := loadConfig()
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+
+		theme, err := renderTheme(conf)
+		if err != nil {
+			return fmt.Errorf("failed to load theme: %w", err)
+		}
+
+		fontManager, err := renderFontManager(conf)
+		if err != nil {
+			return err
+		}
+
+		window := headless.NewWindow(renderCols, renderRows, fontManager.CharSize())
+		terminal := termutil.New(
+			termutil.WithTheme(theme),
+			termutil.WithWindowManipulator(window),
+			termutil.WithCommand(args[0], args[1:]...),
+			termutil.WithHeadless(true),
+		)
+
+		err = headless.Run(terminal, headless.Options{
+			Rows:    uint16(renderRows),
+			Cols:    uint16(renderCols),
+			Quiet:   time.Duration(renderQuietMS) * time.Millisecond,
+			Timeout: time.Duration(renderTimeoutMS) * time.Millisecond,
+		})
+		if errors.Is(err, headless.ErrTimeout) {
+			fmt.Fprintf(os.Stderr, "warning: the program was still producing output after %dms\n", renderTimeoutMS)
+		} else if err != nil {
+			return fmt.Errorf("failed to run %s: %w", args[0], err)
+		}
+		defer func() { _ = terminal.Hangup() }()
+
+		switch outputFormat {
+		case "png":
+			err = writePNG(terminal, fontManager, conf)
+		default:
+			err = writeExport(terminal, fontManager, outputFormat, window.GetTitle())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", renderOut, err)
+		}
+		return nil
+	},
+}
+
+// renderTheme loads the theme given with --theme, or the theme file in the config directory, and picks the
+// variant given with --theme-variant
+func renderTheme(conf *config.Config) (*termutil.Theme, error) {
+	variants, errs, err := resolveTheme(conf, renderThemePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+	}
+	if renderThemeVariant == "" {
+		return variants[0].Theme, nil
+	}
+	var names []string
+	for _, variant := range variants {
+		if strings.EqualFold(variant.Name, renderThemeVariant) {
+			return variant.Theme, nil
+		}
+		names = append(names, variant.Name)
+	}
+	return nil, fmt.Errorf("theme has no variant named '%s' - available variants are: %s", renderThemeVariant, strings.Join(names, ", "))
+}
+
+// renderFontManager loads the fonts in the same way as the GUI does from the config
+func renderFontManager(conf *config.Config) (*font.Manager, error) {
+	fontManager := font.NewManager()
+	if err := fontManager.SetDPI(conf.Font.DPI); err != nil {
+		return nil, err
+	}
+	if err := fontManager.SetSize(conf.Font.Size); err != nil {
+		return nil, err
+	}
+	if err := fontManager.SetWeight(conf.Font.Weight); err != nil {
+		return nil, err
+	}
+	if err := fontManager.SetFontByFamilyName(conf.Font.Family); err != nil {
+		return nil, err
+	}
+	if err := fontManager.SetFallbackFamilies(conf.Font.Fallbacks, conf.Font.FallbackDiscovery); err != nil {
+		return nil, err
+	}
+	fontManager.WaitForFallbackDiscovery()
+	if err := fontManager.SetFeatures(conf.Font.Features); err != nil {
+		return nil, err
+	}
+	return fontManager, nil
+}
+
+func writePNG(terminal *termutil.Terminal, fontManager *font.Manager, conf *config.Config) error {
+
+	options := software.Options{
+		Opacity:   conf.Opacity,
+		Ligatures: conf.Font.Ligatures,
+		Colours: software.ColourOptions{
+			MinimumContrast:     conf.Colours.MinimumContrast,
+			BoldIsBright:        conf.Colours.BoldIsBright,
+			BoldInBrightColours: conf.Colours.BoldInBrightColours,
+		},
+		Glyphs: software.GlyphOptions{
+			Procedural:    conf.Font.BoxDrawing,
+			LineThickness: conf.Font.LineThickness,
+		},
+		Focused: true,
+	}
+	if conf.Cursor.Image != "" {
+		img, err := getImageFromFilePath(conf.Cursor.Image)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		} else {
+			options.CursorImage = img
+		}
+	}
+
+	cellSize := fontManager.CharSize()
+	img := image.NewRGBA(image.Rect(0, 0, renderCols*cellSize.X, renderRows*cellSize.Y))
+	software.Draw(img, terminal, fontManager, options)
+
+	f, err := os.Create(renderOut)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeExport(terminal *termutil.Terminal, fontManager *font.Manager, outputFormat string, title string) error {
+	terminal.Lock()
+	buffer := terminal.GetActiveBuffer()
+	lines := buffer.GetViewCells()
+	theme := terminal.Theme()
+
+	var output string
+	switch outputFormat {
+	case "html":
+		output = format.HTMLDocument(lines, theme, title)
+	case "svg":
+		cellSize := fontManager.CharSize()
+		output = format.SVG(lines, theme, format.SVGOptions{
+			FontFamily: fontManager.Family(),
+			FontSize:   fontManager.Size() * fontManager.DPI() / 72,
+			CellWidth:  cellSize.X,
+			CellHeight: cellSize.Y,
+			Ascent:     fontManager.DotDepth(),
+			Columns:    int(buffer.ViewWidth()),
+		})
+	default:
+		output = format.ANSI(lines, theme) + "\n"
+	}
+	terminal.Unlock()
+
+	return ioutil.WriteFile(renderOut, []byte(output), 0644)
+}
+
+func renderFormatFromFilename(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".png":
+		return "png", nil
+	case ".html", ".htm":
+		return "html", nil
+	case ".svg":
+		return "svg", nil
+	case ".ans", ".ansi":
+		return "ans", nil
+	}
+	return "", fmt.Errorf("cannot tell the format of '%s' - use a .png, .svg, .html or .ans extension", filename)
+}
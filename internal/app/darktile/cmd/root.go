@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"image"
 	"os"
+	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/liamg/darktile/internal/app/darktile/clipboard"
 	"github.com/liamg/darktile/internal/app/darktile/config"
 	"github.com/liamg/darktile/internal/app/darktile/gui"
+	"github.com/liamg/darktile/internal/app/darktile/gui/hold"
+	"github.com/liamg/darktile/internal/app/darktile/gui/keybinding"
 	"github.com/liamg/darktile/internal/app/darktile/hinters"
 	"github.com/liamg/darktile/internal/app/darktile/termutil"
 	"github.com/liamg/darktile/internal/app/darktile/version"
@@ -21,29 +27,45 @@ var initialCommand string
 var shell string
 var screenshotAfterMS int
 var screenshotFilename string
+var exportAfterMS int
+var exportFilename string
+var exportScrollback bool
 var themePath string
+var themeVariant string
+var profileName string
+var workingDirectory string
+var envVars []string
+var loginShell bool
+var cleanEnv bool
+var holdMode string
+var propagateExitCode bool
 var showVersion bool
 
 var rootCmd = &cobra.Command{
-	Use:          os.Args[0],
+	Use:          os.Args[0] + " [flags] [-- command [args...]]",
 	SilenceUsage: true,
+	Args:         cobra.ArbitraryArgs,
 	RunE: func(c *cobra.Command, args []string) error {
 
+		// only arguments after -- are run as a command, so that mistyped subcommands are still reported
+		if len(args) > 0 && c.ArgsLenAtDash() != 0 {
+			return fmt.Errorf("unknown command '%s' - use 'darktile -- %s' to run a program", args[0], strings.Join(args, " "))
+		}
+		for _, env := range envVars {
+			if !strings.Contains(env, "=") || strings.HasPrefix(env, "=") {
+				return fmt.Errorf("invalid environment variable '%s' - expected KEY=VALUE", env)
+			}
+		}
+
 		if showVersion {
 			fmt.Println(version.Version)
 			os.Exit(0)
 		}
 
 		var startupErrors []error
-		var fileNotFound *config.ErrorFileNotFound
 
-		conf, err := config.LoadConfig()
-		if err != nil {
-			if !errors.As(err, &fileNotFound) {
-				startupErrors = append(startupErrors, err)
-			}
-			conf = config.DefaultConfig()
-		}
+		conf, errs := loadConfig()
+		startupErrors = append(startupErrors, errs...)
 
 		if rewriteConfig {
 			if _, err := conf.Save(); err != nil {
@@ -53,57 +75,68 @@ var rootCmd = &cobra.Command{
 			return nil
 		}
 
-		var theme *termutil.Theme
+		profile, err := applyProfile(conf)
+		if err != nil {
+			return err
+		}
 
-		if themePath != "" {
-			theme, err = config.LoadThemeFromPath(conf, themePath)
-			if err != nil {
+		variants, themeErrs, err := resolveTheme(conf, profileThemePath(profile))
+		startupErrors = append(startupErrors, themeErrs...)
+		if err != nil {
+			if profileThemePath(profile) != "" {
 				return fmt.Errorf("failed to load theme: %s", err)
 			}
-		} else {
-			theme, err = config.LoadTheme(conf)
+			startupErrors = append(startupErrors, err)
+			variants, err = config.DefaultThemeVariants(conf)
 			if err != nil {
-				if !errors.As(err, &fileNotFound) {
-					startupErrors = append(startupErrors, err)
-				}
-				theme, err = config.DefaultTheme(conf)
-				if err != nil {
-					return fmt.Errorf("failed to load default theme: %w", err)
-				}
+				return fmt.Errorf("failed to load default theme: %w", err)
 			}
 		}
 
 		termOpts := []termutil.Option{
-			termutil.WithTheme(theme),
+			termutil.WithTheme(variants[0].Theme),
 		}
 
 		if debugFile != "" {
 			termOpts = append(termOpts, termutil.WithLogFile(debugFile))
 		}
+		// command line flags take precedence over the profile
 		if shell != "" {
 			termOpts = append(termOpts, termutil.WithShell(shell))
+		} else if profile.Shell != "" {
+			termOpts = append(termOpts, termutil.WithShell(profile.Shell))
 		}
 		if initialCommand != "" {
 			termOpts = append(termOpts, termutil.WithInitialCommand(initialCommand))
+		} else if profile.Command != "" {
+			termOpts = append(termOpts, termutil.WithInitialCommand(profile.Command))
+		}
+		if len(profile.Env) > 0 {
+			termOpts = append(termOpts, termutil.WithEnv(profile.EnvList()...))
+		}
+		if len(envVars) > 0 {
+			termOpts = append(termOpts, termutil.WithEnv(envVars...))
 		}
+		if workingDirectory != "" {
+			termOpts = append(termOpts, termutil.WithWorkingDirectory(workingDirectory))
+		} else if profile.WorkingDirectory != "" {
+			termOpts = append(termOpts, termutil.WithWorkingDirectory(profile.WorkingDirectory))
+		}
+		if len(args) > 0 {
+			termOpts = append(termOpts, termutil.WithCommand(args[0], args[1:]...))
+		}
+		termOpts = append(termOpts, termutil.WithLoginShell(loginShell), termutil.WithCleanEnv(cleanEnv))
 
 		terminal := termutil.New(termOpts...)
 
-		options := []gui.Option{
-			gui.WithFontDPI(conf.Font.DPI),
-			gui.WithFontSize(conf.Font.Size),
-			gui.WithFontFamily(conf.Font.Family),
-			gui.WithOpacity(conf.Opacity),
-			gui.WithLigatures(conf.Font.Ligatures),
-		}
+		options, errs := configOptions(conf, nil)
+		startupErrors = append(startupErrors, errs...)
+		options = append(options, gui.WithThemeVariants(themeVariants(variants), themeVariant))
 
-		if conf.Cursor.Image != "" {
-			img, err := getImageFromFilePath(conf.Cursor.Image)
-			if err != nil {
-				startupErrors = append(startupErrors, err)
-			} else {
-				options = append(options, gui.WithCursorImage(img))
-			}
+		if conf.LiveReload {
+			options = append(options, gui.WithStartupFunc(func(g *gui.GUI) {
+				watchConfig(g, conf, profileThemePath(profile))
+			}))
 		}
 
 		if screenshotAfterMS > 0 {
@@ -113,6 +146,13 @@ var rootCmd = &cobra.Command{
 			}))
 		}
 
+		if exportAfterMS > 0 {
+			options = append(options, gui.WithStartupFunc(func(g *gui.GUI) {
+				<-time.After(time.Duration(exportAfterMS) * time.Millisecond)
+				g.RequestExport(exportFilename, exportScrollback)
+			}))
+		}
+
 		// load all hinters
 		for _, hinter := range hinters.All() {
 			options = append(options, gui.WithHinter(hinter))
@@ -131,6 +171,214 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// loadConfig loads the config file, falling back to the default config if it is missing or cannot be
+// parsed. Problems found while validating the config are returned as errors, but the config is still used.
+func loadConfig() (*config.Config, []error) {
+	conf, err := config.LoadConfig()
+	if err == nil {
+		return conf, nil
+	}
+
+	if problems, ok := validationProblems(err); ok {
+		return conf, problems
+	}
+
+	var fileNotFound *config.ErrorFileNotFound
+	if errors.As(err, &fileNotFound) {
+		return config.DefaultConfig(), nil
+	}
+
+	return config.DefaultConfig(), []error{err}
+}
+
+// validationProblems returns the problems of a validation error, which leaves the config or theme usable
+func validationProblems(err error) ([]error, bool) {
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, false
+	}
+	problems := make([]error, 0, len(validationErr.Problems))
+	for _, problem := range validationErr.Problems {
+		problems = append(problems, problem)
+	}
+	return problems, true
+}
+
+// applyProfile applies the profile selected with --profile to the config. If no profile was selected, an
+// empty profile is returned.
+func applyProfile(conf *config.Config) (*config.Profile, error) {
+	if profileName == "" {
+		return &config.Profile{}, nil
+	}
+	return conf.ApplyProfile(profileName)
+}
+
+// profileThemePath returns the theme path given by --theme-path, or by the profile if the flag was not used
+func profileThemePath(profile *config.Profile) string {
+	if themePath != "" {
+		return themePath
+	}
+	return profile.ThemePath
+}
+
+// resolveTheme loads the variants of the theme at the given path, or the theme file in the config directory,
+// falling back to the default theme if there is no theme file. Problems found while validating the theme are
+// returned alongside the variants, which are still used, while the error is for a theme which couldn't be loaded.
+func resolveTheme(conf *config.Config, path string) ([]config.ThemeVariant, []error, error) {
+	variants, err := config.LoadThemeVariants(conf, path)
+	var fileNotFound *config.ErrorFileNotFound
+	if path == "" && errors.As(err, &fileNotFound) {
+		variants, err = config.DefaultThemeVariants(conf)
+		return variants, nil, err
+	}
+	if problems, ok := validationProblems(err); ok {
+		return variants, problems, nil
+	}
+	return variants, nil, err
+}
+
+func themeVariants(variants []config.ThemeVariant) []gui.ThemeVariant {
+	converted := make([]gui.ThemeVariant, 0, len(variants))
+	for _, variant := range variants {
+		converted = append(converted, gui.ThemeVariant{Name: variant.Name, Theme: variant.Theme})
+	}
+	return converted
+}
+
+// configOptions converts the config into gui options - these are applied at startup and again whenever the config
+// is reloaded. On reload, the previous config is given so that only the font settings which have changed are
+// applied, which keeps the font size the user has zoomed to unless the size in the config has changed.
+func configOptions(conf *config.Config, previous *config.Config) ([]gui.Option, []error) {
+
+	var errs []error
+
+	options := append(fontOptions(conf, previous),
+		gui.WithOpacity(conf.Opacity),
+		gui.WithLigatures(conf.Font.Ligatures),
+		gui.WithBoxDrawing(conf.Font.BoxDrawing, conf.Font.LineThickness),
+		gui.WithWordSeparators(conf.Selection.WordSeparators),
+		gui.WithCopyOnSelect(conf.Clipboard.CopyOnSelect),
+		gui.WithMinimumContrast(conf.Colours.MinimumContrast),
+		gui.WithBoldIsBright(conf.Colours.BoldIsBright),
+		gui.WithBoldInBrightColours(conf.Colours.BoldInBrightColours),
+		gui.WithConfirmClose(conf.Close.Confirm, conf.Close.Allowlist),
+		gui.WithTitleTemplate(conf.TitleTemplate),
+	)
+
+	// the command line flags take precedence over the config file
+	mode := conf.Exit.Hold
+	if holdMode != "" {
+		mode = holdMode
+	}
+	options = append(options,
+		gui.WithHoldMode(hold.Mode(mode)),
+		gui.WithExitCodePropagation(conf.Exit.PropagateExitCode || propagateExitCode),
+	)
+
+	keyBindings, bindingErrs := keybinding.Parse(conf.KeyBindings)
+	errs = append(errs, bindingErrs...)
+	options = append(options, gui.WithKeyBindings(keyBindings))
+
+	if conf.Export.Format != "" {
+		options = append(options, gui.WithExportFormat(conf.Export.Format))
+	}
+
+	if err := clipboard.UseBackend(conf.Clipboard.Backend); err != nil {
+		errs = append(errs, err)
+	}
+
+	var patterns, delimited []*regexp.Regexp
+	for _, rule := range conf.Selection.SmartSelection {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid smart selection pattern '%s': %w", rule.Name, err))
+			continue
+		}
+		if rule.Delimited {
+			delimited = append(delimited, pattern)
+		} else {
+			patterns = append(patterns, pattern)
+		}
+	}
+	options = append(options, gui.WithSmartSelection(patterns, delimited))
+
+	if conf.Cursor.Image != "" {
+		img, err := getImageFromFilePath(conf.Cursor.Image)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			options = append(options, gui.WithCursorImage(img))
+		}
+	}
+
+	return options, errs
+}
+
+// fontOptions returns the options for the font settings which differ from those of the previous config, or for
+// all of them if there is no previous config
+func fontOptions(conf *config.Config, previous *config.Config) []gui.Option {
+	var options []gui.Option
+	if previous == nil || conf.Font.DPI != previous.Font.DPI {
+		options = append(options, gui.WithFontDPI(conf.Font.DPI))
+	}
+	if previous == nil || conf.Font.Size != previous.Font.Size {
+		options = append(options, gui.WithFontSize(conf.Font.Size))
+	}
+	if previous == nil || conf.Font.Weight != previous.Font.Weight {
+		options = append(options, gui.WithFontWeight(conf.Font.Weight))
+	}
+	if previous == nil || conf.Font.Family != previous.Font.Family {
+		options = append(options, gui.WithFontFamily(conf.Font.Family))
+	}
+	if previous == nil || !reflect.DeepEqual(conf.Font.Fallbacks, previous.Font.Fallbacks) ||
+		conf.Font.FallbackDiscovery != previous.Font.FallbackDiscovery {
+		options = append(options, gui.WithFontFallbacks(conf.Font.Fallbacks, conf.Font.FallbackDiscovery))
+	}
+	if previous == nil || !reflect.DeepEqual(conf.Font.Features, previous.Font.Features) {
+		options = append(options, gui.WithFontFeatures(conf.Font.Features))
+	}
+	return options
+}
+
+// watchConfig reloads the config and theme whenever either file changes. The config the GUI was started with is
+// given so that settings which haven't changed aren't applied again.
+func watchConfig(g *gui.GUI, current *config.Config, themeFile string) {
+	err := config.Watch(themeFile, time.Second, func() {
+		// keep the current settings if the config can't be parsed, e.g. when it is saved half-edited
+		if _, err := config.LoadConfig(); err != nil {
+			var validationErr *config.ValidationError
+			var fileNotFound *config.ErrorFileNotFound
+			if !errors.As(err, &validationErr) && !errors.As(err, &fileNotFound) {
+				g.RequestReload([]error{err})
+				return
+			}
+		}
+
+		conf, errs := loadConfig()
+
+		profile, err := applyProfile(conf)
+		if err != nil {
+			g.RequestReload(append(errs, err))
+			return
+		}
+
+		variants, themeErrs, err := resolveTheme(conf, profileThemePath(profile))
+		errs = append(errs, themeErrs...)
+		if err != nil {
+			g.RequestReload(append(errs, err))
+			return
+		}
+
+		options, optionErrs := configOptions(conf, current)
+		options = append(options, gui.WithThemeVariants(themeVariants(variants), themeVariant))
+		g.RequestReload(append(errs, optionErrs...), options...)
+		current = conf
+	})
+	if err != nil {
+		g.RequestReload([]error{err})
+	}
+}
+
 func getImageFromFilePath(filePath string) (image.Image, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -146,9 +394,20 @@ func Execute() error {
 	rootCmd.Flags().BoolVar(&rewriteConfig, "rewrite-config", rewriteConfig, "Write the resultant config after parsing config files and merging with defauls back to the config file")
 	rootCmd.Flags().StringVar(&debugFile, "log-file", debugFile, "Debug log file")
 	rootCmd.Flags().StringVarP(&shell, "shell", "s", shell, "Shell to launch terminal with - defaults to configured user shell")
-	rootCmd.Flags().StringVarP(&initialCommand, "command", "c", initialCommand, "Command to run when shell starts - use this with caution")
+	rootCmd.Flags().StringVarP(&initialCommand, "command", "c", initialCommand, "Command to type into the shell when it starts - use this with caution. To run a program instead of a shell, use 'darktile -- program args'")
+	rootCmd.Flags().StringVar(&workingDirectory, "working-directory", workingDirectory, "Directory to start the shell or program in")
+	rootCmd.Flags().StringArrayVar(&envVars, "env", envVars, "Environment variable to set for the shell or program, as KEY=VALUE - can be used more than once")
+	rootCmd.Flags().BoolVarP(&loginShell, "login", "l", loginShell, "Start the shell as a login shell")
+	rootCmd.Flags().BoolVar(&cleanEnv, "clean-env", cleanEnv, "Start the shell or program with a minimal environment instead of inheriting darktile's")
 	rootCmd.Flags().IntVar(&screenshotAfterMS, "screenshot-after-ms", screenshotAfterMS, "Take a screenshot after this many milliseconds")
 	rootCmd.Flags().StringVar(&screenshotFilename, "screenshot-filename", screenshotFilename, "Filename to store screenshot taken by --screenshot-after-ms")
+	rootCmd.Flags().IntVar(&exportAfterMS, "export-after-ms", exportAfterMS, "Export the screen as HTML, SVG or ANSI text after this many milliseconds")
+	rootCmd.Flags().StringVar(&exportFilename, "export-filename", exportFilename, "Filename to store the export taken by --export-after-ms - the format is chosen by the .html, .svg or .ans extension")
+	rootCmd.Flags().BoolVar(&exportScrollback, "export-scrollback", exportScrollback, "Include the entire scrollback in the export taken by --export-after-ms")
 	rootCmd.Flags().StringVar(&themePath, "theme-path", themePath, "Path to a theme file to use instead of the default")
+	rootCmd.Flags().StringVar(&holdMode, "hold", holdMode, "Keep the window open when the program exits: never, always or error")
+	rootCmd.Flags().BoolVar(&propagateExitCode, "propagate-exit-code", propagateExitCode, "Exit with the same status as the program running in the terminal")
+	rootCmd.Flags().StringVar(&profileName, "profile", profileName, "Name of a profile from the config file to use")
+	rootCmd.Flags().StringVar(&themeVariant, "theme-variant", themeVariant, "Name of the theme variant to start with, e.g. light")
 	return rootCmd.Execute()
 }
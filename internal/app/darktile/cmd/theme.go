@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/liamg/darktile/internal/app/darktile/config"
+	"github.com/spf13/cobra"
+)
+
+var importThemeOutput string
+var importThemeForce bool
+
+func init() {
+	importThemeCmd.Flags().StringVarP(&importThemeOutput, "output", "o", importThemeOutput, "Path to write the theme to - defaults to theme.yaml in your config directory")
+	importThemeCmd.Flags().BoolVarP(&importThemeForce, "force", "f", importThemeForce, "Overwrite the output file if it already exists")
+	themeCmd.AddCommand(importThemeCmd)
+	rootCmd.AddCommand(themeCmd)
+}
+
+var themeCmd = &cobra.Command{
+	Use:   "theme",
+	Short: "Manage darktile themes",
+}
+
+var importThemeCmd = &cobra.Command{
+	Use:          "import <file>",
+	Short:        "Convert an iTerm2, Alacritty, Windows Terminal, base16 or Xresources colour scheme into a darktile theme",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		theme, err := config.ImportTheme(args[0], data)
+		if err != nil {
+			return err
+		}
+
+		output := importThemeOutput
+		if output == "" {
+			output, err = config.ThemePath()
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := os.Stat(output); err == nil && !importThemeForce {
+			return fmt.Errorf("%s already exists - use --force to overwrite it", output)
+		}
+
+		if err := theme.SaveTo(output); err != nil {
+			return fmt.Errorf("failed to write theme: %w", err)
+		}
+
+		fmt.Printf("Imported %s theme from %s to %s\n", config.DetectThemeFormat(args[0], data), args[0], output)
+		return nil
+	},
+}
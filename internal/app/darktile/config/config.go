@@ -10,22 +10,80 @@ import (
 )
 
 type Config struct {
-	Opacity float64
-	Font    Font
-	Cursor  Cursor
+	Opacity    float64
+	LiveReload bool // apply changes to the config and theme files without restarting
+	Font       Font
+	Colours    Colours
+	Cursor     Cursor
+	Selection  Selection
+	Clipboard  Clipboard
+	Export     Export
+	Exit       Exit
+	Close      Close
+	// TitleTemplate sets the window title, e.g. "{process} — {cwd} — {title}"
+	TitleTemplate string
+	// KeyBindings maps key chords such as "ctrl+shift+c" to actions, overriding the default bindings
+	KeyBindings map[string]string
+	// Profiles are named sets of overrides, selected with --profile
+	Profiles map[string]Profile
 }
 
 type Font struct {
-	Family    string
-	Size      float64
-	DPI       float64
-	Ligatures bool
+	Family            string
+	Size              float64
+	DPI               float64
+	Ligatures         bool
+	Weight            float64  // weight of variable fonts from 1 to 1000, or 0 for the font's default
+	Features          []string // OpenType features applied with ligatures, e.g. ss01 to enable or -calt to disable
+	Fallbacks         []string // families searched in order for characters missing from the main font
+	FallbackDiscovery bool     // search the installed fonts for characters missing from the main font and fallbacks
+	BoxDrawing        bool     // draw box drawing, block, braille and Powerline characters to fit the cell exactly
+	LineThickness     int      // thickness in pixels of lines in box drawing characters, or 0 to fit the font size
+}
+
+type Colours struct {
+	MinimumContrast     float64 // WCAG contrast ratio from 1 (no adjustment) to 21 which text is adjusted to reach
+	BoldIsBright        bool    // draw bold text in bright colours instead of the bold font
+	BoldInBrightColours bool    // draw bold text in bright colours as well as the bold font
 }
 
 type Cursor struct {
 	Image string
 }
 
+type Selection struct {
+	WordSeparators string
+	SmartSelection []SmartSelectionRule
+}
+
+type Clipboard struct {
+	Backend      string // auto, x11, wl-clipboard or xclip
+	CopyOnSelect bool
+}
+
+type Export struct {
+	Format string // html, svg or ans
+}
+
+type Exit struct {
+	Hold              string // keep the window open when the program exits: never, always or error
+	PropagateExitCode bool   // exit with the same status as the program
+}
+
+type Close struct {
+	Confirm   bool     // ask before closing the window while a program other than the shell is running
+	Allowlist []string // names of programs which can be closed without confirmation
+}
+
+// SmartSelectionRule is a regular expression which takes priority over word selection on double-click
+type SmartSelectionRule struct {
+	Name    string
+	Pattern string
+	// Delimited rules only select a match when its first or last character is double-clicked, such as the quotes
+	// around a string, and are tried after the other rules
+	Delimited bool
+}
+
 type ErrorFileNotFound struct {
 	Path string
 }
@@ -62,12 +120,45 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file at '%s': %w", configPath, err)
 	}
 
+	// the config is still usable if it has problems, so it is returned alongside them
+	return decodeConfig(configPath, configData)
+}
+
+// ValidateConfigFile reports all problems in the config file at the given path, or the default config
+// file if the path is empty
+func ValidateConfigFile(configPath string, checks ...Check) error {
+	if configPath == "" {
+		var err error
+		configPath, err = getConfigPath()
+		if err != nil {
+			return fmt.Errorf("failed to locate config path: %w", err)
+		}
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return &ErrorFileNotFound{Path: configPath}
+	}
+
+	configData, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file at '%s': %w", configPath, err)
+	}
+
+	_, err = decodeConfig(configPath, configData, checks...)
+	return err
+}
+
+// decodeConfig decodes and validates a config file. Problems are returned as a *ValidationError alongside the
+// config, which can still be used, while any other error means the file is not valid YAML.
+func decodeConfig(configPath string, configData []byte, checks ...Check) (*Config, error) {
 	config := defaultConfig
-	if err := yaml.Unmarshal(configData, &config); err != nil {
+	problems, err := decodeFile(configPath, configData, &config)
+	if err != nil {
 		return nil, fmt.Errorf("invalid config file at '%s': %w", configPath, err)
 	}
 
-	return &config, nil
+	err = validateConfig(configPath, configData, &config, checks...)
+	return &config, withProblems(problems, err)
 }
 
 func (c *Config) Save() (string, error) {
@@ -9,12 +9,44 @@ import (
 )
 
 var defaultConfig = Config{
-	Opacity: 1.0,
+	Opacity:    1.0,
+	LiveReload: true,
 	Font: Font{
-		Family:    "", // internally packed font will be loaded by default
-		Size:      18.0,
-		DPI:       72.0,
-		Ligatures: true,
+		Family:            "", // internally packed font will be loaded by default
+		Size:              18.0,
+		DPI:               72.0,
+		Ligatures:         true,
+		FallbackDiscovery: true,
+		BoxDrawing:        true,
+	},
+	Colours: Colours{
+		MinimumContrast: 1.0,
+	},
+	Selection: Selection{
+		WordSeparators: termutil.DefaultWordSeparators,
+		SmartSelection: []SmartSelectionRule{
+			{Name: "url", Pattern: `[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>` + "`" + `]+`},
+			{Name: "uuid", Pattern: `\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`},
+			{Name: "ip", Pattern: `\b(?:[0-9]{1,3}\.){3}[0-9]{1,3}(?::[0-9]+)?\b`},
+			{Name: "path", Pattern: `(?:~|\.{1,2})?(?:/[\w.@%+~-]+)+/?`},
+			{Name: "double-quoted", Pattern: `"[^"]*"`, Delimited: true},
+			{Name: "single-quoted", Pattern: `'[^']*'`, Delimited: true},
+		},
+	},
+	Clipboard: Clipboard{
+		Backend:      "auto",
+		CopyOnSelect: false,
+	},
+	Export: Export{
+		Format: "html",
+	},
+	Exit: Exit{
+		Hold: "never",
+	},
+	Close: Close{
+		Confirm: true,
+		// both keep running in the background when their client is closed
+		Allowlist: []string{"tmux", "screen"},
 	},
 }
 
@@ -54,22 +86,75 @@ func DefaultTheme(conf *Config) (*termutil.Theme, error) {
 
 func LoadTheme(conf *Config) (*termutil.Theme, error) {
 
+	// problems with the theme file are returned alongside the theme, which can still be used
 	themeConf, err := loadTheme("")
-	if err != nil {
+	if themeConf == nil {
 		return nil, err
 	}
 
-	return loadThemeFromConf(conf, themeConf)
+	theme, convertErr := loadThemeFromConf(conf, themeConf)
+	if convertErr != nil {
+		return nil, convertErr
+	}
+	return theme, err
 }
 
 func LoadThemeFromPath(conf *Config, path string) (*termutil.Theme, error) {
 
+	// problems with the theme file are returned alongside the theme, which can still be used
 	themeConf, err := loadTheme(path)
-	if err != nil {
+	if themeConf == nil {
 		return nil, err
 	}
 
-	return loadThemeFromConf(conf, themeConf)
+	theme, convertErr := loadThemeFromConf(conf, themeConf)
+	if convertErr != nil {
+		return nil, convertErr
+	}
+	return theme, err
+}
+
+// ThemeVariant is one of the named variants of a theme file, converted for use by the terminal
+type ThemeVariant struct {
+	Name  string
+	Theme *termutil.Theme
+}
+
+func DefaultThemeVariants(conf *Config) ([]ThemeVariant, error) {
+	return loadThemeVariantsFromConf(conf, &defaultTheme)
+}
+
+// LoadThemeVariants loads every variant of the theme file at the given path, or the theme file in the config
+// directory if the path is empty. Problems with the theme file are returned as a *ValidationError alongside the
+// variants, which can still be used.
+func LoadThemeVariants(conf *Config, path string) ([]ThemeVariant, error) {
+
+	themeConf, err := loadTheme(path)
+	if themeConf == nil {
+		return nil, err
+	}
+
+	variants, convertErr := loadThemeVariantsFromConf(conf, themeConf)
+	if convertErr != nil {
+		return nil, convertErr
+	}
+	return variants, err
+}
+
+func loadThemeVariantsFromConf(conf *Config, themeConf *Theme) ([]ThemeVariant, error) {
+	var variants []ThemeVariant
+	for _, name := range themeConf.VariantNames() {
+		variantConf, err := themeConf.Variant(name)
+		if err != nil {
+			return nil, err
+		}
+		theme, err := loadThemeFromConf(conf, variantConf)
+		if err != nil {
+			return nil, fmt.Errorf("theme variant '%s': %w", name, err)
+		}
+		variants = append(variants, ThemeVariant{Name: name, Theme: theme})
+	}
+	return variants, nil
 }
 
 func loadThemeFromConf(conf *Config, themeConf *Theme) (*termutil.Theme, error) {
@@ -101,6 +186,19 @@ func loadThemeFromConf(conf *Config, themeConf *Theme) (*termutil.Theme, error)
 		termutil.ColourCursorBackground:    themeConf.CursorBackground,
 	}
 
+	optionalColours := map[termutil.Colour]string{
+		termutil.ColourBold:                  themeConf.Bold,
+		termutil.ColourDim:                   themeConf.Dim,
+		termutil.ColourLink:                  themeConf.Link,
+		termutil.ColourSearchMatchBackground: themeConf.SearchMatchBackground,
+		termutil.ColourSearchMatchForeground: themeConf.SearchMatchForeground,
+	}
+	for key, colHex := range optionalColours {
+		if colHex != "" {
+			colours[key] = colHex
+		}
+	}
+
 	for key, colHex := range colours {
 		col, err := colourFromHex(colHex, conf.Opacity)
 		if err != nil {
@@ -112,6 +210,17 @@ func loadThemeFromConf(conf *Config, themeConf *Theme) (*termutil.Theme, error)
 		)
 	}
 
+	for index, colHex := range themeConf.Palette {
+		if index < 0 || index > 255 {
+			return nil, fmt.Errorf("invalid palette index %d in theme, must be between 0 and 255", index)
+		}
+		col, err := colourFromHex(colHex, conf.Opacity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex value '%s' for palette index %d in theme", colHex, index)
+		}
+		factory.WithPaletteColour(uint8(index), col)
+	}
+
 	return factory.Build(), nil
 
 }
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type ThemeFormat string
+
+const (
+	ThemeFormatDarktile        ThemeFormat = "darktile"
+	ThemeFormatITerm           ThemeFormat = "iterm"
+	ThemeFormatAlacritty       ThemeFormat = "alacritty"
+	ThemeFormatAlacrittyTOML   ThemeFormat = "alacritty-toml"
+	ThemeFormatWindowsTerminal ThemeFormat = "windows-terminal"
+	ThemeFormatBase16          ThemeFormat = "base16"
+	ThemeFormatXresources      ThemeFormat = "xresources"
+)
+
+// the 16 ANSI colours, in order, using the lowercased names of the Theme fields
+var ansiColourKeys = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"brightblack", "brightred", "brightgreen", "brightyellow", "brightblue", "brightmagenta", "brightcyan", "brightwhite",
+}
+
+// palette maps lowercased Theme field names to colours
+type palette map[string]string
+
+// DetectThemeFormat works out which application a theme file was written for from its name and content
+func DetectThemeFormat(path string, data []byte) ThemeFormat {
+	name := strings.ToLower(filepath.Base(path))
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case strings.HasSuffix(name, ".itermcolors"), bytes.HasPrefix(trimmed, []byte("<?xml")):
+		return ThemeFormatITerm
+	case strings.HasSuffix(name, ".json"), bytes.HasPrefix(trimmed, []byte("{")):
+		return ThemeFormatWindowsTerminal
+	case strings.HasSuffix(name, ".toml"):
+		return ThemeFormatAlacrittyTOML
+	case strings.Contains(name, "xresources"), strings.Contains(name, "xdefaults"):
+		return ThemeFormatXresources
+	}
+
+	if yamlHasKey(data, "colors") {
+		return ThemeFormatAlacritty
+	}
+	if yamlHasKey(data, "base00") {
+		return ThemeFormatBase16
+	}
+	return ThemeFormatDarktile
+}
+
+// ImportTheme converts a theme written for another terminal or colour scheme format into a darktile theme
+func ImportTheme(path string, data []byte) (*Theme, error) {
+	format := DetectThemeFormat(path, data)
+
+	var colours palette
+	var err error
+	switch format {
+	case ThemeFormatITerm:
+		colours, err = parseITermColours(data)
+	case ThemeFormatAlacritty:
+		colours, err = parseAlacrittyYAML(data)
+	case ThemeFormatAlacrittyTOML:
+		colours, err = parseAlacrittyTOML(data)
+	case ThemeFormatWindowsTerminal:
+		colours, err = parseWindowsTerminal(data)
+	case ThemeFormatBase16:
+		colours, err = parseBase16(data)
+	case ThemeFormatXresources:
+		colours, err = parseXresources(data)
+	default:
+		return nil, fmt.Errorf("'%s' is already a darktile theme", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to import %s theme from '%s': %w", format, path, err)
+	}
+
+	return colours.toTheme()
+}
+
+// set normalises and stores a colour, ignoring values which are not colours, such as Alacritty's "CellForeground"
+func (p palette) set(key string, value string) {
+	if colour, err := normaliseColour(value); err == nil {
+		p[key] = colour
+	}
+}
+
+// toTheme fills in any colours the source format did not define and converts the palette into a Theme
+func (p palette) toTheme() (*Theme, error) {
+
+	var found int
+	for _, key := range ansiColourKeys[:8] {
+		if _, ok := p[key]; ok {
+			found++
+		}
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("no ANSI colours were found")
+	}
+
+	fallback := func(key string, from string) {
+		if _, ok := p[key]; !ok {
+			p[key] = p[from]
+		}
+	}
+
+	defaults := reflect.ValueOf(defaultTheme)
+	for i, key := range ansiColourKeys[:8] {
+		if _, ok := p[key]; !ok {
+			p[key] = strings.ToLower(defaults.Field(i).String())
+		}
+		fallback(ansiColourKeys[i+8], key)
+	}
+	if _, ok := p["background"]; !ok {
+		p["background"] = defaultTheme.Background
+	}
+	if _, ok := p["foreground"]; !ok {
+		p["foreground"] = defaultTheme.Foreground
+	}
+	fallback("cursorbackground", "foreground")
+	fallback("cursorforeground", "background")
+	fallback("selectionbackground", "brightblack")
+	fallback("selectionforeground", "foreground")
+
+	var theme Theme
+	value := reflect.ValueOf(&theme).Elem()
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if value.Field(i).Kind() == reflect.String {
+			value.Field(i).SetString(p[strings.ToLower(t.Field(i).Name)])
+		}
+	}
+	return &theme, nil
+}
+
+// normaliseColour converts colours written as #rgb, #rrggbb, 0xrrggbb, rrggbb or rgb:rr/gg/bb to #rrggbb
+func normaliseColour(input string) (string, error) {
+	colour := strings.ToLower(strings.Trim(strings.TrimSpace(input), `"'`))
+
+	switch {
+	case strings.HasPrefix(colour, "rgb:"):
+		parts := strings.Split(colour[4:], "/")
+		if len(parts) != 3 {
+			return "", fmt.Errorf("invalid colour '%s'", input)
+		}
+		colour = ""
+		for _, part := range parts {
+			// X11 allows 1 to 4 hex digits per channel, scaled to the channel size
+			if len(part) == 0 || len(part) > 4 {
+				return "", fmt.Errorf("invalid colour '%s'", input)
+			}
+			v, err := strconv.ParseUint(part, 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid colour '%s'", input)
+			}
+			max := uint64(1)<<(4*uint(len(part))) - 1
+			colour += fmt.Sprintf("%02x", v*0xff/max)
+		}
+	case strings.HasPrefix(colour, "#"):
+		colour = colour[1:]
+	case strings.HasPrefix(colour, "0x"):
+		colour = colour[2:]
+	}
+
+	if len(colour) == 3 {
+		colour = string([]byte{colour[0], colour[0], colour[1], colour[1], colour[2], colour[2]})
+	}
+	if len(colour) != 6 {
+		return "", fmt.Errorf("invalid colour '%s'", input)
+	}
+	if _, err := strconv.ParseUint(colour, 16, 32); err != nil {
+		return "", fmt.Errorf("invalid colour '%s'", input)
+	}
+	return "#" + colour, nil
+}
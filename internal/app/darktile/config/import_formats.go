@@ -0,0 +1,467 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// yamlDocument decodes YAML mappings into nested maps, keeping scalars as their raw text so that
+// unquoted values such as 0x1d1f21 or 282828 are not interpreted as numbers
+func yamlDocument(data []byte) (map[string]interface{}, error) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	document, ok := yamlNodeValue(root.Content[0]).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping")
+	}
+	return document, nil
+}
+
+func yamlNodeValue(node *yamlv3.Node) interface{} {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		mapping := make(map[string]interface{})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			mapping[node.Content[i].Value] = yamlNodeValue(node.Content[i+1])
+		}
+		return mapping
+	case yamlv3.ScalarNode:
+		return node.Value
+	case yamlv3.AliasNode:
+		return yamlNodeValue(node.Alias)
+	}
+	return nil
+}
+
+func yamlHasKey(data []byte, key string) bool {
+	document, err := yamlDocument(data)
+	if err != nil {
+		return false
+	}
+	_, ok := document[key]
+	return ok
+}
+
+// lookup walks nested maps, as decoded from YAML, TOML or JSON, returning the string at the given keys
+func lookup(document map[string]interface{}, keys ...string) string {
+	var current interface{} = document
+	for _, key := range keys {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		current = m[key]
+	}
+	s, _ := current.(string)
+	return s
+}
+
+// parseITermColours reads an iTerm2 .itermcolors property list
+func parseITermColours(data []byte) (palette, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root interface{}
+	for root == nil {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "dict" {
+			root, err = decodePlistValue(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dict, _ := root.(map[string]interface{})
+
+	keys := map[string]string{
+		"Background Color":    "background",
+		"Foreground Color":    "foreground",
+		"Cursor Color":        "cursorbackground",
+		"Cursor Text Color":   "cursorforeground",
+		"Selection Color":     "selectionbackground",
+		"Selected Text Color": "selectionforeground",
+		"Bold Color":          "bold",
+		"Link Color":          "link",
+	}
+	for i, key := range ansiColourKeys {
+		keys[fmt.Sprintf("Ansi %d Color", i)] = key
+	}
+
+	colours := make(palette)
+	for iTermKey, key := range keys {
+		components, ok := dict[iTermKey].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var rgb [3]uint8
+		for i, name := range []string{"Red Component", "Green Component", "Blue Component"} {
+			component, _ := components[name].(float64)
+			rgb[i] = uint8(math.Round(math.Max(0, math.Min(1, component)) * 0xff))
+		}
+		colours[key] = fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+	}
+	return colours, nil
+}
+
+// decodePlistValue decodes the element which has just been started, returning dicts as maps and numbers as float64
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]interface{})
+		var key string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("invalid property list: %w", err)
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "real", "integer":
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	default:
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		return text, nil
+	}
+}
+
+func alacrittyColours(document map[string]interface{}) palette {
+	colours := make(palette)
+	for i, key := range ansiColourKeys {
+		group, name := "normal", key
+		if i >= 8 {
+			group, name = "bright", ansiColourKeys[i-8]
+		}
+		colours.set(key, lookup(document, "colors", group, name))
+	}
+	colours.set("background", lookup(document, "colors", "primary", "background"))
+	colours.set("foreground", lookup(document, "colors", "primary", "foreground"))
+	colours.set("cursorbackground", lookup(document, "colors", "cursor", "cursor"))
+	colours.set("cursorforeground", lookup(document, "colors", "cursor", "text"))
+	colours.set("selectionbackground", lookup(document, "colors", "selection", "background"))
+	colours.set("selectionforeground", lookup(document, "colors", "selection", "text"))
+	colours.set("searchmatchbackground", lookup(document, "colors", "search", "matches", "background"))
+	colours.set("searchmatchforeground", lookup(document, "colors", "search", "matches", "foreground"))
+	return colours
+}
+
+// parseAlacrittyYAML reads the colors section of an alacritty.yml file
+func parseAlacrittyYAML(data []byte) (palette, error) {
+	document, err := yamlDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return alacrittyColours(document), nil
+}
+
+// parseAlacrittyTOML reads the colors tables of an alacritty.toml file
+func parseAlacrittyTOML(data []byte) (palette, error) {
+	document, err := parseSimpleTOML(data)
+	if err != nil {
+		return nil, err
+	}
+	return alacrittyColours(document), nil
+}
+
+// parseWindowsTerminal reads a Windows Terminal colour scheme, or the first scheme in a settings.json file
+func parseWindowsTerminal(data []byte) (palette, error) {
+	// settings.json allows line comments
+	var stripped bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.HasPrefix(strings.TrimSpace(scanner.Text()), "//") {
+			continue
+		}
+		stripped.WriteString(scanner.Text() + "\n")
+	}
+
+	var scheme map[string]interface{}
+	if err := json.Unmarshal(stripped.Bytes(), &scheme); err != nil {
+		return nil, err
+	}
+	if schemes, ok := scheme["schemes"].([]interface{}); ok {
+		if len(schemes) == 0 {
+			return nil, fmt.Errorf("settings contain no colour schemes")
+		}
+		scheme, _ = schemes[0].(map[string]interface{})
+	}
+
+	keys := map[string]string{
+		"black":               "black",
+		"red":                 "red",
+		"green":               "green",
+		"yellow":              "yellow",
+		"blue":                "blue",
+		"purple":              "magenta",
+		"cyan":                "cyan",
+		"white":               "white",
+		"brightBlack":         "brightblack",
+		"brightRed":           "brightred",
+		"brightGreen":         "brightgreen",
+		"brightYellow":        "brightyellow",
+		"brightBlue":          "brightblue",
+		"brightPurple":        "brightmagenta",
+		"brightCyan":          "brightcyan",
+		"brightWhite":         "brightwhite",
+		"background":          "background",
+		"foreground":          "foreground",
+		"cursorColor":         "cursorbackground",
+		"selectionBackground": "selectionbackground",
+	}
+
+	colours := make(palette)
+	for schemeKey, key := range keys {
+		colours.set(key, lookup(scheme, schemeKey))
+	}
+	return colours, nil
+}
+
+// parseBase16 reads a base16 scheme, mapping it in the same way as base16-shell
+func parseBase16(data []byte) (palette, error) {
+	scheme, err := yamlDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]string{
+		"black":               "base00",
+		"red":                 "base08",
+		"green":               "base0B",
+		"yellow":              "base0A",
+		"blue":                "base0D",
+		"magenta":             "base0E",
+		"cyan":                "base0C",
+		"white":               "base05",
+		"brightblack":         "base03",
+		"brightwhite":         "base07",
+		"background":          "base00",
+		"foreground":          "base05",
+		"selectionbackground": "base02",
+		"selectionforeground": "base05",
+		"cursorbackground":    "base05",
+		"cursorforeground":    "base00",
+	}
+
+	colours := make(palette)
+	for key, base := range keys {
+		colours.set(key, lookup(scheme, base))
+	}
+	return colours, nil
+}
+
+// parseXresources reads colour resources such as "*.color1: #cc6666" from an X resources file,
+// expanding any #define macros used for their values
+func parseXresources(data []byte) (palette, error) {
+
+	defines := make(map[string]string)
+	colours := make(palette)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#define") {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 {
+				defines[fields[1]] = fields[2]
+			}
+			continue
+		}
+
+		separator := strings.Index(line, ":")
+		if separator < 0 {
+			continue
+		}
+		resource := strings.TrimSpace(line[:separator])
+		value := strings.TrimSpace(line[separator+1:])
+		if defined, ok := defines[value]; ok {
+			value = defined
+		}
+
+		// only the final component matters, e.g. "URxvt*color4" or "*.color4"
+		if i := strings.LastIndexAny(resource, ".*"); i >= 0 {
+			resource = resource[i+1:]
+		}
+
+		switch resource {
+		case "background", "foreground":
+			colours.set(resource, value)
+		case "cursorColor":
+			colours.set("cursorbackground", value)
+		default:
+			if !strings.HasPrefix(resource, "color") {
+				continue
+			}
+			index, err := strconv.Atoi(resource[5:])
+			if err != nil || index < 0 || index >= len(ansiColourKeys) {
+				continue
+			}
+			colours.set(ansiColourKeys[index], value)
+		}
+	}
+
+	return colours, scanner.Err()
+}
+
+// parseSimpleTOML supports the subset of TOML used by colour schemes - tables, dotted keys, inline tables
+// and string values. Other values are kept as their raw text and arrays of tables are skipped.
+func parseSimpleTOML(data []byte) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	current := document
+	skipping := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") {
+			skipping = true
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid table header", lineNumber)
+			}
+			skipping = false
+			current = tomlTable(document, splitTOMLKey(line[1:len(line)-1]))
+			continue
+		}
+		if skipping {
+			continue
+		}
+
+		if err := setTOMLValue(current, line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+
+	return document, scanner.Err()
+}
+
+func setTOMLValue(table map[string]interface{}, assignment string) error {
+	separator := strings.Index(assignment, "=")
+	if separator < 0 {
+		return fmt.Errorf("expected key = value")
+	}
+	keys := splitTOMLKey(assignment[:separator])
+	raw := strings.TrimSpace(assignment[separator+1:])
+	target := tomlTable(table, keys[:len(keys)-1])
+	key := keys[len(keys)-1]
+
+	if strings.HasPrefix(raw, "{") && strings.HasSuffix(raw, "}") {
+		inline := tomlTable(target, []string{key})
+		for _, item := range splitOutsideQuotes(raw[1:len(raw)-1], ',') {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+			if err := setTOMLValue(inline, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	target[key] = strings.Trim(raw, `"'`)
+	return nil
+}
+
+func tomlTable(document map[string]interface{}, keys []string) map[string]interface{} {
+	current := document
+	for _, key := range keys {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+	return current
+}
+
+func splitTOMLKey(key string) []string {
+	var keys []string
+	for _, part := range splitOutsideQuotes(key, '.') {
+		keys = append(keys, strings.Trim(strings.TrimSpace(part), `"'`))
+	}
+	return keys
+}
+
+func splitOutsideQuotes(input string, separator rune) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range input {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == separator:
+			parts = append(parts, input[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, input[start:])
+}
+
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
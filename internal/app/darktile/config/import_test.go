@@ -0,0 +1,181 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportITermColours(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Ansi 1 Color</key>
+	<dict>
+		<key>Alpha Component</key>
+		<real>1</real>
+		<key>Blue Component</key>
+		<real>0.0</real>
+		<key>Color Space</key>
+		<string>sRGB</string>
+		<key>Green Component</key>
+		<real>0.5</real>
+		<key>Red Component</key>
+		<real>1</real>
+	</dict>
+	<key>Background Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>0.2</real>
+		<key>Green Component</key>
+		<real>0.1</real>
+		<key>Red Component</key>
+		<integer>0</integer>
+	</dict>
+</dict>
+</plist>`
+
+	theme, err := ImportTheme("Solarized.itermcolors", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "#ff8000", theme.Red)
+	assert.Equal(t, "#ff8000", theme.BrightRed)
+	assert.Equal(t, "#001a33", theme.Background)
+	assert.Equal(t, "#001a33", theme.CursorForeground)
+}
+
+func TestImportAlacrittyYAML(t *testing.T) {
+	data := `
+font:
+  size: 12
+colors:
+  primary:
+    background: '0x1d1f21'
+    foreground: '#c5c8c6'
+  cursor:
+    text: CellBackground
+    cursor: CellForeground
+  normal:
+    black: 0x282a2e
+    red: '#a54242'
+  bright:
+    red: '#cc6666'
+`
+
+	assert.Equal(t, ThemeFormatAlacritty, DetectThemeFormat("alacritty.yml", []byte(data)))
+
+	theme, err := ImportTheme("alacritty.yml", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "#1d1f21", theme.Background)
+	assert.Equal(t, "#282a2e", theme.Black)
+	assert.Equal(t, "#a54242", theme.Red)
+	assert.Equal(t, "#cc6666", theme.BrightRed)
+	assert.Equal(t, "#c5c8c6", theme.CursorBackground)
+}
+
+func TestImportAlacrittyTOML(t *testing.T) {
+	data := `
+[font]
+size = 12
+
+# tomorrow night
+[colors.primary]
+background = "#1d1f21" # comment
+foreground = '#c5c8c6'
+
+[colors.normal]
+black = "#282a2e"
+"red" = "#a54242"
+
+[colors]
+cursor = { text = "#000000", cursor = "#ffffff" }
+`
+
+	theme, err := ImportTheme("alacritty.toml", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "#1d1f21", theme.Background)
+	assert.Equal(t, "#c5c8c6", theme.Foreground)
+	assert.Equal(t, "#a54242", theme.Red)
+	assert.Equal(t, "#ffffff", theme.CursorBackground)
+	assert.Equal(t, "#000000", theme.CursorForeground)
+}
+
+func TestImportWindowsTerminal(t *testing.T) {
+	data := `{
+	// settings.json allows comments
+	"schemes": [
+		{
+			"name": "Campbell",
+			"background": "#0C0C0C",
+			"foreground": "#CCCCCC",
+			"black": "#0C0C0C",
+			"purple": "#881798",
+			"brightPurple": "#B4009E",
+			"selectionBackground": "#FFFFFF"
+		}
+	]
+}`
+
+	theme, err := ImportTheme("settings.json", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "#0c0c0c", theme.Background)
+	assert.Equal(t, "#881798", theme.Magenta)
+	assert.Equal(t, "#b4009e", theme.BrightMagenta)
+	assert.Equal(t, "#ffffff", theme.SelectionBackground)
+}
+
+func TestImportBase16(t *testing.T) {
+	data := `
+scheme: "Tomorrow Night"
+author: "Chris Kempson"
+base00: "1d1f21"
+base02: "373b41"
+base03: "969896"
+base05: "c5c8c6"
+base07: "ffffff"
+base08: "cc6666"
+base0B: 181818
+`
+
+	assert.Equal(t, ThemeFormatBase16, DetectThemeFormat("tomorrow-night.yaml", []byte(data)))
+
+	theme, err := ImportTheme("tomorrow-night.yaml", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "#1d1f21", theme.Black)
+	assert.Equal(t, "#1d1f21", theme.Background)
+	assert.Equal(t, "#cc6666", theme.Red)
+	assert.Equal(t, "#cc6666", theme.BrightRed)
+	assert.Equal(t, "#181818", theme.Green)
+	assert.Equal(t, "#373b41", theme.SelectionBackground)
+	assert.Equal(t, "#969896", theme.BrightBlack)
+}
+
+func TestImportXresources(t *testing.T) {
+	data := `
+! comment
+#define red #cc6666
+*.background: #1d1f21
+URxvt*foreground: rgb:c5/c8/c6
+*color1: red
+*.color9:  #d54e53
+*cursorColor: #fff
+`
+
+	theme, err := ImportTheme("/home/user/.Xresources", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "#1d1f21", theme.Background)
+	assert.Equal(t, "#c5c8c6", theme.Foreground)
+	assert.Equal(t, "#cc6666", theme.Red)
+	assert.Equal(t, "#d54e53", theme.BrightRed)
+	assert.Equal(t, "#ffffff", theme.CursorBackground)
+}
+
+func TestImportRejectsThemeWithoutColours(t *testing.T) {
+	_, err := ImportTheme("settings.json", []byte(`{"name": "empty"}`))
+	assert.Error(t, err)
+}
+
+func TestDarktileThemeIsNotImported(t *testing.T) {
+	assert.Equal(t, ThemeFormatDarktile, DetectThemeFormat("theme.yaml", []byte("black: '#000000'\n")))
+}
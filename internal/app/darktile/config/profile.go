@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile is a named set of overrides for the config, selected with --profile. Empty values are inherited
+// from the rest of the config.
+type Profile struct {
+	Shell            string
+	Command          string            // command to run when the shell starts
+	Env              map[string]string // extra environment variables for the shell
+	WorkingDirectory string
+	ThemePath        string
+	Font             ProfileFont
+	Opacity          *float64
+}
+
+type ProfileFont struct {
+	Family    string
+	Size      float64
+	DPI       float64
+	Ligatures *bool
+}
+
+// ProfileNames returns the names of all profiles in the config, sorted alphabetically
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile overrides the font and opacity of the config with those of the named profile, and returns the
+// profile so that its shell and environment settings can be used. The config is left unchanged if any of the
+// overrides are out of range.
+func (c *Config) ApplyProfile(name string) (*Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return nil, fmt.Errorf("unknown profile '%s' - no profiles are defined in the config file", name)
+		}
+		return nil, fmt.Errorf("unknown profile '%s' - available profiles are: %s", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	var problems []string
+	profile.checkRanges(func(message string, keys ...string) {
+		problems = append(problems, fmt.Sprintf("%s: %s", strings.Join(keys, "."), message))
+	})
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid profile '%s' - %s", name, strings.Join(problems, ", "))
+	}
+
+	if profile.Font.Family != "" {
+		c.Font.Family = profile.Font.Family
+	}
+	if profile.Font.Size != 0 {
+		c.Font.Size = profile.Font.Size
+	}
+	if profile.Font.DPI != 0 {
+		c.Font.DPI = profile.Font.DPI
+	}
+	if profile.Font.Ligatures != nil {
+		c.Font.Ligatures = *profile.Font.Ligatures
+	}
+	if profile.Opacity != nil {
+		c.Opacity = *profile.Opacity
+	}
+
+	return &profile, nil
+}
+
+// checkRanges reports overrides which are out of range, with keys relative to the profile
+func (p *Profile) checkRanges(report Report) {
+	if p.Opacity != nil && (*p.Opacity < 0 || *p.Opacity > 1) {
+		report(fmt.Sprintf("%g is out of range, must be between 0.0 and 1.0", *p.Opacity), "opacity")
+	}
+	if p.Font.Size < 0 || p.Font.Size > 500 {
+		report(fmt.Sprintf("%g is out of range, must be greater than 0 and at most 500", p.Font.Size), "font", "size")
+	}
+	if p.Font.DPI < 0 || p.Font.DPI > 1000 {
+		report(fmt.Sprintf("%g is out of range, must be greater than 0 and at most 1000", p.Font.DPI), "font", "dpi")
+	}
+}
+
+// EnvList returns the profile's environment variables as KEY=VALUE pairs, sorted by key
+func (p *Profile) EnvList() []string {
+	keys := make([]string, 0, len(p.Env))
+	for key := range p.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		env = append(env, key+"="+p.Env[key])
+	}
+	return env
+}
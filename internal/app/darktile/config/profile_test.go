@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestApplyProfile(t *testing.T) {
+	conf := DefaultConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+font:
+  family: Hack
+profiles:
+  demo:
+    shell: /bin/bash
+    env:
+      PS1: '$ '
+      LANG: C
+    font:
+      size: 28
+      ligatures: false
+    opacity: 0
+`), conf))
+
+	profile, err := conf.ApplyProfile("demo")
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/bash", profile.Shell)
+	assert.Equal(t, []string{"LANG=C", "PS1=$ "}, profile.EnvList())
+
+	assert.Equal(t, "Hack", conf.Font.Family)
+	assert.Equal(t, 28.0, conf.Font.Size)
+	assert.Equal(t, defaultConfig.Font.DPI, conf.Font.DPI)
+	assert.False(t, conf.Font.Ligatures)
+	assert.Equal(t, 0.0, conf.Opacity)
+
+	_, err = conf.ApplyProfile("prod")
+	assert.EqualError(t, err, "unknown profile 'prod' - available profiles are: demo")
+}
+
+func TestApplyProfileOutOfRange(t *testing.T) {
+	conf := DefaultConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+profiles:
+  demo:
+    font:
+      family: Hack
+      size: -4
+      dpi: 2000
+`), conf))
+
+	_, err := conf.ApplyProfile("demo")
+	assert.EqualError(t, err, "invalid profile 'demo' - font.size: -4 is out of range, must be greater than 0 and at most 500, "+
+		"font.dpi: 2000 is out of range, must be greater than 0 and at most 1000")
+	assert.Equal(t, defaultConfig.Font, conf.Font)
+}
+
+func TestValidateProfiles(t *testing.T) {
+	data := []byte(`
+profiles:
+  demo:
+    opacity: 2
+    font:
+      sise: 10
+    workingdirectory: /does/not/exist
+`)
+	conf := DefaultConfig()
+	require.NoError(t, yaml.Unmarshal(data, conf))
+
+	err := validateConfig("config.yaml", data, conf)
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok)
+	require.Len(t, validationErr.Problems, 3)
+	assert.Equal(t, "config.yaml:6:7: unknown key 'profiles.demo.font.sise'", validationErr.Problems[0].Error())
+	assert.Equal(t, "config.yaml:4:14: profiles.demo.opacity: 2 is out of range, must be between 0.0 and 1.0", validationErr.Problems[1].Error())
+	assert.Equal(t, "config.yaml:7:23: profiles.demo.workingdirectory: directory '/does/not/exist' could not be found", validationErr.Problems[2].Error())
+}
@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
+	"sort"
 
 	"gopkg.in/yaml.v2"
 )
@@ -32,6 +34,67 @@ type Theme struct {
 	SelectionForeground string
 	CursorForeground    string
 	CursorBackground    string
+
+	// optional colours - these are left unset if empty
+	Bold                  string `yaml:",omitempty"`
+	Dim                   string `yaml:",omitempty"`
+	Link                  string `yaml:",omitempty"`
+	SearchMatchBackground string `yaml:",omitempty"`
+	SearchMatchForeground string `yaml:",omitempty"`
+
+	// Palette overrides entries in the 256 colour palette by index
+	Palette map[int]string `yaml:",omitempty"`
+
+	// Variants are named sets of colours which override the colours above, e.g. a light version of a dark theme
+	Variants map[string]Theme `yaml:",omitempty"`
+}
+
+// DefaultThemeVariant is the name of the variant made up of the top level colours of a theme file
+const DefaultThemeVariant = "default"
+
+// VariantNames returns the names of the variants in the theme, starting with the default variant
+func (t *Theme) VariantNames() []string {
+	names := []string{DefaultThemeVariant}
+	var others []string
+	for name := range t.Variants {
+		if name != DefaultThemeVariant {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+// Variant returns the theme with the colours of the named variant applied on top
+func (t *Theme) Variant(name string) (*Theme, error) {
+	if name == DefaultThemeVariant {
+		if _, ok := t.Variants[name]; !ok {
+			return t, nil
+		}
+	}
+	override, ok := t.Variants[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme variant '%s'", name)
+	}
+
+	merged := *t
+	merged.Variants = nil
+	merged.Palette = make(map[int]string, len(t.Palette)+len(override.Palette))
+	for index, colour := range t.Palette {
+		merged.Palette[index] = colour
+	}
+	for index, colour := range override.Palette {
+		merged.Palette[index] = colour
+	}
+
+	base := reflect.ValueOf(&merged).Elem()
+	overrides := reflect.ValueOf(override)
+	for i := 0; i < base.NumField(); i++ {
+		if base.Field(i).Kind() == reflect.String && overrides.Field(i).String() != "" {
+			base.Field(i).SetString(overrides.Field(i).String())
+		}
+	}
+	return &merged, nil
 }
 
 func getThemePath() (string, error) {
@@ -57,12 +120,26 @@ func loadTheme(themePath string) (*Theme, error) {
 		return nil, fmt.Errorf("failed to read theme file at '%s': %w", themePath, err)
 	}
 
+	if DetectThemeFormat(themePath, themeData) != ThemeFormatDarktile {
+		return ImportTheme(themePath, themeData)
+	}
+
 	theme := defaultTheme
-	if err := yaml.Unmarshal(themeData, &theme); err != nil {
+	problems, err := decodeFile(themePath, themeData, &theme)
+	if err != nil {
 		return nil, fmt.Errorf("invalid theme file at '%s': %w", themePath, err)
 	}
 
-	return &theme, nil
+	// like the config, the theme is still usable if it has problems, so it is returned alongside them
+	err = validateTheme(themePath, themeData, &theme)
+	return &theme, withProblems(problems, err)
+}
+
+// ValidateThemeFile reports all problems in the theme file at the given path, or the default theme
+// file if the path is empty
+func ValidateThemeFile(themePath string) error {
+	_, err := loadTheme(themePath)
+	return err
 }
 
 func (t *Theme) Save() (string, error) {
@@ -71,14 +148,24 @@ func (t *Theme) Save() (string, error) {
 		return "", fmt.Errorf("failed to locate theme path: %w", err)
 	}
 
+	return themePath, t.SaveTo(themePath)
+}
+
+// SaveTo writes the theme as darktile YAML to the given path
+func (t *Theme) SaveTo(themePath string) error {
 	if err := os.MkdirAll(path.Dir(themePath), 0700); err != nil {
-		return "", err
+		return err
 	}
 
 	data, err := yaml.Marshal(t)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return themePath, ioutil.WriteFile(themePath, data, 0600)
+	return ioutil.WriteFile(themePath, data, 0600)
+}
+
+// ThemePath returns the path of the theme file in the darktile config directory
+func ThemePath() (string, error) {
+	return getThemePath()
 }
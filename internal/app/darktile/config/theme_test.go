@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTheme(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "theme.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(data), 0600))
+	return path
+}
+
+func TestThemeVariants(t *testing.T) {
+	path := writeTheme(t, `
+background: '#000000'
+bold: '#ffffff'
+palette:
+  200: '#123456'
+variants:
+  light:
+    background: '#ffffff'
+    foreground: '#000000'
+    palette:
+      201: '#654321'
+`)
+
+	theme, err := loadTheme(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultThemeVariant, "light"}, theme.VariantNames())
+
+	light, err := theme.Variant("light")
+	require.NoError(t, err)
+	assert.Equal(t, "#ffffff", light.Background)
+	assert.Equal(t, "#000000", light.Foreground)
+	assert.Equal(t, "#ffffff", light.Bold)
+	assert.Equal(t, defaultTheme.Red, light.Red)
+	assert.Equal(t, map[int]string{200: "#123456", 201: "#654321"}, light.Palette)
+
+	_, err = theme.Variant("missing")
+	assert.Error(t, err)
+
+	variants, err := LoadThemeVariants(DefaultConfig(), path)
+	require.NoError(t, err)
+	require.Len(t, variants, 2)
+	assert.Equal(t, "light", variants[1].Name)
+
+	colour, err := variants[1].Theme.ColourFrom8Bit("201")
+	require.NoError(t, err)
+	r, g, b, _ := colour.RGBA()
+	assert.Equal(t, []uint32{0x65, 0x43, 0x21}, []uint32{r >> 8, g >> 8, b >> 8})
+}
+
+func TestThemeValidation(t *testing.T) {
+	path := writeTheme(t, `
+red: 'red'
+dim: '#zzzzzz'
+palette:
+  300: '#000000'
+  20: 'blue'
+variants:
+  light:
+    background: '#fff'
+    unknown: '#ffffff'
+`)
+
+	_, err := loadTheme(path)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	var messages []string
+	for _, problem := range validationErr.Problems {
+		messages = append(messages, problem.Message)
+	}
+	assert.Contains(t, messages, "unknown key 'variants.light.unknown'")
+	assert.Contains(t, messages, "red: invalid colour 'red': colour values should start with '#' and contain an RGB value encoded in hex, for example #ffffff")
+	assert.Contains(t, messages, "palette.300: 300 is out of range, must be between 0 and 255")
+	assert.Len(t, messages, 6)
+}
+
+func TestThemeWithProblemsIsStillUsable(t *testing.T) {
+	path := writeTheme(t, `
+red: 'red'
+green: '#00ff00'
+dim: '#zzzzzz'
+palette:
+  300: '#000000'
+  20: 'blue'
+  21: '#0000ff'
+variants:
+  light:
+    background: '#fff'
+    foreground: '#000000'
+    unknown: '#ffffff'
+`)
+
+	theme, err := loadTheme(path)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.NotNil(t, theme)
+	assert.Equal(t, defaultTheme.Red, theme.Red)
+	assert.Equal(t, "#00ff00", theme.Green)
+	assert.Empty(t, theme.Dim)
+	assert.Equal(t, map[int]string{21: "#0000ff"}, theme.Palette)
+
+	light, err := theme.Variant("light")
+	require.NoError(t, err)
+	assert.Equal(t, defaultTheme.Background, light.Background)
+	assert.Equal(t, "#000000", light.Foreground)
+
+	variants, err := LoadThemeVariants(DefaultConfig(), path)
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Problems, 6)
+	require.Len(t, variants, 2)
+	assert.Equal(t, "light", variants[1].Name)
+}
+
+func TestThemeWithValuesOfWrongType(t *testing.T) {
+	path := writeTheme(t, `
+red: '#ff0000'
+palette: [1, 2]
+`)
+
+	theme, err := loadTheme(path)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Problems, 1)
+	assert.Equal(t, 3, validationErr.Problems[0].Line)
+	assert.Equal(t, 10, validationErr.Problems[0].Column)
+	require.NotNil(t, theme)
+	assert.Equal(t, "#ff0000", theme.Red)
+}
@@ -0,0 +1,455 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Problem is an issue found in a config or theme file, along with its location in the file
+type Problem struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", p.Path, p.Line, p.Column, p.Message)
+}
+
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		messages = append(messages, problem.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+var decodeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+var decodeErrorValue = regexp.MustCompile("^cannot unmarshal (!![a-z]+)(?: `(.*)`)? into ")
+
+var fontFeaturePattern = regexp.MustCompile(`^[+-]?[A-Za-z0-9 ]{4}(=\d+)?$`)
+
+// decodeFile decodes a config or theme file on top of the defaults already in out. Values of the wrong type are
+// skipped and returned as problems, so that the rest of the file can still be used.
+func decodeFile(path string, data []byte, out interface{}) ([]Problem, error) {
+	err := yaml.Unmarshal(data, out)
+	var typeErr *yaml.TypeError
+	if err == nil || !errors.As(err, &typeErr) {
+		return nil, err
+	}
+
+	// the decoder only reports lines, so the columns are found from the nodes of the document
+	var document yamlv3.Node
+	_ = yamlv3.Unmarshal(data, &document)
+	problems := make([]Problem, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		problems = append(problems, problemFromDecodeError(path, &document, message))
+	}
+	return problems, nil
+}
+
+// problemFromDecodeError converts an error message from the yaml decoder, e.g. "line 2: cannot unmarshal...",
+// locating it at the value on that line of the document which the message describes
+func problemFromDecodeError(path string, document *yamlv3.Node, message string) Problem {
+	problem := Problem{Path: path, Line: 1, Column: 1, Message: message}
+	if matches := decodeErrorLine.FindStringSubmatch(message); matches != nil {
+		problem.Line, _ = strconv.Atoi(matches[1])
+		problem.Message = matches[2]
+		if node := describedValue(valuesOnLine(document, problem.Line), problem.Message); node != nil {
+			problem.Column = node.Column
+		}
+	}
+	return problem
+}
+
+// valuesOnLine returns the values which start on the given line, outermost first, ignoring mapping keys
+func valuesOnLine(node *yamlv3.Node, line int) []*yamlv3.Node {
+	var found []*yamlv3.Node
+	if node.Kind != yamlv3.DocumentNode && node.Line == line {
+		found = append(found, node)
+	}
+	for i, child := range node.Content {
+		if node.Kind == yamlv3.MappingNode && i%2 == 0 {
+			continue
+		}
+		found = append(found, valuesOnLine(child, line)...)
+	}
+	return found
+}
+
+// describedValue picks the value with the tag and value given in a decode error, e.g. "cannot unmarshal !!str
+// `abc` into float64", or the first value if none match
+func describedValue(values []*yamlv3.Node, message string) *yamlv3.Node {
+	if len(values) == 0 {
+		return nil
+	}
+	if matches := decodeErrorValue.FindStringSubmatch(message); matches != nil {
+		tag, value := matches[1], matches[2]
+		for _, node := range values {
+			if node.ShortTag() != tag {
+				continue
+			}
+			// long values are shortened to their first 7 characters
+			if value == "" || node.Value == value || (strings.HasSuffix(value, "...") && strings.HasPrefix(node.Value, strings.TrimSuffix(value, "..."))) {
+				return node
+			}
+		}
+	}
+	return values[0]
+}
+
+// withProblems combines the problems found while decoding a file with the error from validating it
+func withProblems(problems []Problem, err error) error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return &ValidationError{Problems: append(problems, validationErr.Problems...)}
+	}
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Report records a problem with the value at the given keys, e.g. Report("must be positive", "font", "size")
+type Report func(message string, keys ...string)
+
+// Check is an additional validation rule for a config which has been decoded successfully
+type Check func(conf *Config, report Report)
+
+type validator struct {
+	path     string
+	root     *yamlv3.Node
+	problems []Problem
+}
+
+func newValidator(path string, data []byte) (*validator, error) {
+	v := &validator{path: path}
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) > 0 {
+		v.root = document.Content[0]
+	}
+	return v, nil
+}
+
+func (v *validator) addProblem(node *yamlv3.Node, message string) {
+	line, column := 1, 1
+	if node != nil {
+		line, column = node.Line, node.Column
+	}
+	v.problems = append(v.problems, Problem{
+		Path:    v.path,
+		Line:    line,
+		Column:  column,
+		Message: message,
+	})
+}
+
+func (v *validator) report(message string, keys ...string) {
+	node := v.root
+	for _, key := range keys {
+		child := mappingValue(node, key)
+		if child == nil {
+			break
+		}
+		node = child
+	}
+	v.addProblem(node, fmt.Sprintf("%s: %s", strings.Join(keys, "."), message))
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkKeys reports any keys in the document which do not correspond to a field of the given type.
+// Field names are lowercased to match the default behaviour of the yaml decoder.
+func (v *validator) checkKeys(node *yamlv3.Node, t reflect.Type, path string) {
+	if node == nil {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			if node.Tag != "!!null" {
+				v.addProblem(node, fmt.Sprintf("%s: expected a mapping", path))
+			}
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := fieldByKey(t, key.Value)
+			if !ok {
+				v.addProblem(key, fmt.Sprintf("unknown key '%s'", joinPath(path, key.Value)))
+				continue
+			}
+			v.checkKeys(node.Content[i+1], field.Type, joinPath(path, key.Value))
+		}
+	case reflect.Ptr:
+		v.checkKeys(node, t.Elem(), path)
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			v.checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.ToLower(field.Name) == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// validateConfig reports problems with the raw config file and the config decoded from it. Out of range
+// values which would prevent darktile from working are replaced with their defaults.
+func validateConfig(path string, data []byte, conf *Config, checks ...Check) error {
+	v, err := newValidator(path, data)
+	if err != nil {
+		return err
+	}
+
+	v.checkKeys(v.root, reflect.TypeOf(Config{}), "")
+
+	if conf.Opacity < 0 || conf.Opacity > 1 {
+		v.report(fmt.Sprintf("%g is out of range, must be between 0.0 and 1.0", conf.Opacity), "opacity")
+		conf.Opacity = defaultConfig.Opacity
+	}
+	if conf.Font.Size <= 0 || conf.Font.Size > 500 {
+		v.report(fmt.Sprintf("%g is out of range, must be greater than 0 and at most 500", conf.Font.Size), "font", "size")
+		conf.Font.Size = defaultConfig.Font.Size
+	}
+	if conf.Font.DPI <= 0 || conf.Font.DPI > 1000 {
+		v.report(fmt.Sprintf("%g is out of range, must be greater than 0 and at most 1000", conf.Font.DPI), "font", "dpi")
+		conf.Font.DPI = defaultConfig.Font.DPI
+	}
+	if conf.Font.Weight < 0 || conf.Font.Weight > 1000 {
+		v.report(fmt.Sprintf("%g is out of range, must be between 1 and 1000, or 0 for the font's default", conf.Font.Weight), "font", "weight")
+		conf.Font.Weight = defaultConfig.Font.Weight
+	}
+	if conf.Font.LineThickness < 0 || conf.Font.LineThickness > 50 {
+		v.report(fmt.Sprintf("%d is out of range, must be between 1 and 50, or 0 to fit the font size", conf.Font.LineThickness), "font", "linethickness")
+		conf.Font.LineThickness = defaultConfig.Font.LineThickness
+	}
+	features := conf.Font.Features[:0]
+	for i, feature := range conf.Font.Features {
+		if !fontFeaturePattern.MatchString(feature) {
+			v.report(fmt.Sprintf("'%s' is not a font feature, expected a 4 character tag such as 'ss01', optionally prefixed with + or - or followed by =<value>", feature), "font", "features", strconv.Itoa(i))
+			continue
+		}
+		features = append(features, feature)
+	}
+	conf.Font.Features = features
+	fallbacks := conf.Font.Fallbacks[:0]
+	for i, family := range conf.Font.Fallbacks {
+		if strings.TrimSpace(family) == "" {
+			v.report("font family must not be empty", "font", "fallbacks", strconv.Itoa(i))
+			continue
+		}
+		fallbacks = append(fallbacks, family)
+	}
+	conf.Font.Fallbacks = fallbacks
+	if conf.Colours.MinimumContrast < 1 || conf.Colours.MinimumContrast > 21 {
+		v.report(fmt.Sprintf("%g is out of range, must be between 1 and 21", conf.Colours.MinimumContrast), "colours", "minimumcontrast")
+		conf.Colours.MinimumContrast = defaultConfig.Colours.MinimumContrast
+	}
+	if conf.Cursor.Image != "" {
+		if _, err := os.Stat(conf.Cursor.Image); err != nil {
+			v.report(fmt.Sprintf("cursor image '%s' could not be found", conf.Cursor.Image), "cursor", "image")
+		}
+	}
+	switch conf.Clipboard.Backend {
+	case "", "auto", "x11", "wl-clipboard", "xclip":
+	default:
+		v.report(fmt.Sprintf("unknown backend '%s', must be one of auto, x11, wl-clipboard or xclip", conf.Clipboard.Backend), "clipboard", "backend")
+	}
+	switch conf.Export.Format {
+	case "", "html", "svg", "ans":
+	default:
+		v.report(fmt.Sprintf("unknown format '%s', must be one of html, svg or ans", conf.Export.Format), "export", "format")
+	}
+	switch conf.Exit.Hold {
+	case "never", "always", "error":
+	default:
+		v.report(fmt.Sprintf("unknown hold mode '%s', must be one of never, always or error", conf.Exit.Hold), "exit", "hold")
+		conf.Exit.Hold = defaultConfig.Exit.Hold
+	}
+	for i, rule := range conf.Selection.SmartSelection {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			node := mappingValue(mappingValue(v.root, "selection"), "smartselection")
+			if node != nil && i < len(node.Content) {
+				node = mappingValue(node.Content[i], "pattern")
+			}
+			v.addProblem(node, fmt.Sprintf("selection.smartselection[%d].pattern: %s", i, err))
+		}
+	}
+
+	for _, name := range conf.ProfileNames() {
+		v.checkProfile(name, conf.Profiles[name])
+	}
+
+	for _, check := range checks {
+		check(conf, v.report)
+	}
+
+	return v.err()
+}
+
+// checkProfile reports problems with the overrides in a profile. Unlike the rest of the config, these are not
+// replaced with defaults, as the profile may never be used.
+func (v *validator) checkProfile(name string, profile Profile) {
+	profile.checkRanges(func(message string, keys ...string) {
+		v.report(message, append([]string{"profiles", name}, keys...)...)
+	})
+	if profile.WorkingDirectory != "" {
+		if info, err := os.Stat(profile.WorkingDirectory); err != nil || !info.IsDir() {
+			v.report(fmt.Sprintf("directory '%s' could not be found", profile.WorkingDirectory), "profiles", name, "workingdirectory")
+		}
+	}
+	if profile.ThemePath != "" {
+		if _, err := os.Stat(profile.ThemePath); err != nil {
+			v.report(fmt.Sprintf("theme '%s' could not be found", profile.ThemePath), "profiles", name, "themepath")
+		}
+	}
+	var keys []string
+	for key := range profile.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "" || strings.Contains(key, "=") {
+			v.report(fmt.Sprintf("invalid environment variable name '%s'", key), "profiles", name, "env")
+		}
+	}
+}
+
+// validateTheme reports unknown keys and invalid colours in a raw theme file. Invalid colours are replaced with
+// their defaults, so that the theme can still be used.
+func validateTheme(path string, data []byte, theme *Theme) error {
+	v, err := newValidator(path, data)
+	if err != nil {
+		return err
+	}
+
+	v.checkKeys(v.root, reflect.TypeOf(Theme{}), "")
+	v.checkThemeColours(theme, nil, true)
+
+	var names []string
+	for name := range theme.Variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		variant := theme.Variants[name]
+		if len(variant.Variants) > 0 {
+			v.report("variants cannot contain other variants", "variants", name, "variants")
+			variant.Variants = nil
+		}
+		v.checkThemeColours(&variant, []string{"variants", name}, false)
+		theme.Variants[name] = variant
+	}
+
+	return v.err()
+}
+
+// checkThemeColours reports invalid colours in a theme. Fields tagged with omitempty are optional, as are all
+// fields of a variant, where an empty value means the colour is inherited from the rest of the theme. Invalid
+// colours are replaced with those of the default theme, or left unset if they are optional, and invalid palette
+// entries are removed.
+func (v *validator) checkThemeColours(theme *Theme, keys []string, required bool) {
+	value := reflect.ValueOf(theme).Elem()
+	defaults := reflect.ValueOf(defaultTheme)
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() != reflect.String {
+			continue
+		}
+		optional := !required || strings.Contains(field.Tag.Get("yaml"), "omitempty")
+		colour := value.Field(i).String()
+		if colour == "" && optional {
+			continue
+		}
+		if _, err := colourFromHex(colour, 1); err != nil {
+			v.report(fmt.Sprintf("invalid colour '%s': %s", colour, err), append(keys, strings.ToLower(field.Name))...)
+			if optional {
+				value.Field(i).SetString("")
+			} else {
+				value.Field(i).Set(defaults.Field(i))
+			}
+		}
+	}
+
+	var indexes []int
+	for index := range theme.Palette {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		colour := theme.Palette[index]
+		paletteKeys := append(append([]string{}, keys...), "palette", strconv.Itoa(index))
+		if index < 0 || index > 255 {
+			v.report(fmt.Sprintf("%d is out of range, must be between 0 and 255", index), paletteKeys...)
+			delete(theme.Palette, index)
+			continue
+		}
+		if _, err := colourFromHex(colour, 1); err != nil {
+			v.report(fmt.Sprintf("invalid colour '%s': %s", colour, err), paletteKeys...)
+			delete(theme.Palette, index)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeConfigReportsValuesOfWrongType(t *testing.T) {
+	data := []byte(`
+opacity: 0.5
+font:
+  size: large
+  family: Hack
+selection:
+  smartselection:
+    - name: url
+      pattern: [1]
+`)
+
+	conf, err := decodeConfig("config.yaml", data)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Problems, 2)
+	assert.Equal(t, "config.yaml:4:9: cannot unmarshal !!str `large` into float64", validationErr.Problems[0].Error())
+	assert.Equal(t, "config.yaml:9:16: cannot unmarshal !!seq into string", validationErr.Problems[1].Error())
+
+	// the rest of the file is still used
+	require.NotNil(t, conf)
+	assert.Equal(t, 0.5, conf.Opacity)
+	assert.Equal(t, "Hack", conf.Font.Family)
+	assert.Equal(t, defaultConfig.Font.Size, conf.Font.Size)
+}
+
+func TestDecodeConfigCombinesProblems(t *testing.T) {
+	data := []byte(`
+opacity: 2
+font:
+  dpi: high
+colour: {}
+`)
+
+	conf, err := decodeConfig("config.yaml", data)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	var messages []string
+	for _, problem := range validationErr.Problems {
+		messages = append(messages, problem.Error())
+	}
+	assert.Equal(t, []string{
+		"config.yaml:4:8: cannot unmarshal !!str `high` into float64",
+		"config.yaml:5:1: unknown key 'colour'",
+		"config.yaml:2:10: opacity: 2 is out of range, must be between 0.0 and 1.0",
+	}, messages)
+	assert.Equal(t, defaultConfig.Opacity, conf.Opacity)
+}
+
+func TestDecodeConfigRejectsInvalidYAML(t *testing.T) {
+	conf, err := decodeConfig("config.yaml", []byte("font: [\n"))
+	require.Error(t, err)
+	assert.Nil(t, conf)
+	var validationErr *ValidationError
+	assert.False(t, errors.As(err, &validationErr))
+}
+
+func TestDecodeErrorsAreLocatedAtTheirValues(t *testing.T) {
+	data := []byte(`opacity: x
+font: {family: Hack, size: z}
+exit:
+    hold: [1]
+colours: {minimumcontrast: [2]}
+`)
+	tests := []struct {
+		line   int
+		column int
+	}{
+		{1, 10},
+		{2, 28},
+		{4, 11},
+		{5, 28},
+	}
+
+	problems, err := decodeFile("config.yaml", data, DefaultConfig())
+	require.NoError(t, err)
+	require.Len(t, problems, len(tests))
+	for i, test := range tests {
+		assert.Equal(t, test.line, problems[i].Line, "problem %d", i)
+		assert.Equal(t, test.column, problems[i].Column, "problem %d", i)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{
+		exists:  true,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+}
+
+// Watch polls the config file and the theme file (or the theme at themePath, if given) and calls
+// onChange whenever either of them is created, modified or removed. It blocks forever.
+func Watch(themePath string, interval time.Duration, onChange func()) error {
+
+	configPath, err := getConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to locate config path: %w", err)
+	}
+
+	if themePath == "" {
+		themePath, err = getThemePath()
+		if err != nil {
+			return fmt.Errorf("failed to locate theme path: %w", err)
+		}
+	}
+
+	paths := []string{configPath, themePath}
+	states := make([]fileState, len(paths))
+	for i, path := range paths {
+		states[i] = statFile(path)
+	}
+
+	for range time.Tick(interval) {
+		var changed bool
+		for i, path := range paths {
+			state := statFile(path)
+			if state != states[i] {
+				states[i] = state
+				changed = true
+			}
+		}
+		if changed {
+			onChange()
+		}
+	}
+
+	return nil
+}
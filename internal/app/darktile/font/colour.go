@@ -0,0 +1,228 @@
+package font
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	gotext "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/tiff"
+)
+
+// maxColourGlyphs is the number of colour glyph images which are kept before the cache is cleared
+const maxColourGlyphs = 1024
+
+var (
+	tagCOLR = ot.MustNewTag("COLR")
+	tagCPAL = ot.MustNewTag("CPAL")
+	tagCBDT = ot.MustNewTag("CBDT")
+	tagSbix = ot.MustNewTag("sbix")
+)
+
+// colourFont holds the tables of a font which has colour glyphs, such as an emoji font. Glyphs are drawn from
+// the COLR table if it has them, otherwise from the colour bitmaps of the CBDT or sbix tables.
+type colourFont struct {
+	face    *gotext.Face
+	colr    *colrTable
+	palette []color.NRGBA
+	bitmaps bool
+}
+
+type colourKey struct {
+	r          rune
+	cells      int
+	foreground color.RGBA64
+}
+
+// loadColourFont parses the colour tables of a font, returning nil if it has none
+func loadColourFont(src ot.Resource) *colourFont {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+	ld, err := ot.NewLoader(src)
+	if err != nil {
+		return nil
+	}
+	cf := &colourFont{
+		bitmaps: ld.HasTable(tagCBDT) || ld.HasTable(tagSbix),
+	}
+	if data, err := ld.RawTable(tagCOLR); err == nil {
+		cf.colr = parseCOLR(data)
+		if data, err := ld.RawTable(tagCPAL); err == nil {
+			cf.palette = parseCPAL(data)
+		}
+	}
+	if cf.colr == nil && !cf.bitmaps {
+		return nil
+	}
+	fnt, err := gotext.NewFont(ld)
+	if err != nil {
+		return nil
+	}
+	cf.face = gotext.NewFace(fnt)
+	return cf
+}
+
+// ColourGlyph returns a colour image of the rune, such as an emoji, which fills the given number of cells. Nil is
+// returned if the font which draws the rune has no colour glyph for it, in which case the rune should be drawn
+// from its face as usual. Parts of the glyph which the font draws in the text colour use the foreground colour.
+func (m *Manager) ColourGlyph(r rune, cells int, foreground color.Color) *image.RGBA {
+
+	cf := m.colourFont(m.runeResource(r))
+	if cf == nil {
+		return nil
+	}
+
+	fr, fg, fb, fa := foreground.RGBA()
+	key := colourKey{r: r, cells: cells, foreground: color.RGBA64{R: uint16(fr), G: uint16(fg), B: uint16(fb), A: uint16(fa)}}
+	if img, ok := m.colourGlyphs[key]; ok {
+		return img
+	}
+
+	var img *image.RGBA
+	if gid, ok := cf.face.NominalGlyph(r); ok {
+		img = cf.draw(gid, image.Rect(0, 0, cells*m.charSize.X, m.charSize.Y), foreground)
+	}
+
+	if m.colourGlyphs == nil || len(m.colourGlyphs) >= maxColourGlyphs {
+		m.colourGlyphs = make(map[colourKey]*image.RGBA)
+	}
+	m.colourGlyphs[key] = img
+	return img
+}
+
+// runeResource returns the font file which draws the rune, either the primary font or a fallback
+func (m *Manager) runeResource(r rune) ot.Resource {
+	if m.Covers(r) {
+		if source, ok := m.sources[Regular]; ok {
+			return source.resource
+		}
+		return nil
+	}
+	if fb := m.runeFallback(r); fb != nil {
+		return fb.resource
+	}
+	return nil
+}
+
+// colourFont returns the colour tables of a font file, parsing them when the file is first used
+func (m *Manager) colourFont(src ot.Resource) *colourFont {
+	if src == nil {
+		return nil
+	}
+	cf, ok := m.colourFonts[src]
+	if !ok {
+		cf = loadColourFont(src)
+		if m.colourFonts == nil {
+			m.colourFonts = make(map[ot.Resource]*colourFont)
+		}
+		m.colourFonts[src] = cf
+	}
+	return cf
+}
+
+// draw draws a colour glyph fitted into the bounds, returning nil if the font has no colour glyph for it
+func (cf *colourFont) draw(gid gotext.GID, bounds image.Rectangle, foreground color.Color) *image.RGBA {
+
+	t, ppem := cf.layout(gid, bounds)
+
+	if cf.colr != nil {
+		painter := &colrPainter{
+			colr:       cf.colr,
+			face:       cf.face,
+			palette:    cf.palette,
+			foreground: foreground,
+			bounds:     bounds,
+		}
+		if paint, ok := cf.colr.paintV1(gid); ok {
+			img := image.NewRGBA(bounds)
+			painter.paint(img, paint, t, 0)
+			return img
+		}
+		if layers, count, ok := cf.colr.layersV0(gid); ok {
+			img := image.NewRGBA(bounds)
+			painter.drawV0(img, layers, count, t)
+			return img
+		}
+	}
+
+	if cf.bitmaps {
+		return cf.drawBitmap(gid, bounds, t, ppem)
+	}
+	return nil
+}
+
+// layout returns the transform from font units to pixels which fits the glyph's em box - its advance by the
+// font's ascent and descent - centred in the bounds, along with the resulting size of the em in pixels
+func (cf *colourFont) layout(gid gotext.GID, bounds image.Rectangle) (affine, float64) {
+	upem := float64(cf.face.Upem())
+	advance := float64(cf.face.HorizontalAdvance(gid))
+	if advance <= 0 {
+		advance = upem
+	}
+	ascent, descent := upem*0.8, -upem*0.2
+	if extents, ok := cf.face.FontHExtents(); ok && extents.Ascender > extents.Descender {
+		ascent, descent = float64(extents.Ascender), float64(extents.Descender)
+	}
+
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	scale := math.Min(w/advance, h/(ascent-descent))
+	x0 := float64(bounds.Min.X) + (w-advance*scale)/2
+	y0 := float64(bounds.Min.Y) + (h-(ascent-descent)*scale)/2
+
+	// font units have y increasing upwards, pixels downwards
+	return affine{xx: scale, yy: -scale, dx: x0, dy: y0 + ascent*scale}, upem * scale
+}
+
+// drawBitmap draws a colour bitmap from the CBDT or sbix table, scaled from the strike closest in size
+func (cf *colourFont) drawBitmap(gid gotext.GID, bounds image.Rectangle, t affine, ppem float64) *image.RGBA {
+
+	size := uint16(math.Max(1, math.Round(ppem)))
+	cf.face.SetPpem(size, size)
+
+	bitmap, ok := cf.face.GlyphData(gid).(gotext.GlyphBitmap)
+	if !ok {
+		return nil
+	}
+
+	var src image.Image
+	var err error
+	switch bitmap.Format {
+	case gotext.PNG:
+		src, err = png.Decode(bytes.NewReader(bitmap.Data))
+	case gotext.JPG:
+		src, err = jpeg.Decode(bytes.NewReader(bitmap.Data))
+	case gotext.TIFF:
+		src, err = tiff.Decode(bytes.NewReader(bitmap.Data))
+	default:
+		// black and white bitmaps aren't colour glyphs, so are drawn from the outlines
+		return nil
+	}
+	if err != nil || src.Bounds().Empty() {
+		return nil
+	}
+
+	// the bitmap covers the glyph's extents, which are in font units
+	var dr image.Rectangle
+	if extents, ok := cf.face.GlyphExtents(gid); ok && extents.Width != 0 && extents.Height != 0 {
+		x0, y0 := t.apply(float64(extents.XBearing), float64(extents.YBearing))
+		x1, y1 := t.apply(float64(extents.XBearing+extents.Width), float64(extents.YBearing+extents.Height))
+		dr = image.Rect(int(math.Round(x0)), int(math.Round(y0)), int(math.Round(x1)), int(math.Round(y1)))
+	} else {
+		// without extents the bitmap is fitted into the bounds, keeping its aspect ratio
+		sb := src.Bounds()
+		scale := math.Min(float64(bounds.Dx())/float64(sb.Dx()), float64(bounds.Dy())/float64(sb.Dy()))
+		w, h := int(math.Round(float64(sb.Dx())*scale)), int(math.Round(float64(sb.Dy())*scale))
+		dr = image.Rect(0, 0, w, h).Add(bounds.Min).Add(image.Pt((bounds.Dx()-w)/2, (bounds.Dy()-h)/2))
+	}
+
+	img := image.NewRGBA(bounds)
+	xdraw.CatmullRom.Scale(img, dr, src, src.Bounds(), xdraw.Over, nil)
+	return img
+}
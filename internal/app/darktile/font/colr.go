@@ -0,0 +1,757 @@
+package font
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	gotext "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"golang.org/x/image/vector"
+)
+
+// foregroundPaletteIndex is the palette index of the text colour
+const foregroundPaletteIndex = 0xFFFF
+
+// maxPaintDepth and maxPaintOperations protect against paint graphs which are cyclic or very large
+const (
+	maxPaintDepth      = 64
+	maxPaintOperations = 10000
+)
+
+// colrTable is the COLR table of a font, which describes colour glyphs as layers of other glyphs. Version 0
+// layers are filled with solid colours from the CPAL palette, version 1 layers form a graph of paints with
+// gradients, transforms and blending. Variations are not applied, so variable colour fonts are drawn with their
+// default values.
+type colrTable struct {
+	data          []byte
+	numBaseGlyphs int
+	baseGlyphs    int // offset of the version 0 base glyph records
+	layers        int // offset of the version 0 layer records
+	numLayers     int
+	baseGlyphList int // offset of the version 1 base glyph list, or 0
+	layerList     int // offset of the version 1 layer list, or 0
+}
+
+func parseCOLR(data []byte) *colrTable {
+	if len(data) < 14 {
+		return nil
+	}
+	t := &colrTable{data: data}
+	version := t.u16(0)
+	t.numBaseGlyphs = int(t.u16(2))
+	t.baseGlyphs = int(t.u32(4))
+	t.layers = int(t.u32(8))
+	t.numLayers = int(t.u16(12))
+	if version >= 1 {
+		t.baseGlyphList = int(t.u32(14))
+		t.layerList = int(t.u32(18))
+	}
+	return t
+}
+
+// out of range reads return zero, which is a null offset or an unknown paint, so malformed tables draw nothing
+// rather than panicking
+
+func (t *colrTable) u8(offset int) uint8 {
+	if offset < 0 || offset+1 > len(t.data) {
+		return 0
+	}
+	return t.data[offset]
+}
+
+func (t *colrTable) u16(offset int) uint16 {
+	if offset < 0 || offset+2 > len(t.data) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(t.data[offset:])
+}
+
+func (t *colrTable) i16(offset int) float64 {
+	return float64(int16(t.u16(offset)))
+}
+
+func (t *colrTable) u24(offset int) uint32 {
+	if offset < 0 || offset+3 > len(t.data) {
+		return 0
+	}
+	return uint32(t.data[offset])<<16 | uint32(t.data[offset+1])<<8 | uint32(t.data[offset+2])
+}
+
+func (t *colrTable) u32(offset int) uint32 {
+	if offset < 0 || offset+4 > len(t.data) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(t.data[offset:])
+}
+
+// f2dot14 reads a 2.14 fixed point number
+func (t *colrTable) f2dot14(offset int) float64 {
+	return float64(int16(t.u16(offset))) / (1 << 14)
+}
+
+// fixed reads a 16.16 fixed point number
+func (t *colrTable) fixed(offset int) float64 {
+	return float64(int32(t.u32(offset))) / (1 << 16)
+}
+
+// offset24 reads an offset relative to the given base, returning 0 for null offsets
+func (t *colrTable) offset24(base, offset int) int {
+	if o := t.u24(offset); o != 0 {
+		return base + int(o)
+	}
+	return 0
+}
+
+// layersV0 returns the version 0 layers of a glyph as the offset and number of its layer records
+func (t *colrTable) layersV0(gid gotext.GID) (int, int, bool) {
+	i := sort.Search(t.numBaseGlyphs, func(i int) bool {
+		return gotext.GID(t.u16(t.baseGlyphs+i*6)) >= gid
+	})
+	if i == t.numBaseGlyphs || gotext.GID(t.u16(t.baseGlyphs+i*6)) != gid {
+		return 0, 0, false
+	}
+	record := t.baseGlyphs + i*6
+	first, count := int(t.u16(record+2)), int(t.u16(record+4))
+	if first+count > t.numLayers {
+		return 0, 0, false
+	}
+	return t.layers + first*4, count, true
+}
+
+// paintV1 returns the offset of the root paint of a version 1 glyph
+func (t *colrTable) paintV1(gid gotext.GID) (int, bool) {
+	if t.baseGlyphList == 0 {
+		return 0, false
+	}
+	count := int(t.u32(t.baseGlyphList))
+	records := t.baseGlyphList + 4
+	i := sort.Search(count, func(i int) bool {
+		return gotext.GID(t.u16(records+i*6)) >= gid
+	})
+	if i == count || gotext.GID(t.u16(records+i*6)) != gid {
+		return 0, false
+	}
+	paint := int(t.u32(records + i*6 + 2))
+	if paint == 0 {
+		return 0, false
+	}
+	return t.baseGlyphList + paint, true
+}
+
+// has returns true if the table has a colour glyph for the glyph
+func (t *colrTable) has(gid gotext.GID) bool {
+	if _, ok := t.paintV1(gid); ok {
+		return true
+	}
+	_, _, ok := t.layersV0(gid)
+	return ok
+}
+
+// parseCPAL returns the colours of the first palette of a CPAL table
+func parseCPAL(data []byte) []color.NRGBA {
+	if len(data) < 14 {
+		return nil
+	}
+	numEntries := int(binary.BigEndian.Uint16(data[2:]))
+	numPalettes := int(binary.BigEndian.Uint16(data[4:]))
+	recordsOffset := int(binary.BigEndian.Uint32(data[8:]))
+	if numPalettes == 0 {
+		return nil
+	}
+	first := int(binary.BigEndian.Uint16(data[12:]))
+	start := recordsOffset + first*4
+	if start+numEntries*4 > len(data) {
+		return nil
+	}
+	palette := make([]color.NRGBA, numEntries)
+	for i := range palette {
+		record := data[start+i*4:]
+		palette[i] = color.NRGBA{B: record[0], G: record[1], R: record[2], A: record[3]}
+	}
+	return palette
+}
+
+// affine maps x, y to xx*x + xy*y + dx, yx*x + yy*y + dy
+type affine struct {
+	xx, yx, xy, yy, dx, dy float64
+}
+
+// then returns the transform which applies b and then a
+func (a affine) then(b affine) affine {
+	return affine{
+		xx: a.xx*b.xx + a.xy*b.yx,
+		yx: a.yx*b.xx + a.yy*b.yx,
+		xy: a.xx*b.xy + a.xy*b.yy,
+		yy: a.yx*b.xy + a.yy*b.yy,
+		dx: a.xx*b.dx + a.xy*b.dy + a.dx,
+		dy: a.yx*b.dx + a.yy*b.dy + a.dy,
+	}
+}
+
+func (a affine) apply(x, y float64) (float64, float64) {
+	return a.xx*x + a.xy*y + a.dx, a.yx*x + a.yy*y + a.dy
+}
+
+func (a affine) invert() (affine, bool) {
+	det := a.xx*a.yy - a.xy*a.yx
+	if math.Abs(det) < 1e-12 {
+		return affine{}, false
+	}
+	inv := affine{xx: a.yy / det, xy: -a.xy / det, yx: -a.yx / det, yy: a.xx / det}
+	inv.dx = -(inv.xx*a.dx + inv.xy*a.dy)
+	inv.dy = -(inv.yx*a.dx + inv.yy*a.dy)
+	return inv, true
+}
+
+func translation(dx, dy float64) affine {
+	return affine{xx: 1, yy: 1, dx: dx, dy: dy}
+}
+
+// aroundCenter applies a transform about a centre point rather than the origin
+func aroundCenter(t affine, cx, cy float64) affine {
+	return translation(cx, cy).then(t).then(translation(-cx, -cy))
+}
+
+// colrPainter draws a colour glyph from a COLR table into an image
+type colrPainter struct {
+	colr       *colrTable
+	face       *gotext.Face
+	palette    []color.NRGBA
+	foreground color.Color
+	bounds     image.Rectangle
+	rast       vector.Rasterizer
+	operations int // paints drawn so far
+}
+
+// drawV0 draws each layer of a version 0 glyph, filled with its palette colour
+func (p *colrPainter) drawV0(dst *image.RGBA, layers, count int, t affine) {
+	for i := 0; i < count; i++ {
+		record := layers + i*4
+		mask := p.glyphMask(gotext.GID(p.colr.u16(record)), t)
+		if mask == nil {
+			continue
+		}
+		colour := p.colour(p.colr.u16(record+2), 1)
+		draw.DrawMask(dst, p.bounds, image.NewUniform(colour), image.Point{}, mask, image.Point{}, draw.Over)
+	}
+}
+
+// colour returns a palette colour with its alpha scaled
+func (p *colrPainter) colour(index uint16, alpha float64) color.NRGBA {
+	var c color.NRGBA
+	if index == foregroundPaletteIndex {
+		c = color.NRGBAModel.Convert(p.foreground).(color.NRGBA)
+	} else if int(index) < len(p.palette) {
+		c = p.palette[index]
+	}
+	c.A = uint8(math.Round(float64(c.A) * math.Max(0, math.Min(1, alpha))))
+	return c
+}
+
+// glyphMask rasterises the outline of a glyph, transformed from font units to pixels
+func (p *colrPainter) glyphMask(gid gotext.GID, t affine) *image.Alpha {
+	var segments []ot.Segment
+	switch data := p.face.GlyphData(gid).(type) {
+	case gotext.GlyphOutline:
+		segments = data.Segments
+	case gotext.GlyphBitmap:
+		if data.Outline != nil {
+			segments = data.Outline.Segments
+		}
+	case gotext.GlyphSVG:
+		segments = data.Outline.Segments
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	point := func(sp ot.SegmentPoint) (float32, float32) {
+		x, y := t.apply(float64(sp.X), float64(sp.Y))
+		return float32(x), float32(y)
+	}
+
+	p.rast.Reset(p.bounds.Dx(), p.bounds.Dy())
+	p.rast.DrawOp = draw.Src
+	for _, seg := range segments {
+		switch seg.Op {
+		case ot.SegmentOpMoveTo:
+			p.rast.ClosePath()
+			p.rast.MoveTo(point(seg.Args[0]))
+		case ot.SegmentOpLineTo:
+			p.rast.LineTo(point(seg.Args[0]))
+		case ot.SegmentOpQuadTo:
+			x1, y1 := point(seg.Args[0])
+			x2, y2 := point(seg.Args[1])
+			p.rast.QuadTo(x1, y1, x2, y2)
+		case ot.SegmentOpCubeTo:
+			x1, y1 := point(seg.Args[0])
+			x2, y2 := point(seg.Args[1])
+			x3, y3 := point(seg.Args[2])
+			p.rast.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	p.rast.ClosePath()
+	mask := image.NewAlpha(p.bounds)
+	p.rast.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	return mask
+}
+
+// paint draws a version 1 paint, and the paints beneath it, over the image. The transform maps the paint's
+// coordinates, in font units, to pixels.
+func (p *colrPainter) paint(dst *image.RGBA, offset int, t affine, depth int) {
+	if offset == 0 || depth > maxPaintDepth || p.operations >= maxPaintOperations {
+		return
+	}
+	p.operations++
+	c := p.colr
+
+	switch format := c.u8(offset); format {
+	case 1: // PaintColrLayers
+		count := int(c.u8(offset + 1))
+		first := int(c.u32(offset + 2))
+		if c.layerList == 0 || first+count > int(c.u32(c.layerList)) {
+			return
+		}
+		for i := 0; i < count; i++ {
+			layer := int(c.u32(c.layerList + 4 + (first+i)*4))
+			if layer != 0 {
+				p.paint(dst, c.layerList+layer, t, depth+1)
+			}
+		}
+	case 2, 3: // PaintSolid, PaintVarSolid
+		colour := p.colour(c.u16(offset+1), c.f2dot14(offset+3))
+		draw.Draw(dst, p.bounds, image.NewUniform(colour), image.Point{}, draw.Over)
+	case 4, 5: // PaintLinearGradient, PaintVarLinearGradient
+		line := p.colourLine(c.offset24(offset, offset+1), format == 5)
+		x0, y0 := c.i16(offset+4), c.i16(offset+6)
+		x1, y1 := c.i16(offset+8), c.i16(offset+10)
+		x2, y2 := c.i16(offset+12), c.i16(offset+14)
+		p.fillGradient(dst, t, line, linearGradient(x0, y0, x1, y1, x2, y2))
+	case 6, 7: // PaintRadialGradient, PaintVarRadialGradient
+		line := p.colourLine(c.offset24(offset, offset+1), format == 7)
+		x0, y0, r0 := c.i16(offset+4), c.i16(offset+6), float64(c.u16(offset+8))
+		x1, y1, r1 := c.i16(offset+10), c.i16(offset+12), float64(c.u16(offset+14))
+		p.fillGradient(dst, t, line, radialGradient(x0, y0, r0, x1, y1, r1))
+	case 8, 9: // PaintSweepGradient, PaintVarSweepGradient
+		line := p.colourLine(c.offset24(offset, offset+1), format == 9)
+		cx, cy := c.i16(offset+4), c.i16(offset+6)
+		start, end := c.f2dot14(offset+8)*180, c.f2dot14(offset+10)*180
+		p.fillGradient(dst, t, line, sweepGradient(cx, cy, start, end))
+	case 10: // PaintGlyph
+		mask := p.glyphMask(gotext.GID(c.u16(offset+4)), t)
+		if mask == nil {
+			return
+		}
+		layer := image.NewRGBA(p.bounds)
+		p.paint(layer, c.offset24(offset, offset+1), t, depth+1)
+		draw.DrawMask(dst, p.bounds, layer, image.Point{}, mask, image.Point{}, draw.Over)
+	case 11: // PaintColrGlyph
+		if paint, ok := c.paintV1(gotext.GID(c.u16(offset + 1))); ok {
+			p.paint(dst, paint, t, depth+1)
+		}
+	case 12, 13: // PaintTransform, PaintVarTransform
+		matrix := c.offset24(offset, offset+4)
+		if matrix == 0 {
+			return
+		}
+		transform := affine{
+			xx: c.fixed(matrix), yx: c.fixed(matrix + 4),
+			xy: c.fixed(matrix + 8), yy: c.fixed(matrix + 12),
+			dx: c.fixed(matrix + 16), dy: c.fixed(matrix + 20),
+		}
+		p.paint(dst, c.offset24(offset, offset+1), t.then(transform), depth+1)
+	case 14, 15: // PaintTranslate, PaintVarTranslate
+		transform := translation(c.i16(offset+4), c.i16(offset+6))
+		p.paint(dst, c.offset24(offset, offset+1), t.then(transform), depth+1)
+	case 16, 17, 18, 19, 20, 21, 22, 23: // PaintScale and its uniform, around centre and variable forms
+		sx := c.f2dot14(offset + 4)
+		sy, centre := sx, offset+6
+		if format < 20 {
+			sy, centre = c.f2dot14(offset+6), offset+8
+		}
+		transform := affine{xx: sx, yy: sy}
+		if format == 18 || format == 19 || format == 22 || format == 23 {
+			transform = aroundCenter(transform, c.i16(centre), c.i16(centre+2))
+		}
+		p.paint(dst, c.offset24(offset, offset+1), t.then(transform), depth+1)
+	case 24, 25, 26, 27: // PaintRotate, PaintVarRotate, PaintRotateAroundCenter, PaintVarRotateAroundCenter
+		angle := c.f2dot14(offset+4) * math.Pi
+		sin, cos := math.Sincos(angle)
+		transform := affine{xx: cos, yx: sin, xy: -sin, yy: cos}
+		if format >= 26 {
+			transform = aroundCenter(transform, c.i16(offset+6), c.i16(offset+8))
+		}
+		p.paint(dst, c.offset24(offset, offset+1), t.then(transform), depth+1)
+	case 28, 29, 30, 31: // PaintSkew, PaintVarSkew, PaintSkewAroundCenter, PaintVarSkewAroundCenter
+		xSkew := c.f2dot14(offset+4) * math.Pi
+		ySkew := c.f2dot14(offset+6) * math.Pi
+		transform := affine{xx: 1, yx: math.Tan(ySkew), xy: -math.Tan(xSkew), yy: 1}
+		if format >= 30 {
+			transform = aroundCenter(transform, c.i16(offset+8), c.i16(offset+10))
+		}
+		p.paint(dst, c.offset24(offset, offset+1), t.then(transform), depth+1)
+	case 32: // PaintComposite
+		source := image.NewRGBA(p.bounds)
+		p.paint(source, c.offset24(offset, offset+1), t, depth+1)
+		backdrop := image.NewRGBA(p.bounds)
+		p.paint(backdrop, c.offset24(offset, offset+5), t, depth+1)
+		composite(backdrop, source, c.u8(offset+4))
+		draw.Draw(dst, p.bounds, backdrop, image.Point{}, draw.Over)
+	}
+}
+
+// colourStop is a colour at a position along a gradient, premultiplied and scaled to 0-1
+type colourStop struct {
+	offset     float64
+	r, g, b, a float64
+}
+
+// colourLine is the colours of a gradient
+type colourLine struct {
+	extend uint8 // 0 to pad, 1 to repeat or 2 to reflect beyond the first and last stops
+	stops  []colourStop
+}
+
+func (p *colrPainter) colourLine(offset int, variable bool) colourLine {
+	c := p.colr
+	if offset == 0 {
+		return colourLine{}
+	}
+	size := 6
+	if variable {
+		size = 10
+	}
+	line := colourLine{extend: c.u8(offset)}
+	count := int(c.u16(offset + 1))
+	for i := 0; i < count; i++ {
+		stop := offset + 3 + i*size
+		colour := p.colour(c.u16(stop+2), c.f2dot14(stop+4))
+		alpha := float64(colour.A) / 0xff
+		line.stops = append(line.stops, colourStop{
+			offset: c.f2dot14(stop),
+			r:      float64(colour.R) / 0xff * alpha,
+			g:      float64(colour.G) / 0xff * alpha,
+			b:      float64(colour.B) / 0xff * alpha,
+			a:      alpha,
+		})
+	}
+	sort.SliceStable(line.stops, func(i, j int) bool {
+		return line.stops[i].offset < line.stops[j].offset
+	})
+	return line
+}
+
+// at returns the colour at a position along the gradient
+func (line colourLine) at(t float64) colourStop {
+	first, last := line.stops[0], line.stops[len(line.stops)-1]
+	if span := last.offset - first.offset; span > 1e-9 && line.extend != 0 {
+		u := (t - first.offset) / span
+		switch line.extend {
+		case 1:
+			u -= math.Floor(u)
+		case 2:
+			u = math.Mod(math.Abs(u), 2)
+			if u > 1 {
+				u = 2 - u
+			}
+		}
+		t = first.offset + u*span
+	}
+	if t <= first.offset {
+		return first
+	}
+	for i := 1; i < len(line.stops); i++ {
+		next := line.stops[i]
+		if t <= next.offset {
+			prev := line.stops[i-1]
+			f := (t - prev.offset) / (next.offset - prev.offset)
+			return colourStop{
+				r: prev.r + (next.r-prev.r)*f,
+				g: prev.g + (next.g-prev.g)*f,
+				b: prev.b + (next.b-prev.b)*f,
+				a: prev.a + (next.a-prev.a)*f,
+			}
+		}
+	}
+	return last
+}
+
+// gradient returns the position along the colour line of a point in font units, or false if the point isn't painted
+type gradient func(x, y float64) (float64, bool)
+
+func linearGradient(x0, y0, x1, y1, x2, y2 float64) gradient {
+	// the colour line runs from p0 towards p1, but is rotated so that its stripes are parallel to p0-p2
+	nx, ny := -(y2 - y0), x2-x0
+	dx, dy := x1-x0, y1-y0
+	if n := nx*nx + ny*ny; n > 1e-9 {
+		d := (dx*nx + dy*ny) / n
+		dx, dy = nx*d, ny*d
+	}
+	length := dx*dx + dy*dy
+	return func(x, y float64) (float64, bool) {
+		if length < 1e-9 {
+			return 0, false
+		}
+		return ((x-x0)*dx + (y-y0)*dy) / length, true
+	}
+}
+
+func radialGradient(x0, y0, r0, x1, y1, r1 float64) gradient {
+	// the gradient is the set of circles interpolated between the two circles, with later circles drawn on top
+	cdx, cdy, dr := x1-x0, y1-y0, r1-r0
+	a := cdx*cdx + cdy*cdy - dr*dr
+	return func(x, y float64) (float64, bool) {
+		pdx, pdy := x-x0, y-y0
+		b := pdx*cdx + pdy*cdy + r0*dr
+		c := pdx*pdx + pdy*pdy - r0*r0
+		if math.Abs(a) < 1e-9 {
+			if math.Abs(b) < 1e-9 {
+				return 0, false
+			}
+			t := c / (2 * b)
+			return t, r0+t*dr >= 0
+		}
+		disc := b*b - a*c
+		if disc < 0 {
+			return 0, false
+		}
+		sq := math.Sqrt(disc)
+		t1, t2 := (b+sq)/a, (b-sq)/a
+		if t1 < t2 {
+			t1, t2 = t2, t1
+		}
+		if r0+t1*dr >= 0 {
+			return t1, true
+		}
+		if r0+t2*dr >= 0 {
+			return t2, true
+		}
+		return 0, false
+	}
+}
+
+func sweepGradient(cx, cy, start, end float64) gradient {
+	return func(x, y float64) (float64, bool) {
+		if math.Abs(end-start) < 1e-9 {
+			return 0, false
+		}
+		angle := math.Atan2(y-cy, x-cx) * 180 / math.Pi
+		if angle < 0 {
+			angle += 360
+		}
+		return (angle - start) / (end - start), true
+	}
+}
+
+// fillGradient paints a gradient over the whole image. Parent glyph paints clip it to their outline.
+func (p *colrPainter) fillGradient(dst *image.RGBA, t affine, line colourLine, g gradient) {
+	inv, ok := t.invert()
+	if !ok || len(line.stops) == 0 {
+		return
+	}
+	for py := p.bounds.Min.Y; py < p.bounds.Max.Y; py++ {
+		for px := p.bounds.Min.X; px < p.bounds.Max.X; px++ {
+			x, y := inv.apply(float64(px)+0.5, float64(py)+0.5)
+			pos, ok := g(x, y)
+			if !ok {
+				continue
+			}
+			c := line.at(pos)
+			i := dst.PixOffset(px, py)
+			pix := dst.Pix[i : i+4 : i+4]
+			pix[0] = blendOver(c.r, pix[0], c.a)
+			pix[1] = blendOver(c.g, pix[1], c.a)
+			pix[2] = blendOver(c.b, pix[2], c.a)
+			pix[3] = blendOver(c.a, pix[3], c.a)
+		}
+	}
+}
+
+func blendOver(src float64, dst uint8, srcAlpha float64) uint8 {
+	return clampByte(src + float64(dst)/0xff*(1-srcAlpha))
+}
+
+func clampByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 0xff))
+}
+
+// composite combines the source image with the backdrop image, storing the result in the backdrop
+func composite(backdrop *image.RGBA, source *image.RGBA, mode uint8) {
+	for i := 0; i+3 < len(backdrop.Pix); i += 4 {
+		var s, d [4]float64
+		for j := 0; j < 4; j++ {
+			s[j] = float64(source.Pix[i+j]) / 0xff
+			d[j] = float64(backdrop.Pix[i+j]) / 0xff
+		}
+		out := compositePixel(s, d, mode)
+		for j := 0; j < 4; j++ {
+			backdrop.Pix[i+j] = clampByte(out[j])
+		}
+	}
+}
+
+// compositePixel combines premultiplied source and backdrop colours with one of the COLR composite modes
+func compositePixel(s, d [4]float64, mode uint8) [4]float64 {
+	sa, da := s[3], d[3]
+	porterDuff := func(fs, fd float64) [4]float64 {
+		return [4]float64{s[0]*fs + d[0]*fd, s[1]*fs + d[1]*fd, s[2]*fs + d[2]*fd, sa*fs + da*fd}
+	}
+	switch mode {
+	case 0: // clear
+		return [4]float64{}
+	case 1: // src
+		return s
+	case 2: // dest
+		return d
+	case 4: // dest over
+		return porterDuff(1-da, 1)
+	case 5: // src in
+		return porterDuff(da, 0)
+	case 6: // dest in
+		return porterDuff(0, sa)
+	case 7: // src out
+		return porterDuff(1-da, 0)
+	case 8: // dest out
+		return porterDuff(0, 1-sa)
+	case 9: // src atop
+		return porterDuff(da, 1-sa)
+	case 10: // dest atop
+		return porterDuff(1-da, sa)
+	case 11: // xor
+		return porterDuff(1-da, 1-sa)
+	case 12: // plus
+		return [4]float64{s[0] + d[0], s[1] + d[1], s[2] + d[2], sa + da}
+	}
+
+	if mode < 13 || mode > 27 {
+		return porterDuff(1, 1-sa) // src over, and unknown modes
+	}
+
+	// blend modes mix the unpremultiplied colours where the source and backdrop overlap
+	var cs, cd [3]float64
+	for j := 0; j < 3; j++ {
+		if sa > 0 {
+			cs[j] = s[j] / sa
+		}
+		if da > 0 {
+			cd[j] = d[j] / da
+		}
+	}
+	var blended [3]float64
+	if mode >= 24 {
+		blended = blendNonSeparable(cs, cd, mode)
+	} else {
+		for j := 0; j < 3; j++ {
+			blended[j] = blendSeparable(cs[j], cd[j], mode)
+		}
+	}
+	var out [4]float64
+	for j := 0; j < 3; j++ {
+		out[j] = (1-da)*s[j] + (1-sa)*d[j] + sa*da*blended[j]
+	}
+	out[3] = sa + da - sa*da
+	return out
+}
+
+func blendSeparable(cs, cd float64, mode uint8) float64 {
+	switch mode {
+	case 13: // screen
+		return cs + cd - cs*cd
+	case 14: // overlay
+		return blendSeparable(cd, cs, 19)
+	case 15: // darken
+		return math.Min(cs, cd)
+	case 16: // lighten
+		return math.Max(cs, cd)
+	case 17: // colour dodge
+		if cd == 0 {
+			return 0
+		}
+		if cs >= 1 {
+			return 1
+		}
+		return math.Min(1, cd/(1-cs))
+	case 18: // colour burn
+		if cd >= 1 {
+			return 1
+		}
+		if cs <= 0 {
+			return 0
+		}
+		return 1 - math.Min(1, (1-cd)/cs)
+	case 19: // hard light
+		if cs <= 0.5 {
+			return cd * 2 * cs
+		}
+		return blendSeparable(2*cs-1, cd, 13)
+	case 20: // soft light
+		if cs <= 0.5 {
+			return cd - (1-2*cs)*cd*(1-cd)
+		}
+		dd := math.Sqrt(cd)
+		if cd <= 0.25 {
+			dd = ((16*cd-12)*cd + 4) * cd
+		}
+		return cd + (2*cs-1)*(dd-cd)
+	case 21: // difference
+		return math.Abs(cs - cd)
+	case 22: // exclusion
+		return cs + cd - 2*cs*cd
+	case 23: // multiply
+		return cs * cd
+	}
+	return cs
+}
+
+// blendNonSeparable implements the hue, saturation, colour and luminosity blend modes
+func blendNonSeparable(cs, cd [3]float64, mode uint8) [3]float64 {
+	lum := func(c [3]float64) float64 {
+		return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+	}
+	clip := func(c [3]float64) [3]float64 {
+		l := lum(c)
+		n := math.Min(c[0], math.Min(c[1], c[2]))
+		x := math.Max(c[0], math.Max(c[1], c[2]))
+		for j := range c {
+			if n < 0 {
+				c[j] = l + (c[j]-l)*l/(l-n)
+			}
+			if x > 1 {
+				c[j] = l + (c[j]-l)*(1-l)/(x-l)
+			}
+		}
+		return c
+	}
+	setLum := func(c [3]float64, l float64) [3]float64 {
+		d := l - lum(c)
+		return clip([3]float64{c[0] + d, c[1] + d, c[2] + d})
+	}
+	sat := func(c [3]float64) float64 {
+		return math.Max(c[0], math.Max(c[1], c[2])) - math.Min(c[0], math.Min(c[1], c[2]))
+	}
+	setSat := func(c [3]float64, s float64) [3]float64 {
+		n := math.Min(c[0], math.Min(c[1], c[2]))
+		x := math.Max(c[0], math.Max(c[1], c[2]))
+		var out [3]float64
+		if x > n {
+			for j := range c {
+				out[j] = (c[j] - n) * s / (x - n)
+			}
+		}
+		return out
+	}
+	switch mode {
+	case 24: // hue
+		return setLum(setSat(cs, sat(cd)), lum(cd))
+	case 25: // saturation
+		return setLum(setSat(cd, sat(cs)), lum(cd))
+	case 26: // colour
+		return setLum(cs, lum(cd))
+	default: // luminosity
+		return setLum(cd, lum(cs))
+	}
+}
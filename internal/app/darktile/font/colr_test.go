@@ -0,0 +1,245 @@
+package font
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	gotext "github.com/go-text/typesetting/font"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the glyphs of the colour test font. The base glyphs are drawn from the left and right halves, which are
+// layers with no characters of their own.
+const (
+	colrLeftGID   gotext.GID = 2
+	colrRightGID  gotext.GID = 3
+	colrV0GID     gotext.GID = 1
+	colrV1GID     gotext.GID = 4
+	colrCyclicGID gotext.GID = 5
+)
+
+var colrGlyphs = []testGlyph{
+	{0, 50, 0, 550, 700},
+	{'a', 50, 0, 550, 700},
+	{0, 0, 0, 300, 800},
+	{0, 300, 0, 600, 800},
+	{'b', 50, 0, 550, 700},
+	{'c', 50, 0, 550, 700},
+	{'d', 50, 0, 550, 700},
+}
+
+var (
+	colrRed  = color.RGBA{R: 0xff, A: 0xff}
+	colrBlue = color.RGBA{B: 0xff, A: 0xff}
+)
+
+func appendUint24(b []byte, v uint32) []byte {
+	return append(b, byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// testCPAL is a palette of red and then green
+func testCPAL() []byte {
+	var b []byte
+	b = appendUint16(b, 0)  // version
+	b = appendUint16(b, 2)  // entries in each palette
+	b = appendUint16(b, 1)  // palettes
+	b = appendUint16(b, 2)  // colour records
+	b = appendUint32(b, 14) // offset of the colour records
+	b = appendUint16(b, 0)  // first record of the palette
+	return append(b, 0x00, 0x00, 0xff, 0xff, 0x00, 0xff, 0x00, 0xff)
+}
+
+// testCOLRv0 draws the 'a' glyph as the left half in red and the right half in the text colour
+func testCOLRv0() []byte {
+	var b []byte
+	b = appendUint16(b, 0)    // version
+	b = appendUint16(b, 1)    // base glyphs
+	b = appendUint32(b, 14)   // offset of the base glyph records
+	b = appendUint32(b, 14+6) // offset of the layer records
+	b = appendUint16(b, 2)    // layers
+	b = appendUint16(b, uint16(colrV0GID))
+	b = appendUint16(b, 0) // first layer
+	b = appendUint16(b, 2) // layers
+	b = appendUint16(b, uint16(colrLeftGID))
+	b = appendUint16(b, 0)
+	b = appendUint16(b, uint16(colrRightGID))
+	return appendUint16(b, foregroundPaletteIndex)
+}
+
+// testCOLRv1 draws the 'b' glyph as the left half filled with a gradient from red to green, and the right half in
+// the text colour. The 'c' glyph is a paint which refers to itself.
+func testCOLRv1() []byte {
+	const (
+		baseGlyphList = 34
+		rootPaint     = 16 // relative to the base glyph list
+		cyclicPaint   = rootPaint + 6
+		layerList     = baseGlyphList + cyclicPaint + 3
+		firstLayer    = 12 // relative to the layer list
+		secondLayer   = firstLayer + 6 + 16 + 15
+	)
+
+	var b []byte
+	b = appendUint16(b, 1) // version
+	b = appendUint16(b, 0)
+	b = appendUint32(b, 0)
+	b = appendUint32(b, 0)
+	b = appendUint16(b, 0)
+	b = appendUint32(b, baseGlyphList)
+	b = appendUint32(b, layerList)
+	b = appendUint32(b, 0) // clip list
+	b = appendUint32(b, 0) // variation index map
+	b = appendUint32(b, 0) // item variation store
+
+	b = appendUint32(b, 2)
+	b = appendUint16(b, uint16(colrV1GID))
+	b = appendUint32(b, rootPaint)
+	b = appendUint16(b, uint16(colrCyclicGID))
+	b = appendUint32(b, cyclicPaint)
+
+	// PaintColrLayers
+	b = append(b, 1, 2)
+	b = appendUint32(b, 0)
+	// PaintColrGlyph
+	b = append(b, 11)
+	b = appendUint16(b, uint16(colrCyclicGID))
+
+	b = appendUint32(b, 2)
+	b = appendUint32(b, firstLayer)
+	b = appendUint32(b, secondLayer)
+
+	// PaintGlyph of a PaintLinearGradient along the x axis, across the left half
+	b = append(b, 10)
+	b = appendUint24(b, 6)
+	b = appendUint16(b, uint16(colrLeftGID))
+	b = append(b, 4)
+	b = appendUint24(b, 16)
+	for _, v := range []uint16{0, 0, 300, 0, 0, 300} {
+		b = appendUint16(b, v)
+	}
+	b = append(b, 0) // the colour line, padded beyond its stops
+	b = appendUint16(b, 2)
+	for _, stop := range [][3]uint16{{0, 0, 0x4000}, {0x4000, 1, 0x4000}} {
+		for _, v := range stop {
+			b = appendUint16(b, v)
+		}
+	}
+
+	// PaintGlyph of a PaintSolid in the text colour
+	b = append(b, 10)
+	b = appendUint24(b, 6)
+	b = appendUint16(b, uint16(colrRightGID))
+	b = append(b, 2)
+	b = appendUint16(b, foregroundPaletteIndex)
+	return appendUint16(b, 0x4000)
+}
+
+func colourTestFont(colr []byte) []byte {
+	return testFont{glyphs: colrGlyphs, tables: map[string][]byte{"COLR": colr, "CPAL": testCPAL()}}.build()
+}
+
+func loadColourTestFont(t *testing.T, colr []byte) *colourFont {
+	cf := loadColourFont(bytes.NewReader(colourTestFont(colr)))
+	require.NotNil(t, cf)
+	return cf
+}
+
+// colrBounds fits the glyphs at a tenth of their size in font units, so that the left half covers x 0-30 and
+// the glyphs cover y 0-80 above the baseline
+var colrBounds = image.Rect(0, 0, 60, 100)
+
+func TestParseCOLRv0(t *testing.T) {
+	colr := parseCOLR(testCOLRv0())
+	require.NotNil(t, colr)
+
+	layers, count, ok := colr.layersV0(colrV0GID)
+	require.True(t, ok)
+	assert.Equal(t, 14+6, layers)
+	assert.Equal(t, 2, count)
+	assert.True(t, colr.has(colrV0GID))
+	assert.False(t, colr.has(colrLeftGID))
+	_, ok = colr.paintV1(colrV0GID)
+	assert.False(t, ok)
+}
+
+func TestParseCOLRv1(t *testing.T) {
+	colr := parseCOLR(testCOLRv1())
+	require.NotNil(t, colr)
+
+	paint, ok := colr.paintV1(colrV1GID)
+	require.True(t, ok)
+	assert.Equal(t, uint8(1), colr.u8(paint), "the root paint is PaintColrLayers")
+	assert.True(t, colr.has(colrCyclicGID))
+	assert.False(t, colr.has(colrV0GID))
+}
+
+func TestParseTruncatedCOLR(t *testing.T) {
+	assert.Nil(t, parseCOLR(testCOLRv1()[:10]))
+
+	// reads beyond the end of the table return zero rather than panicking
+	colr := parseCOLR(testCOLRv1()[:40])
+	require.NotNil(t, colr)
+	assert.False(t, colr.has(colrV1GID))
+}
+
+func TestParseCPAL(t *testing.T) {
+	assert.Equal(t, []color.NRGBA{{R: 0xff, A: 0xff}, {G: 0xff, A: 0xff}}, parseCPAL(testCPAL()))
+	assert.Nil(t, parseCPAL(testCPAL()[:16]))
+}
+
+func TestPaintCOLRv0(t *testing.T) {
+	cf := loadColourTestFont(t, testCOLRv0())
+	img := cf.draw(colrV0GID, colrBounds, colrBlue)
+	require.NotNil(t, img)
+
+	assert.Equal(t, colrRed, img.RGBAAt(15, 40))
+	assert.Equal(t, colrBlue, img.RGBAAt(45, 40))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(15, 90), "nothing is drawn below the baseline")
+}
+
+func TestPaintCOLRv1(t *testing.T) {
+	cf := loadColourTestFont(t, testCOLRv1())
+	img := cf.draw(colrV1GID, colrBounds, colrBlue)
+	require.NotNil(t, img)
+
+	// the gradient runs from red at the left edge to green in the middle
+	left, middle := img.RGBAAt(0, 40), img.RGBAAt(29, 40)
+	assert.Greater(t, left.R, uint8(0xf0))
+	assert.Less(t, left.G, uint8(0x10))
+	assert.Less(t, middle.R, uint8(0x10))
+	assert.Greater(t, middle.G, uint8(0xf0))
+	halfway := img.RGBAAt(15, 40)
+	assert.InDelta(t, 0x80, int(halfway.R), 0x10)
+	assert.InDelta(t, 0x80, int(halfway.G), 0x10)
+
+	assert.Equal(t, colrBlue, img.RGBAAt(45, 40))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(15, 90), "the gradient is clipped to the glyph")
+}
+
+func TestPaintCyclicCOLRv1Terminates(t *testing.T) {
+	cf := loadColourTestFont(t, testCOLRv1())
+	img := cf.draw(colrCyclicGID, colrBounds, colrBlue)
+	require.NotNil(t, img)
+	for _, v := range img.Pix {
+		require.Zero(t, v)
+	}
+}
+
+func TestColourGlyph(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.SetSize(10))
+	require.NoError(t, m.SetFontData("Colour", colourTestFont(testCOLRv0())))
+
+	img := m.ColourGlyph('a', 2, colrBlue)
+	require.NotNil(t, img)
+	assert.Equal(t, image.Rect(0, 0, 2*m.CharSize().X, m.CharSize().Y), img.Bounds())
+	assert.Same(t, img, m.ColourGlyph('a', 2, colrBlue), "colour glyphs are cached")
+
+	assert.Nil(t, m.ColourGlyph('d', 2, colrBlue), "glyphs without colour layers are drawn from the face")
+}
@@ -0,0 +1,340 @@
+package font
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	gotext "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/font/opentype/tables"
+	"github.com/liamg/fontinfo"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// fallback is a font used to draw runes which are missing from the primary font
+type fallback struct {
+	family   string
+	font     *opentype.Font
+	resource ot.Resource // the font file, which is read again for colour glyphs
+	face     font.Face   // created lazily at a size which fits the primary cell
+}
+
+// listFonts lists the installed fonts which are indexed for fallback discovery
+var listFonts = fontinfo.List
+
+// discovery is an index of the runes covered by each installed font, so that a font containing a rune missing from
+// the configured fonts can be found without reading any files while drawing. The index is built in the background,
+// and a font is only opened once it is needed.
+type discovery struct {
+	done       chan struct{}     // closed once candidates and covers are complete
+	candidates []fontinfo.Font   // the regular style of each installed font outside the configured families
+	covers     map[rune]int      // the first candidate which covers each rune
+	found      map[int]*fallback // the candidates opened so far, nil where the font could not be loaded
+	stale      bool              // runes were looked up before the index was complete
+}
+
+func startDiscovery(excluded []string) *discovery {
+	d := &discovery{
+		done:  make(chan struct{}),
+		found: make(map[int]*fallback),
+	}
+	go d.index(excluded)
+	return d
+}
+
+func (d *discovery) index(excluded []string) {
+	defer close(d.done)
+
+	d.covers = make(map[rune]int)
+	fonts, err := listFonts()
+	if err != nil {
+		return
+	}
+	for _, f := range fonts {
+		if StyleName(f.Style) != StyleRegular || containsFamily(excluded, f.Family) {
+			continue
+		}
+		cmap := readCmap(f.Path)
+		if cmap == nil {
+			continue
+		}
+		candidate := len(d.candidates)
+		d.candidates = append(d.candidates, f)
+		for iter := cmap.Iter(); iter.Next(); {
+			r, gid := iter.Char()
+			if _, ok := d.covers[r]; !ok && gid != 0 {
+				d.covers[r] = candidate
+			}
+		}
+	}
+}
+
+func (d *discovery) indexed() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// readCmap reads which runes a font file covers, without parsing the rest of the font
+func readCmap(path string) gotext.Cmap {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	ld, err := ot.NewLoader(f)
+	if err != nil {
+		return nil
+	}
+	raw, err := ld.RawTable(ot.MustNewTag("cmap"))
+	if err != nil {
+		return nil
+	}
+	table, _, err := tables.ParseCmap(raw)
+	if err != nil {
+		return nil
+	}
+	cmap, _, err := gotext.ProcessCmap(table, 0)
+	if err != nil {
+		return nil
+	}
+	return cmap
+}
+
+func containsFamily(families []string, family string) bool {
+	for _, f := range families {
+		if f == family {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFallbackFamilies sets the font families which are searched, in order, for runes the primary font cannot draw.
+// When discover is enabled, installed fonts are searched after the configured families once they have been indexed
+// in the background.
+func (m *Manager) SetFallbackFamilies(families []string, discover bool) error {
+
+	fallbacks := make([]*fallback, 0, len(families))
+	for _, family := range families {
+		fb, err := loadFallback(family)
+		if err != nil {
+			return err
+		}
+		fallbacks = append(fallbacks, fb)
+	}
+
+	m.fallbacks = fallbacks
+	m.discovery = nil
+	if discover {
+		m.discovery = startDiscovery(families)
+	}
+	m.runeFallbacks = make(map[rune]*fallback)
+	return nil
+}
+
+func loadFallback(family string) (*fallback, error) {
+	fonts, err := fontinfo.Match(fontinfo.MatchFamily(family))
+	if err != nil {
+		return nil, err
+	}
+	if len(fonts) == 0 {
+		return nil, fmt.Errorf("could not find fallback font with family '%s'", family)
+	}
+	meta := fonts[0]
+	for _, f := range fonts {
+		if StyleName(f.Style) == StyleRegular {
+			meta = f
+			break
+		}
+	}
+	fnt, src, err := loadFont(meta.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fallback font '%s': %w", family, err)
+	}
+	return &fallback{
+		family:   family,
+		font:     fnt,
+		resource: src,
+	}, nil
+}
+
+// Face returns the face which should be used to draw the rune in the given style. The style's own face is used
+// whenever the primary font contains the rune, otherwise the fallback chain is searched.
+func (m *Manager) Face(style Style, r rune) font.Face {
+
+	primary := m.styleFace(style)
+	if m.Covers(r) {
+		return primary
+	}
+
+	fb := m.runeFallback(r)
+	if fb == nil {
+		return primary
+	}
+	if face := m.fallbackFace(fb); face != nil {
+		return face
+	}
+	return primary
+}
+
+// runeFallback returns the fallback font which draws a rune missing from the primary font, or nil if there is none
+func (m *Manager) runeFallback(r rune) *fallback {
+	if m.runeFallbacks == nil {
+		m.runeFallbacks = make(map[rune]*fallback)
+	}
+	fb, ok := m.runeFallbacks[r]
+	if !ok {
+		// a rune missing from the configured fonts is looked up again once the installed fonts are indexed
+		var final bool
+		fb, final = m.findFallback(r)
+		if final {
+			m.runeFallbacks[r] = fb
+		}
+	}
+	return fb
+}
+
+func (m *Manager) styleFace(style Style) font.Face {
+	switch style {
+	case Bold:
+		return m.BoldFontFace()
+	case Italic:
+		return m.ItalicFontFace()
+	case BoldItalic:
+		return m.BoldItalicFontFace()
+	default:
+		return m.RegularFontFace()
+	}
+}
+
+// findFallback returns the first font in the fallback chain which contains the rune. The result is not final if the
+// installed fonts have not been indexed yet.
+func (m *Manager) findFallback(r rune) (*fallback, bool) {
+	for _, fb := range m.fallbacks {
+		if hasGlyph(fb.font, r) {
+			return fb, true
+		}
+	}
+	if m.discovery == nil {
+		return nil, true
+	}
+	return m.discoverFallback(r)
+}
+
+// discoverFallback looks the rune up in the index of installed fonts, opening the font which contains it the first
+// time it is needed. Nothing is found until the index is complete.
+func (m *Manager) discoverFallback(r rune) (*fallback, bool) {
+	d := m.discovery
+	if !d.indexed() {
+		d.stale = true
+		return nil, false
+	}
+
+	candidate, ok := d.covers[r]
+	if !ok {
+		return nil, true
+	}
+	if fb, ok := d.found[candidate]; ok {
+		return fb, true
+	}
+
+	var fb *fallback
+	if fnt, src, err := loadFont(d.candidates[candidate].Path); err == nil {
+		fb = &fallback{
+			family:   d.candidates[candidate].Family,
+			font:     fnt,
+			resource: src,
+		}
+	}
+	d.found[candidate] = fb
+	return fb, true
+}
+
+// FallbackDiscoveryCompleted reports whether the installed fonts have finished being indexed since runes were drawn
+// without them. It is true only once, after which anything drawn before should be drawn again.
+func (m *Manager) FallbackDiscoveryCompleted() bool {
+	d := m.discovery
+	if d == nil || !d.stale || !d.indexed() {
+		return false
+	}
+	d.stale = false
+	return true
+}
+
+// WaitForFallbackDiscovery blocks until the installed fonts have been indexed, for when a single frame is drawn
+func (m *Manager) WaitForFallbackDiscovery() {
+	if m.discovery != nil {
+		<-m.discovery.done
+	}
+}
+
+// resetFallbackFaces drops the faces of every fallback font, configured or discovered, so that they are created
+// again to fit a new cell size
+func (m *Manager) resetFallbackFaces() {
+	m.runeFallbacks = make(map[rune]*fallback)
+	for _, fb := range m.fallbacks {
+		fb.face = nil
+	}
+	if m.discovery == nil {
+		return
+	}
+	for _, fb := range m.discovery.found {
+		if fb != nil {
+			fb.face = nil
+		}
+	}
+}
+
+// fallbackFace creates the face for a fallback font, scaled so that its line height matches the primary font and
+// its glyphs are no wider than a cell
+func (m *Manager) fallbackFace(fb *fallback) font.Face {
+	if fb.face != nil {
+		return fb.face
+	}
+
+	face, err := m.createFace(fb.font)
+	if err != nil {
+		return nil
+	}
+
+	primaryMetrics := m.regularFace.Metrics()
+	metrics := face.Metrics()
+	scale := 1.0
+	if metrics.Height > 0 {
+		scale = float64(primaryMetrics.Height) / float64(metrics.Height)
+	}
+	if hasGlyph(fb.font, 'M') {
+		cellAdvance, _ := m.regularFace.GlyphAdvance('M')
+		if advance, ok := face.GlyphAdvance('M'); ok && advance > 0 && float64(advance)*scale > float64(cellAdvance) {
+			scale = float64(cellAdvance) / float64(advance)
+		}
+	}
+
+	if math.Abs(scale-1) > 0.01 {
+		scaled, err := opentype.NewFace(fb.font, &opentype.FaceOptions{
+			Size:    m.size * scale,
+			DPI:     m.dpi,
+			Hinting: font.HintingFull,
+		})
+		if err == nil {
+			face = scaled
+		}
+	}
+
+	fb.face = face
+	return face
+}
+
+func hasGlyph(f *opentype.Font, r rune) bool {
+	var buf sfnt.Buffer
+	index, err := f.GlyphIndex(&buf, r)
+	return err == nil && index != 0
+}
@@ -0,0 +1,135 @@
+package font
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamg/fontinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/opentype"
+)
+
+// the runes of the fallback tests, none of which are in Go Mono
+const (
+	fallbackRuneA = '日'
+	fallbackRuneB = '本'
+	fallbackRuneC = '한'
+)
+
+// fallbackTestFont builds a font which contains only the given runes
+func fallbackTestFont(runes ...rune) []byte {
+	glyphs := []testGlyph{{0, 50, 0, 550, 700}}
+	for _, r := range runes {
+		glyphs = append(glyphs, testGlyph{r, 50, 0, 550, 700})
+	}
+	return testFont{glyphs: glyphs}.build()
+}
+
+func configuredFallback(t *testing.T, family string, runes ...rune) *fallback {
+	data := fallbackTestFont(runes...)
+	fnt, err := opentype.Parse(data)
+	require.NoError(t, err)
+	return &fallback{family: family, font: fnt, resource: bytes.NewReader(data)}
+}
+
+type installedTestFont struct {
+	family string
+	style  string
+	runes  []rune
+}
+
+// installTestFonts makes discovery index the given fonts instead of those installed. When release is not nil,
+// listing the fonts blocks until it is closed.
+func installTestFonts(t *testing.T, release <-chan struct{}, fonts ...installedTestFont) {
+	dir := t.TempDir()
+	var list []fontinfo.Font
+	for _, f := range fonts {
+		path := filepath.Join(dir, f.family+" "+f.style+".ttf")
+		require.NoError(t, os.WriteFile(path, fallbackTestFont(f.runes...), 0o600))
+		list = append(list, fontinfo.Font{Family: f.family, Style: f.style, Path: path})
+	}
+
+	previous := listFonts
+	t.Cleanup(func() { listFonts = previous })
+	listFonts = func() ([]fontinfo.Font, error) {
+		if release != nil {
+			<-release
+		}
+		return list, nil
+	}
+}
+
+func TestFallbackChainIsSearchedInOrder(t *testing.T) {
+	m := newTestManager(t)
+	first := configuredFallback(t, "First", fallbackRuneA)
+	second := configuredFallback(t, "Second", fallbackRuneA, fallbackRuneB)
+	require.NoError(t, m.SetFallbackFamilies(nil, false))
+	m.fallbacks = []*fallback{first, second}
+
+	assert.Same(t, m.fallbackFace(first), m.Face(Regular, fallbackRuneA))
+	assert.Same(t, m.fallbackFace(second), m.Face(Regular, fallbackRuneB))
+	assert.Same(t, m.RegularFontFace(), m.Face(Regular, fallbackRuneC), "runes missing from every font use the primary face")
+	assert.Same(t, m.BoldFontFace(), m.Face(Bold, 'a'), "runes in the primary font use the style's face")
+}
+
+func TestDiscoveryUsesIndexOnceComplete(t *testing.T) {
+	release := make(chan struct{})
+	installTestFonts(t, release,
+		installedTestFont{"Bold Only", "Bold", []rune{fallbackRuneC}},
+		installedTestFont{"First", "Regular", []rune{fallbackRuneA}},
+		installedTestFont{"Second", "Regular", []rune{fallbackRuneA, fallbackRuneB}},
+	)
+	m := newTestManager(t)
+	configured := configuredFallback(t, "Configured", fallbackRuneB)
+	require.NoError(t, m.SetFallbackFamilies(nil, true))
+	m.fallbacks = []*fallback{configured}
+
+	// nothing is read from disk or remembered while the fonts are being indexed
+	assert.Same(t, m.RegularFontFace(), m.Face(Regular, fallbackRuneA))
+	assert.Empty(t, m.discovery.found)
+	assert.False(t, m.FallbackDiscoveryCompleted())
+
+	close(release)
+	m.WaitForFallbackDiscovery()
+	assert.True(t, m.FallbackDiscoveryCompleted(), "runes drawn without the index should be drawn again")
+	assert.False(t, m.FallbackDiscoveryCompleted())
+
+	fb := m.runeFallback(fallbackRuneA)
+	require.NotNil(t, fb)
+	assert.Equal(t, "First", fb.family, "the first font listed which covers the rune is used")
+	assert.Same(t, m.fallbackFace(fb), m.Face(Regular, fallbackRuneA))
+	assert.Same(t, configured, m.runeFallback(fallbackRuneB), "configured fonts are searched before installed fonts")
+	assert.Nil(t, m.runeFallback(fallbackRuneC), "only the regular style of installed fonts is indexed")
+	assert.Len(t, m.discovery.found, 1)
+}
+
+func TestDiscoveryExcludesConfiguredFamilies(t *testing.T) {
+	installTestFonts(t, nil,
+		installedTestFont{"Configured", "Regular", []rune{fallbackRuneA}},
+		installedTestFont{"Other", "Regular", []rune{fallbackRuneA}},
+	)
+	d := startDiscovery([]string{"Configured"})
+	<-d.done
+
+	require.Len(t, d.candidates, 1)
+	assert.Equal(t, "Other", d.candidates[0].Family)
+	assert.Equal(t, map[rune]int{fallbackRuneA: 0}, d.covers)
+}
+
+func TestFallbackFacesAreRecreatedOnResize(t *testing.T) {
+	installTestFonts(t, nil, installedTestFont{"Discovered", "Regular", []rune{fallbackRuneA}})
+	m := newTestManager(t)
+	require.NoError(t, m.SetFallbackFamilies(nil, true))
+	m.fallbacks = []*fallback{configuredFallback(t, "Configured", fallbackRuneB)}
+	m.WaitForFallbackDiscovery()
+
+	discovered := m.Face(Regular, fallbackRuneA).Metrics().Height
+	configured := m.Face(Regular, fallbackRuneB).Metrics().Height
+
+	require.NoError(t, m.SetSize(28))
+	assert.Greater(t, m.Face(Regular, fallbackRuneA).Metrics().Height, discovered)
+	assert.Greater(t, m.Face(Regular, fallbackRuneB).Metrics().Height, configured)
+}
@@ -0,0 +1,139 @@
+package font
+
+import (
+	"sort"
+)
+
+// BlockCoverage is the number of code points in a Unicode block which the regular style of the font can draw
+type BlockCoverage struct {
+	Name    string
+	First   rune
+	Last    rune
+	Covered int
+}
+
+// Size returns the number of code points in the block
+func (b BlockCoverage) Size() int {
+	return int(b.Last-b.First) + 1
+}
+
+// blocks are the Unicode blocks reported by Coverage - those most likely to appear in a terminal
+var blocks = []BlockCoverage{
+	{Name: "Basic Latin", First: 0x0000, Last: 0x007F},
+	{Name: "Latin-1 Supplement", First: 0x0080, Last: 0x00FF},
+	{Name: "Latin Extended-A", First: 0x0100, Last: 0x017F},
+	{Name: "Latin Extended-B", First: 0x0180, Last: 0x024F},
+	{Name: "IPA Extensions", First: 0x0250, Last: 0x02AF},
+	{Name: "Spacing Modifier Letters", First: 0x02B0, Last: 0x02FF},
+	{Name: "Combining Diacritical Marks", First: 0x0300, Last: 0x036F},
+	{Name: "Greek and Coptic", First: 0x0370, Last: 0x03FF},
+	{Name: "Cyrillic", First: 0x0400, Last: 0x04FF},
+	{Name: "Armenian", First: 0x0530, Last: 0x058F},
+	{Name: "Hebrew", First: 0x0590, Last: 0x05FF},
+	{Name: "Arabic", First: 0x0600, Last: 0x06FF},
+	{Name: "Devanagari", First: 0x0900, Last: 0x097F},
+	{Name: "Thai", First: 0x0E00, Last: 0x0E7F},
+	{Name: "Georgian", First: 0x10A0, Last: 0x10FF},
+	{Name: "Hangul Jamo", First: 0x1100, Last: 0x11FF},
+	{Name: "Latin Extended Additional", First: 0x1E00, Last: 0x1EFF},
+	{Name: "Greek Extended", First: 0x1F00, Last: 0x1FFF},
+	{Name: "General Punctuation", First: 0x2000, Last: 0x206F},
+	{Name: "Superscripts and Subscripts", First: 0x2070, Last: 0x209F},
+	{Name: "Currency Symbols", First: 0x20A0, Last: 0x20CF},
+	{Name: "Letterlike Symbols", First: 0x2100, Last: 0x214F},
+	{Name: "Number Forms", First: 0x2150, Last: 0x218F},
+	{Name: "Arrows", First: 0x2190, Last: 0x21FF},
+	{Name: "Mathematical Operators", First: 0x2200, Last: 0x22FF},
+	{Name: "Miscellaneous Technical", First: 0x2300, Last: 0x23FF},
+	{Name: "Control Pictures", First: 0x2400, Last: 0x243F},
+	{Name: "Enclosed Alphanumerics", First: 0x2460, Last: 0x24FF},
+	{Name: "Box Drawing", First: 0x2500, Last: 0x257F},
+	{Name: "Block Elements", First: 0x2580, Last: 0x259F},
+	{Name: "Geometric Shapes", First: 0x25A0, Last: 0x25FF},
+	{Name: "Miscellaneous Symbols", First: 0x2600, Last: 0x26FF},
+	{Name: "Dingbats", First: 0x2700, Last: 0x27BF},
+	{Name: "Supplemental Arrows-A", First: 0x27F0, Last: 0x27FF},
+	{Name: "Braille Patterns", First: 0x2800, Last: 0x28FF},
+	{Name: "Supplemental Arrows-B", First: 0x2900, Last: 0x297F},
+	{Name: "Miscellaneous Symbols and Arrows", First: 0x2B00, Last: 0x2BFF},
+	{Name: "CJK Symbols and Punctuation", First: 0x3000, Last: 0x303F},
+	{Name: "Hiragana", First: 0x3040, Last: 0x309F},
+	{Name: "Katakana", First: 0x30A0, Last: 0x30FF},
+	{Name: "CJK Unified Ideographs", First: 0x4E00, Last: 0x9FFF},
+	{Name: "Hangul Syllables", First: 0xAC00, Last: 0xD7AF},
+	{Name: "Private Use Area", First: 0xE000, Last: 0xF8FF},
+	{Name: "Halfwidth and Fullwidth Forms", First: 0xFF00, Last: 0xFFEF},
+	{Name: "Specials", First: 0xFFF0, Last: 0xFFFF},
+	{Name: "Miscellaneous Symbols and Pictographs", First: 0x1F300, Last: 0x1F5FF},
+	{Name: "Emoticons", First: 0x1F600, Last: 0x1F64F},
+	{Name: "Supplemental Symbols and Pictographs", First: 0x1F900, Last: 0x1F9FF},
+	{Name: "Symbols for Legacy Computing", First: 0x1FB00, Last: 0x1FBFF},
+}
+
+// Coverage returns how much of each of the Unicode blocks most used in terminals the regular style of the font
+// can draw, leaving out blocks which it doesn't cover at all. Fallback fonts aren't included.
+func (m *Manager) Coverage() []BlockCoverage {
+	if m.regularFont == nil {
+		return nil
+	}
+	var coverage []BlockCoverage
+	for _, block := range blocks {
+		for r := block.First; r <= block.Last; r++ {
+			if hasGlyph(m.regularFont, r) {
+				block.Covered++
+			}
+		}
+		if block.Covered > 0 {
+			coverage = append(coverage, block)
+		}
+	}
+	return coverage
+}
+
+// Features returns the tags of the OpenType substitution features of the regular style, such as "liga", "calt"
+// and "ss01", in alphabetical order. These are the features which can be changed with SetFeatures.
+func (m *Manager) Features() []string {
+	source, ok := m.sources[Regular]
+	if !ok {
+		return nil
+	}
+	face := source.shapingFace()
+	if face == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var tags []string
+	for _, feature := range face.Font.GSUB.Features {
+		tag := feature.Tag.String()
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Synthesised returns true if the family has no font for the style, so it is drawn by emboldening or slanting
+// the font of another style
+func (m *Manager) Synthesised(style Style) bool {
+	_, ok := m.sources[style]
+	return !ok
+}
+
+// defaultFeatures are the substitution features which the shaper applies to horizontal text unless they are turned
+// off, as in HarfBuzz
+var defaultFeatures = map[string]bool{
+	"ccmp": true,
+	"locl": true,
+	"rlig": true,
+	"rclt": true,
+	"calt": true,
+	"clig": true,
+	"liga": true,
+}
+
+// EnabledByDefault returns true if the feature is applied when shaping unless it is turned off with SetFeatures
+func EnabledByDefault(tag string) bool {
+	return defaultFeatures[tag]
+}
@@ -1,11 +1,15 @@
 package font
 
 import (
+	"bytes"
 	"fmt"
 	"image"
+	"io"
 	"math"
 	"os"
 
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/shaping"
 	"github.com/liamg/darktile/internal/app/darktile/packed"
 	"github.com/liamg/fontinfo"
 	"golang.org/x/image/font"
@@ -32,6 +36,7 @@ const (
 
 type Manager struct {
 	family         string
+	regularFont    *opentype.Font // used to check which runes the primary font can draw
 	regularFace    font.Face
 	boldFace       font.Face
 	italicFace     font.Face
@@ -40,6 +45,17 @@ type Manager struct {
 	dpi            float64
 	charSize       image.Point
 	fontDotDepth   int
+	weight         float64 // requested weight of the regular style, zero for the font's default
+	fallbacks      []*fallback
+	discovery      *discovery                  // nil unless installed fonts are searched for fallbacks
+	runeFallbacks  map[rune]*fallback          // fallback fonts by rune, nil where no font has the rune
+	colourFonts    map[ot.Resource]*colourFont // colour tables by font file, nil where the font has none
+	colourGlyphs   map[colourKey]*image.RGBA
+	sources        map[Style]*fontSource
+	styles         map[Style]*styleFont
+	features       []shaping.FontFeature
+	shaper         shaping.HarfbuzzShaper
+	shaped         map[shapeKey][]ShapedGlyph
 }
 
 func NewManager() *Manager {
@@ -53,6 +69,15 @@ func (m *Manager) CharSize() image.Point {
 	return m.charSize
 }
 
+// Family returns the configured font family name, which is empty when the packed default font is in use
+func (m *Manager) Family() string {
+	return m.family
+}
+
+func (m *Manager) Size() float64 {
+	return m.size
+}
+
 func (m *Manager) IncreaseSize() {
 	m.SetSize(m.size + 1)
 }
@@ -76,29 +101,90 @@ func (m *Manager) SetDPI(dpi float64) error {
 	if dpi <= 0 {
 		return fmt.Errorf("DPI must be >0")
 	}
+	previous := m.dpi
 	m.dpi = dpi
+	if m.regularFace != nil {
+		if err := m.reload(); err != nil {
+			m.dpi = previous
+			return err
+		}
+	}
 	return nil
 }
 
 func (m *Manager) SetSize(size float64) error {
+	if size <= 0 {
+		return fmt.Errorf("size must be >0")
+	}
+	previous := m.size
 	m.size = size
 	if m.regularFace != nil {
-		// effectively reload fonts at new size
-		m.SetFontByFamilyName(m.family)
+		if err := m.reload(); err != nil {
+			m.size = previous
+			return err
+		}
 	}
 	return nil
 }
 
-func (m *Manager) loadFontFace(path string) (font.Face, error) {
+// SetWeight sets the weight of the regular style, from 1 to 1000 or zero for the font's default. Only variable
+// fonts have a weight axis to apply it to.
+func (m *Manager) SetWeight(weight float64) error {
+	if weight < 0 || weight > 1000 {
+		return fmt.Errorf("font weight must be between 1 and 1000")
+	}
+	previous := m.weight
+	m.weight = weight
+	if m.regularFace != nil {
+		if err := m.reload(); err != nil {
+			m.weight = previous
+			return err
+		}
+	}
+	return nil
+}
+
+func loadFont(path string) (*opentype.Font, ot.Resource, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	fnt, err := opentype.ParseReaderAt(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	return fnt, f, nil
+}
+
+// loadStyle loads the font for a style of the family from a file or packed font
+func (m *Manager) loadStyle(src ot.Resource) (*fontSource, error) {
+	fnt, err := opentype.ParseReaderAt(src)
 	if err != nil {
 		return nil, err
 	}
-	return m.createFace(fnt)
+	face, err := m.createFace(fnt)
+	if err != nil {
+		return nil, err
+	}
+	return &fontSource{
+		resource: src,
+		font:     fnt,
+		face:     face,
+	}, nil
+}
+
+func (m *Manager) loadStyleFromPath(path string) (*fontSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	source, err := m.loadStyle(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return source, nil
 }
 
 func (m *Manager) createFace(f *opentype.Font) (font.Face, error) {
@@ -109,58 +195,160 @@ func (m *Manager) createFace(f *opentype.Font) (font.Face, error) {
 	})
 }
 
+// SetFontByFamilyName loads a family from the installed fonts, or from those packed into darktile. The fonts in
+// use are only replaced once the whole family has loaded, so they are kept if it can't be.
 func (m *Manager) SetFontByFamilyName(name string) error {
 
-	m.family = name
-
+	var sources map[Style]*fontSource
+	var err error
 	if name == "" {
-		return m.loadDefaultFonts()
+		sources, err = m.loadDefaultFonts()
+	} else {
+		sources, err = m.loadInstalledFamily(name)
+	}
+	if err != nil {
+		return err
 	}
 
+	return m.useSources(name, sources)
+}
+
+// loadInstalledFamily loads the styles of an installed family, falling back to a packed family of the same name
+func (m *Manager) loadInstalledFamily(name string) (map[Style]*fontSource, error) {
+
 	fonts, err := fontinfo.Match(fontinfo.MatchFamily(name))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(fonts) == 0 {
-		return fmt.Errorf("could not find font with family '%s'", name)
+		if family, ok := packed.Lookup(name); ok {
+			return m.loadPackedFamily(family)
+		}
+		return nil, fmt.Errorf("could not find font with family '%s'", name)
 	}
 
+	sources := make(map[Style]*fontSource)
 	for _, fontMeta := range fonts {
+		var style Style
 		switch StyleName(fontMeta.Style) {
 		case StyleRegular:
-			m.regularFace, err = m.loadFontFace(fontMeta.Path)
-			if err != nil {
-				return err
-			}
+			style = Regular
 		case StyleBold:
-			m.boldFace, err = m.loadFontFace(fontMeta.Path)
-			if err != nil {
-				return err
-			}
+			style = Bold
 		case StyleItalic:
-			m.italicFace, err = m.loadFontFace(fontMeta.Path)
-			if err != nil {
-				return err
-			}
+			style = Italic
 		case StyleBoldItalic:
-			m.boldItalicFace, err = m.loadFontFace(fontMeta.Path)
-			if err != nil {
-				return err
-			}
+			style = BoldItalic
+		default:
+			continue
+		}
+		source, err := m.loadStyleFromPath(fontMeta.Path)
+		if err != nil {
+			closeUnused(sources, nil)
+			return nil, err
+		}
+		if previous, ok := sources[style]; ok {
+			// the family has more than one font for the style, the last of which is used
+			closeSource(previous)
+		}
+		sources[style] = source
+	}
+
+	if _, ok := sources[Regular]; !ok {
+		closeUnused(sources, nil)
+		return nil, fmt.Errorf("could not find regular style for font family '%s'", name)
+	}
+
+	return sources, nil
+}
+
+// SetFontData loads a font from the contents of a font file as the regular style of a family, with the other
+// styles synthesised from it
+func (m *Manager) SetFontData(name string, data []byte) error {
+	source, err := m.loadStyle(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return m.useSources(name, map[Style]*fontSource{Regular: source})
+}
+
+// reload creates the faces of the fonts in use again, after the size or weight has changed
+func (m *Manager) reload() error {
+	sources := make(map[Style]*fontSource, len(m.sources))
+	for style, source := range m.sources {
+		face, err := m.createFace(source.font)
+		if err != nil {
+			return err
+		}
+		sources[style] = &fontSource{
+			resource: source.resource,
+			font:     source.font,
+			face:     face,
+			shaping:  source.shaping,
+			parsed:   source.parsed,
 		}
 	}
+	return m.useSources(m.family, sources)
+}
 
-	if m.regularFace == nil {
-		return fmt.Errorf("could not find regular style for font family '%s'", name)
+// useSources replaces the fonts in use with those of a newly loaded family, synthesising the styles it is missing.
+// If the cell size can't be measured from the family, the fonts in use are kept.
+func (m *Manager) useSources(name string, sources map[Style]*fontSource) error {
+
+	styles := m.synthesiseStyles(sources, readWeightAxis(sources[Regular].resource))
+	charSize, dotDepth, err := m.measure(styles[Regular].face)
+	if err != nil {
+		closeUnused(sources, m.sources)
+		return err
 	}
 
-	return m.calcMetrics()
+	for _, source := range closeUnused(m.sources, sources) {
+		delete(m.colourFonts, source.resource)
+	}
+	m.family = name
+	m.sources = sources
+	m.styles = styles
+	m.regularFont = sources[Regular].font
+	m.regularFace = styles[Regular].face
+	m.boldFace = styles[Bold].face
+	m.italicFace = styles[Italic].face
+	m.boldItalicFace = styles[BoldItalic].face
+	m.charSize = charSize
+	m.fontDotDepth = dotDepth
+
+	// fallback faces are scaled to fit the cell size, so they need to be created again
+	m.resetFallbackFaces()
+	m.shaped = make(map[shapeKey][]ShapedGlyph)
+	m.colourGlyphs = make(map[colourKey]*image.RGBA)
+	return nil
 }
 
-func (m *Manager) calcMetrics() error {
+// closeUnused closes the files of fonts which aren't also used by the fonts being kept, returning those closed
+func closeUnused(sources map[Style]*fontSource, kept map[Style]*fontSource) []*fontSource {
+	inUse := make(map[ot.Resource]bool)
+	for _, source := range kept {
+		inUse[source.resource] = true
+	}
+	var closed []*fontSource
+	for _, source := range sources {
+		if !inUse[source.resource] {
+			closeSource(source)
+			closed = append(closed, source)
+		}
+	}
+	return closed
+}
 
-	face := m.regularFace
+func closeSource(source *fontSource) {
+	if closer, ok := source.resource.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// measure returns the cell size and the depth of the baseline below the top of the cell for a face, which must be
+// monospaced
+func (m *Manager) measure(face font.Face) (image.Point, int, error) {
 
 	var prevAdvance int
 	for ch := rune(32); ch <= 126; ch++ {
@@ -168,64 +356,61 @@ func (m *Manager) calcMetrics() error {
 		if ok && adv26 > 0 {
 			advance := int(adv26)
 			if prevAdvance > 0 && prevAdvance != advance {
-				return fmt.Errorf("the specified font is not monospaced: %d 0x%X=%d", prevAdvance, ch, advance)
+				return image.Point{}, 0, fmt.Errorf("the specified font is not monospaced: %d 0x%X=%d", prevAdvance, ch, advance)
 			}
 			prevAdvance = advance
 		}
 	}
 
 	if prevAdvance == 0 {
-		return fmt.Errorf("failed to calculate advance width for font face")
+		return image.Point{}, 0, fmt.Errorf("failed to calculate advance width for font face")
 	}
 
 	metrics := face.Metrics()
 
-	m.charSize.X = int(math.Round(float64(prevAdvance) / m.dpi))
-	m.charSize.Y = int(math.Round(float64(metrics.Height) / m.dpi))
-	m.fontDotDepth = int(math.Round(float64(metrics.Ascent) / m.dpi))
-
-	return nil
-}
-
-func (m *Manager) loadDefaultFonts() error {
-
-	regular, err := opentype.Parse(packed.MesloLGSNFRegularTTF)
-	if err != nil {
-		return err
-	}
-	m.regularFace, err = m.createFace(regular)
-	if err != nil {
-		return err
+	charSize := image.Point{
+		X: int(math.Round(float64(prevAdvance) / m.dpi)),
+		Y: int(math.Round(float64(metrics.Height) / m.dpi)),
 	}
+	return charSize, int(math.Round(float64(metrics.Ascent) / m.dpi)), nil
+}
 
-	bold, err := opentype.Parse(packed.MesloLGSNFBoldTTF)
-	if err != nil {
-		return err
-	}
-	m.boldFace, err = m.createFace(bold)
-	if err != nil {
-		return err
+func (m *Manager) loadDefaultFonts() (map[Style]*fontSource, error) {
+	family, ok := packed.Lookup(packed.DefaultFamily)
+	if !ok {
+		return nil, fmt.Errorf("the default font '%s' was not packed into this build of darktile", packed.DefaultFamily)
 	}
+	return m.loadPackedFamily(family)
+}
 
-	italic, err := opentype.Parse(packed.MesloLGSNFItalicTTF)
-	if err != nil {
-		return err
-	}
-	m.italicFace, err = m.createFace(italic)
-	if err != nil {
-		return err
+// loadPackedFamily loads the styles of a family compiled into darktile
+func (m *Manager) loadPackedFamily(family packed.Family) (map[Style]*fontSource, error) {
+
+	sources := make(map[Style]*fontSource)
+	for _, packedStyle := range []struct {
+		style Style
+		data  []byte
+	}{
+		{Regular, family.Regular},
+		{Bold, family.Bold},
+		{Italic, family.Italic},
+		{BoldItalic, family.BoldItalic},
+	} {
+		if packedStyle.data == nil {
+			continue
+		}
+		source, err := m.loadStyle(bytes.NewReader(packedStyle.data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load packed font '%s': %w", family.Name, err)
+		}
+		sources[packedStyle.style] = source
 	}
 
-	boldItalic, err := opentype.Parse(packed.MesloLGSNFBoldItalicTTF)
-	if err != nil {
-		return err
-	}
-	m.boldItalicFace, err = m.createFace(boldItalic)
-	if err != nil {
-		return err
+	if _, ok := sources[Regular]; !ok {
+		return nil, fmt.Errorf("packed font '%s' has no regular style", family.Name)
 	}
 
-	return m.calcMetrics()
+	return sources, nil
 }
 
 func (m *Manager) RegularFontFace() font.Face {
@@ -0,0 +1,59 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	m := NewManager()
+	require.NoError(t, m.SetSize(14))
+	require.NoError(t, m.SetFontData("Go Mono", gomono.TTF))
+	return m
+}
+
+func TestFailedFamilyChangeKeepsCurrentFonts(t *testing.T) {
+	m := newTestManager(t)
+	regular := m.RegularFontFace()
+	cellSize := m.CharSize()
+
+	require.Error(t, m.SetFontByFamilyName("No Such Family Installed"))
+
+	assert.Equal(t, "Go Mono", m.Family())
+	assert.Same(t, regular, m.RegularFontFace())
+	assert.NotNil(t, m.BoldItalicFontFace())
+	assert.Equal(t, cellSize, m.CharSize())
+}
+
+func TestFailedDataChangeKeepsCurrentFonts(t *testing.T) {
+	m := newTestManager(t)
+	regular := m.RegularFontFace()
+
+	require.Error(t, m.SetFontData("Broken", []byte("not a font")))
+
+	assert.Equal(t, "Go Mono", m.Family())
+	assert.Same(t, regular, m.RegularFontFace())
+}
+
+func TestSetFontDataSynthesisesMissingStyles(t *testing.T) {
+	m := newTestManager(t)
+	for _, style := range []Style{Bold, Italic, BoldItalic} {
+		assert.True(t, m.Synthesised(style))
+		assert.NotNil(t, m.Face(style, 'a'))
+	}
+	assert.False(t, m.Synthesised(Regular))
+}
+
+func TestSetDPIRescalesLoadedFonts(t *testing.T) {
+	m := newTestManager(t)
+	before, _, ok := m.RegularFontFace().GlyphBounds('a')
+	require.True(t, ok)
+
+	require.NoError(t, m.SetDPI(m.DPI()*2))
+	after, _, ok := m.RegularFontFace().GlyphBounds('a')
+	require.True(t, ok)
+	assert.Greater(t, after.Max.X-after.Min.X, before.Max.X-before.Min.X)
+}
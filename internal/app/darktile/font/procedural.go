@@ -0,0 +1,445 @@
+package font
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// line weights of the arms of box drawing characters
+const (
+	armNone uint8 = iota
+	armLight
+	armHeavy
+	armDouble
+)
+
+// boxArms holds the weights of the up, right, down and left arms of the box drawing characters U+2500 to U+257F
+// which are made up of straight lines from the centre of the cell to its edges
+var boxArms = map[rune][4]uint8{
+	'─': {0, 1, 0, 1}, '━': {0, 2, 0, 2}, '│': {1, 0, 1, 0}, '┃': {2, 0, 2, 0},
+	'┌': {0, 1, 1, 0}, '┍': {0, 2, 1, 0}, '┎': {0, 1, 2, 0}, '┏': {0, 2, 2, 0},
+	'┐': {0, 0, 1, 1}, '┑': {0, 0, 1, 2}, '┒': {0, 0, 2, 1}, '┓': {0, 0, 2, 2},
+	'└': {1, 1, 0, 0}, '┕': {1, 2, 0, 0}, '┖': {2, 1, 0, 0}, '┗': {2, 2, 0, 0},
+	'┘': {1, 0, 0, 1}, '┙': {1, 0, 0, 2}, '┚': {2, 0, 0, 1}, '┛': {2, 0, 0, 2},
+	'├': {1, 1, 1, 0}, '┝': {1, 2, 1, 0}, '┞': {2, 1, 1, 0}, '┟': {1, 1, 2, 0},
+	'┠': {2, 1, 2, 0}, '┡': {2, 2, 1, 0}, '┢': {1, 2, 2, 0}, '┣': {2, 2, 2, 0},
+	'┤': {1, 0, 1, 1}, '┥': {1, 0, 1, 2}, '┦': {2, 0, 1, 1}, '┧': {1, 0, 2, 1},
+	'┨': {2, 0, 2, 1}, '┩': {2, 0, 1, 2}, '┪': {1, 0, 2, 2}, '┫': {2, 0, 2, 2},
+	'┬': {0, 1, 1, 1}, '┭': {0, 1, 1, 2}, '┮': {0, 2, 1, 1}, '┯': {0, 2, 1, 2},
+	'┰': {0, 1, 2, 1}, '┱': {0, 1, 2, 2}, '┲': {0, 2, 2, 1}, '┳': {0, 2, 2, 2},
+	'┴': {1, 1, 0, 1}, '┵': {1, 1, 0, 2}, '┶': {1, 2, 0, 1}, '┷': {1, 2, 0, 2},
+	'┸': {2, 1, 0, 1}, '┹': {2, 1, 0, 2}, '┺': {2, 2, 0, 1}, '┻': {2, 2, 0, 2},
+	'┼': {1, 1, 1, 1}, '┽': {1, 1, 1, 2}, '┾': {1, 2, 1, 1}, '┿': {1, 2, 1, 2},
+	'╀': {2, 1, 1, 1}, '╁': {1, 1, 2, 1}, '╂': {2, 1, 2, 1}, '╃': {2, 1, 1, 2},
+	'╄': {2, 2, 1, 1}, '╅': {1, 1, 2, 2}, '╆': {1, 2, 2, 1}, '╇': {2, 2, 1, 2},
+	'╈': {1, 2, 2, 2}, '╉': {2, 1, 2, 2}, '╊': {2, 2, 2, 1}, '╋': {2, 2, 2, 2},
+	'═': {0, 3, 0, 3}, '║': {3, 0, 3, 0}, '╒': {0, 3, 1, 0}, '╓': {0, 1, 3, 0},
+	'╔': {0, 3, 3, 0}, '╕': {0, 0, 1, 3}, '╖': {0, 0, 3, 1}, '╗': {0, 0, 3, 3},
+	'╘': {1, 3, 0, 0}, '╙': {3, 1, 0, 0}, '╚': {3, 3, 0, 0}, '╛': {1, 0, 0, 3},
+	'╜': {3, 0, 0, 1}, '╝': {3, 0, 0, 3}, '╞': {1, 3, 1, 0}, '╟': {3, 1, 3, 0},
+	'╠': {3, 3, 3, 0}, '╡': {1, 0, 1, 3}, '╢': {3, 0, 3, 1}, '╣': {3, 0, 3, 3},
+	'╤': {0, 3, 1, 3}, '╥': {0, 1, 3, 1}, '╦': {0, 3, 3, 3}, '╧': {1, 3, 0, 3},
+	'╨': {3, 1, 0, 1}, '╩': {3, 3, 0, 3}, '╪': {1, 3, 1, 3}, '╫': {3, 1, 3, 1},
+	'╬': {3, 3, 3, 3},
+	'╴': {0, 0, 0, 1}, '╵': {1, 0, 0, 0}, '╶': {0, 1, 0, 0}, '╷': {0, 0, 1, 0},
+	'╸': {0, 0, 0, 2}, '╹': {2, 0, 0, 0}, '╺': {0, 2, 0, 0}, '╻': {0, 0, 2, 0},
+	'╼': {0, 2, 0, 1}, '╽': {1, 0, 2, 0}, '╾': {0, 1, 0, 2}, '╿': {2, 0, 1, 0},
+}
+
+// boxDashes holds the dashed box drawing characters: whether they are vertical, heavy, and the number of dashes
+var boxDashes = map[rune]struct {
+	vertical bool
+	heavy    bool
+	count    int
+}{
+	'┄': {false, false, 3}, '┅': {false, true, 3}, '┆': {true, false, 3}, '┇': {true, true, 3},
+	'┈': {false, false, 4}, '┉': {false, true, 4}, '┊': {true, false, 4}, '┋': {true, true, 4},
+	'╌': {false, false, 2}, '╍': {false, true, 2}, '╎': {true, false, 2}, '╏': {true, true, 2},
+}
+
+// IsProcedural returns true for runes which are drawn procedurally rather than from the font, so that they fill
+// their cell exactly and join up with the characters around them: box drawing, block elements, braille patterns
+// and the Powerline separators
+func IsProcedural(r rune) bool {
+	return (r >= 0x2500 && r <= 0x259F) || (r >= 0x2800 && r <= 0x28FF) || (r >= 0xE0B0 && r <= 0xE0B3)
+}
+
+// LineThickness returns the thickness in pixels of light lines in procedurally drawn characters for a cell size
+func LineThickness(size image.Point) int {
+	return int(math.Max(1, math.Round(float64(size.X)/10)))
+}
+
+// ProceduralGlyph draws a rune for which IsProcedural is true as a coverage mask the size of a cell. Light lines
+// are drawn with the given thickness in pixels, or with a thickness derived from the cell size if it is zero.
+func ProceduralGlyph(r rune, size image.Point, thickness int) (*image.Alpha, bool) {
+	if !IsProcedural(r) || size.X <= 0 || size.Y <= 0 {
+		return nil, false
+	}
+	if thickness <= 0 {
+		thickness = LineThickness(size)
+	}
+
+	g := &proceduralGlyph{
+		mask:  image.NewAlpha(image.Rect(0, 0, size.X, size.Y)),
+		w:     size.X,
+		h:     size.Y,
+		light: thickness,
+	}
+
+	switch {
+	case r >= 0x2800 && r <= 0x28FF:
+		g.braille(r)
+	case r >= 0xE0B0:
+		g.powerline(r)
+	case r >= 0x2580:
+		g.block(r)
+	default:
+		g.box(r)
+	}
+	return g.mask, true
+}
+
+type proceduralGlyph struct {
+	mask  *image.Alpha
+	w, h  int
+	light int
+}
+
+func (g *proceduralGlyph) thickness(weight uint8) int {
+	if weight == armHeavy {
+		return 2*g.light + 1
+	}
+	return g.light
+}
+
+// fill sets the coverage of a rectangle of pixels, clipped to the cell
+func (g *proceduralGlyph) fill(x0, y0, x1, y1 int, coverage uint8) {
+	rect := image.Rect(x0, y0, x1, y1).Intersect(g.mask.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			g.mask.Pix[g.mask.PixOffset(x, y)] = coverage
+		}
+	}
+}
+
+// start returns the first pixel of a line of the given thickness centred on a pixel
+func start(centre int, thickness int) int {
+	return centre - thickness/2
+}
+
+// hline draws a horizontal line covering the pixels from x0 to x1, centred on the pixel row y
+func (g *proceduralGlyph) hline(x0, x1, y, thickness int) {
+	g.fill(x0, start(y, thickness), x1, start(y, thickness)+thickness, 0xff)
+}
+
+// vline draws a vertical line covering the pixels from y0 to y1, centred on the pixel column x
+func (g *proceduralGlyph) vline(y0, y1, x, thickness int) {
+	g.fill(start(x, thickness), y0, start(x, thickness)+thickness, y1, 0xff)
+}
+
+func (g *proceduralGlyph) box(r rune) {
+
+	if dash, ok := boxDashes[r]; ok {
+		weight := armLight
+		if dash.heavy {
+			weight = armHeavy
+		}
+		g.dashes(dash.vertical, g.thickness(weight), dash.count)
+		return
+	}
+
+	switch r {
+	case '╭', '╮', '╯', '╰':
+		g.arc(r)
+		return
+	case '╱':
+		g.stroke(float64(g.w), 0, 0, float64(g.h))
+		return
+	case '╲':
+		g.stroke(0, 0, float64(g.w), float64(g.h))
+		return
+	case '╳':
+		g.stroke(float64(g.w), 0, 0, float64(g.h))
+		g.stroke(0, 0, float64(g.w), float64(g.h))
+		return
+	}
+
+	arms, ok := boxArms[r]
+	if !ok {
+		return
+	}
+	up, right, down, left := arms[0], arms[1], arms[2], arms[3]
+
+	// horizontal arms are drawn along the middle row, and vertical arms along the middle column
+	g.arms(left, right, up, down, g.w/2, g.h/2, g.w, g.hline)
+	g.arms(up, down, left, right, g.h/2, g.w/2, g.h, g.vline)
+}
+
+// arms draws the two arms of a box drawing character along one axis, given the arms crossing them. centre is the
+// middle of the cell along the axis, across is the middle of the cell across it, and length is the size of the
+// cell along the axis.
+func (g *proceduralGlyph) arms(before, after, crossBefore, crossAfter uint8, centre, across, length int, line func(from, to, at, thickness int)) {
+
+	// double lines are two light lines, each offset from the middle of the cell by the light thickness
+	offset := g.light
+
+	// the thickness of the lines crossing this axis, which single lines are extended over so that joins are square
+	crossing := 0
+	for _, weight := range []uint8{crossBefore, crossAfter} {
+		switch weight {
+		case armNone:
+		case armDouble:
+			crossing = maxInt(crossing, 2*offset+g.light)
+		default:
+			crossing = maxInt(crossing, g.thickness(weight))
+		}
+	}
+	crossingDouble := crossBefore == armDouble || crossAfter == armDouble
+
+	for _, arm := range []struct {
+		weight   uint8
+		dir      int // -1 for the arm towards the start of the axis, 1 for the arm towards the end
+		opposite uint8
+	}{{before, -1, after}, {after, 1, before}} {
+		if arm.weight == armNone {
+			continue
+		}
+
+		// span returns the pixels from the edge of the cell to a line of the given thickness centred on stop
+		span := func(stop int, thickness int) (int, int) {
+			if arm.dir > 0 {
+				return stop - thickness/2, length
+			}
+			return 0, stop - thickness/2 + thickness
+		}
+
+		if arm.weight != armDouble {
+			from, to := span(centre, crossing)
+			if arm.opposite == armNone && crossingDouble {
+				// stop at the nearer of the double lines, as in ╟
+				from, to = span(centre+arm.dir*offset, g.light)
+			}
+			line(from, to, across, g.thickness(arm.weight))
+			continue
+		}
+
+		for _, side := range []struct {
+			offset int
+			near   uint8 // the crossing arm on the same side as this line
+			far    uint8
+		}{{-offset, crossBefore, crossAfter}, {offset, crossAfter, crossBefore}} {
+			from, to := span(centre, crossing)
+			switch {
+			case side.near == armDouble:
+				// an inner corner, as in the lower line of ╔
+				from, to = span(centre+arm.dir*offset, g.light)
+			case side.near == armNone && side.far == armDouble:
+				// an outer corner, as in the upper line of ╔
+				from, to = span(centre-arm.dir*offset, g.light)
+			}
+			line(from, to, across+side.offset, g.light)
+		}
+	}
+}
+
+// dashes draws a dashed line through the centre of the cell, with the gaps between dashes split across the ends of
+// the cell so that neighbouring cells continue the pattern
+func (g *proceduralGlyph) dashes(vertical bool, thickness int, count int) {
+	length := g.w
+	if vertical {
+		length = g.h
+	}
+	for i := 0; i < count; i++ {
+		from := i * length / count
+		to := (i + 1) * length / count
+		gap := maxInt(1, (to-from)/4)
+		from += gap / 2
+		to -= gap - gap/2
+		if vertical {
+			g.vline(from, to, g.w/2, thickness)
+		} else {
+			g.hline(from, to, g.h/2, thickness)
+		}
+	}
+}
+
+// arc draws the rounded corners ╭ ╮ ╯ ╰, which join the centres of two edges with a quarter circle
+func (g *proceduralGlyph) arc(r rune) {
+	// the centre of the pixel lines drawn by hline and vline through the middle of the cell
+	cx := float64(start(g.w/2, g.light)) + float64(g.light)/2
+	cy := float64(start(g.h/2, g.light)) + float64(g.light)/2
+
+	var dx, dy float64 // direction from the centre of the cell towards the horizontal and vertical edges
+	switch r {
+	case '╭':
+		dx, dy = 1, 1
+	case '╮':
+		dx, dy = -1, 1
+	case '╯':
+		dx, dy = -1, -1
+	case '╰':
+		dx, dy = 1, -1
+	}
+
+	edgeX, edgeY := 0.0, 0.0
+	if dx > 0 {
+		edgeX = float64(g.w)
+	}
+	if dy > 0 {
+		edgeY = float64(g.h)
+	}
+	radius := math.Min(math.Abs(edgeX-cx), math.Abs(edgeY-cy))
+	ox, oy := cx+dx*radius, cy+dy*radius
+
+	g.strokeFunc(func(px, py float64) float64 {
+		distance := math.Inf(1)
+		// the quarter circle, within the quadrant facing the centre of the cell
+		if (px-ox)*dx <= 0 && (py-oy)*dy <= 0 {
+			distance = math.Abs(math.Hypot(px-ox, py-oy) - radius)
+		}
+		// straight lines from the ends of the arc to the edges
+		distance = math.Min(distance, segmentDistance(px, py, ox, cy, edgeX, cy))
+		distance = math.Min(distance, segmentDistance(px, py, cx, oy, cx, edgeY))
+		return distance
+	})
+}
+
+// stroke draws an antialiased line of light thickness between two points
+func (g *proceduralGlyph) stroke(x0, y0, x1, y1 float64) {
+	g.strokeFunc(func(px, py float64) float64 {
+		return segmentDistance(px, py, x0, y0, x1, y1)
+	})
+}
+
+// strokeFunc draws a line of light thickness along the path described by a distance function, adding to any
+// coverage already drawn
+func (g *proceduralGlyph) strokeFunc(distance func(x, y float64) float64) {
+	half := float64(g.light) / 2
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			coverage := math.Max(0, math.Min(1, half+0.5-distance(float64(x)+0.5, float64(y)+0.5)))
+			i := g.mask.PixOffset(x, y)
+			g.mask.Pix[i] = uint8(math.Max(float64(g.mask.Pix[i]), math.Round(coverage*0xff)))
+		}
+	}
+}
+
+func segmentDistance(px, py, x0, y0, x1, y1 float64) float64 {
+	dx, dy := x1-x0, y1-y0
+	t := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/lengthSquared))
+	}
+	return math.Hypot(px-(x0+t*dx), py-(y0+t*dy))
+}
+
+// block draws the block elements U+2580 to U+259F
+func (g *proceduralGlyph) block(r rune) {
+	w, h := g.w, g.h
+	eighthX := func(n int) int { return int(math.Round(float64(w*n) / 8)) }
+	eighthY := func(n int) int { return int(math.Round(float64(h*n) / 8)) }
+	cx, cy := w/2, h/2
+
+	switch {
+	case r == '▀':
+		g.fill(0, 0, w, cy, 0xff)
+	case r >= '▁' && r <= '█':
+		g.fill(0, h-eighthY(int(r-'▁')+1), w, h, 0xff)
+	case r >= '▉' && r <= '▏':
+		g.fill(0, 0, eighthX(int('▏'-r)+1), h, 0xff)
+	case r == '▐':
+		g.fill(cx, 0, w, h, 0xff)
+	case r == '░':
+		g.fill(0, 0, w, h, 0x40)
+	case r == '▒':
+		g.fill(0, 0, w, h, 0x80)
+	case r == '▓':
+		g.fill(0, 0, w, h, 0xc0)
+	case r == '▔':
+		g.fill(0, 0, w, eighthY(1), 0xff)
+	case r == '▕':
+		g.fill(w-eighthX(1), 0, w, h, 0xff)
+	default:
+		// quadrants, as bits for the upper left, upper right, lower left and lower right
+		quadrants := map[rune]uint8{
+			'▖': 0b0010, '▗': 0b0001, '▘': 0b1000, '▙': 0b1011, '▚': 0b1001,
+			'▛': 0b1110, '▜': 0b1101, '▝': 0b0100, '▞': 0b0110, '▟': 0b0111,
+		}[r]
+		if quadrants&0b1000 != 0 {
+			g.fill(0, 0, cx, cy, 0xff)
+		}
+		if quadrants&0b0100 != 0 {
+			g.fill(cx, 0, w, cy, 0xff)
+		}
+		if quadrants&0b0010 != 0 {
+			g.fill(0, cy, cx, h, 0xff)
+		}
+		if quadrants&0b0001 != 0 {
+			g.fill(cx, cy, w, h, 0xff)
+		}
+	}
+}
+
+// braille draws the braille patterns U+2800 to U+28FF, where each bit of the offset from U+2800 is a dot
+func (g *proceduralGlyph) braille(r rune) {
+	// the column and row of each dot, in the order of the bits
+	dots := [8][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}, {0, 3}, {1, 3}}
+	radius := math.Max(0.75, math.Min(float64(g.w)/4, float64(g.h)/8)*0.6)
+	bits := r - 0x2800
+	for i, dot := range dots {
+		if bits&(1<<i) == 0 {
+			continue
+		}
+		x := float64(g.w) * (float64(dot[0])*2 + 1) / 4
+		y := float64(g.h) * (float64(dot[1])*2 + 1) / 8
+		g.strokeFunc(func(px, py float64) float64 {
+			return math.Max(0, math.Hypot(px-x, py-y)-radius+float64(g.light)/2)
+		})
+	}
+}
+
+// powerline draws the Powerline separators U+E0B0 to U+E0B3
+func (g *proceduralGlyph) powerline(r rune) {
+	w, h := float64(g.w), float64(g.h)
+	switch r {
+	case 0xE0B0:
+		g.polygon([][2]float64{{0, 0}, {w, h / 2}, {0, h}})
+	case 0xE0B1:
+		g.stroke(0, 0, w, h/2)
+		g.stroke(w, h/2, 0, h)
+	case 0xE0B2:
+		g.polygon([][2]float64{{w, 0}, {0, h / 2}, {w, h}})
+	case 0xE0B3:
+		g.stroke(w, 0, 0, h/2)
+		g.stroke(0, h/2, w, h)
+	}
+}
+
+// polygon fills a closed shape with antialiased edges
+func (g *proceduralGlyph) polygon(points [][2]float64) {
+	rasterizer := vector.NewRasterizer(g.w, g.h)
+	rasterizer.DrawOp = draw.Src
+	rasterizer.MoveTo(float32(points[0][0]), float32(points[0][1]))
+	for _, point := range points[1:] {
+		rasterizer.LineTo(float32(point[0]), float32(point[1]))
+	}
+	rasterizer.ClosePath()
+	rasterizer.Draw(g.mask, g.mask.Bounds(), image.Opaque, image.Point{})
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,327 @@
+package font
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-text/typesetting/di"
+	gotext "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// glyphRuneBase is added to glyph indices so that shaped glyphs can be drawn as runes by GlyphFace. The
+// supplementary private use area is used as every glyph index then maps to a valid rune.
+const glyphRuneBase = 0xF0000
+
+// maxShapedRuns is the number of shaped runs which are kept before the cache is cleared
+const maxShapedRuns = 4096
+
+// fontSource is a font file loaded for one style of the family
+type fontSource struct {
+	resource ot.Resource
+	font     *opentype.Font
+	face     font.Face    // face at the current size, without synthesised weight or slant
+	shaping  *gotext.Face // parsed when text is first shaped in the style
+	parsed   bool
+}
+
+// styleFont is how a style is drawn, either with its own font or synthesised from the font of another style
+type styleFont struct {
+	source *fontSource
+	face   font.Face // draws runes
+	glyphs font.Face // draws shaped glyphs, as returned by Shape
+}
+
+// ShapedGlyph is a glyph produced by shaping a run of cells, positioned relative to the first cell of its cluster
+type ShapedGlyph struct {
+	Rune rune // the glyph, to be drawn with GlyphFace
+	Cell int  // index in the run of the first cell of the cluster the glyph belongs to
+	X, Y int  // offset in pixels of the glyph origin from the left of the cell and from the baseline
+}
+
+type shapeKey struct {
+	style Style
+	text  string
+}
+
+// newStyleFont creates the faces of a style from a font, at a weight on its weight axis or zero for the default
+// instance, emboldened by the strength in pixels and optionally slanted
+func (m *Manager) newStyleFont(source *fontSource, weight float64, strength int, oblique bool) *styleFont {
+	ppem := fixed.Int26_6(0.5 + m.size*m.dpi*64/72)
+	var face, glyphs font.Face = source.face, &indexFace{
+		font:    source.font,
+		metrics: source.face.Metrics(),
+		ppem:    ppem,
+	}
+	if weight != 0 {
+		if variable := newVariableFace(source, weight, ppem, false); variable != nil {
+			face = variable
+			glyphs = newVariableFace(source, weight, ppem, true)
+		}
+	}
+	return &styleFont{
+		source: source,
+		face:   newSyntheticFace(face, strength, oblique),
+		glyphs: newSyntheticFace(glyphs, strength, oblique),
+	}
+}
+
+// SetFeatures sets the OpenType features used when shaping text, e.g. "ss01" or "+ss01" to enable a stylistic
+// set, "-calt" to disable contextual alternates or "cv01=2" to choose an alternate. Features which aren't listed
+// keep the font's defaults.
+func (m *Manager) SetFeatures(features []string) error {
+	parsed := make([]shaping.FontFeature, 0, len(features))
+	for _, feature := range features {
+		f, err := parseFeature(feature)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, f)
+	}
+	m.features = parsed
+	m.shaped = make(map[shapeKey][]ShapedGlyph)
+	return nil
+}
+
+func parseFeature(feature string) (shaping.FontFeature, error) {
+	value := uint32(1)
+	tag := feature
+	switch {
+	case strings.HasPrefix(tag, "-"):
+		value = 0
+		tag = tag[1:]
+	case strings.HasPrefix(tag, "+"):
+		tag = tag[1:]
+	}
+	if i := strings.Index(tag, "="); i >= 0 {
+		v, err := strconv.ParseUint(tag[i+1:], 10, 32)
+		if err != nil {
+			return shaping.FontFeature{}, fmt.Errorf("invalid value for font feature '%s'", feature)
+		}
+		value = uint32(v)
+		tag = tag[:i]
+	}
+	if len(tag) != 4 {
+		return shaping.FontFeature{}, fmt.Errorf("invalid font feature '%s': tags are 4 characters e.g. 'calt'", feature)
+	}
+	return shaping.FontFeature{Tag: ot.MustNewTag(tag), Value: value}, nil
+}
+
+// Covers returns true if the primary font can draw the rune, meaning it can be shaped with the runes around it
+func (m *Manager) Covers(r rune) bool {
+	return r < 0x80 || m.regularFont == nil || hasGlyph(m.regularFont, r)
+}
+
+// GlyphFace returns the face which draws the glyphs returned by Shape for the style
+func (m *Manager) GlyphFace(style Style) font.Face {
+	if sf, ok := m.styles[style]; ok {
+		return sf.glyphs
+	}
+	return m.styles[Regular].glyphs
+}
+
+// Shape applies the substitutions and positioning of the font to a run of cells drawn in the same style, such as
+// ligatures and contextual alternates. Nil is returned if the font can't be used for shaping, in which case the
+// runes should be drawn individually.
+func (m *Manager) Shape(style Style, text []rune) []ShapedGlyph {
+
+	sf, ok := m.styles[style]
+	if !ok || len(text) == 0 {
+		return nil
+	}
+
+	key := shapeKey{style: style, text: string(text)}
+	if glyphs, ok := m.shaped[key]; ok {
+		return glyphs
+	}
+
+	face := sf.source.shapingFace()
+	if face == nil {
+		return nil
+	}
+
+	script := language.Latin
+	for _, r := range text {
+		if s := language.LookupScript(r); s != language.Common && s != language.Inherited && s != language.Unknown {
+			script = s
+			break
+		}
+	}
+
+	output := m.shaper.Shape(shaping.Input{
+		Text:         text,
+		RunStart:     0,
+		RunEnd:       len(text),
+		Direction:    di.DirectionLTR,
+		Face:         face,
+		FontFeatures: m.features,
+		Size:         fixed.Int26_6(0.5 + m.size*m.dpi*64/72),
+		Script:       script,
+	})
+
+	cellWidth := fixed.I(m.charSize.X)
+	glyphs := make([]ShapedGlyph, 0, len(output.Glyphs))
+	for i := 0; i < len(output.Glyphs); {
+		first := output.Glyphs[i]
+		count := first.GlyphCount
+		if count < 1 || i+count > len(output.Glyphs) {
+			count = 1
+		}
+		cluster := output.Glyphs[i : i+count]
+
+		// ligatures which replace several runes with one glyph are centred across the cells of those runes
+		var x fixed.Int26_6
+		if first.RuneCount > 1 {
+			var advance fixed.Int26_6
+			for _, g := range cluster {
+				advance += g.XAdvance
+			}
+			x = (cellWidth*fixed.Int26_6(first.RuneCount) - advance) / 2
+		}
+
+		for _, g := range cluster {
+			glyphs = append(glyphs, ShapedGlyph{
+				Rune: glyphRuneBase + rune(g.GlyphID),
+				Cell: first.ClusterIndex,
+				X:    (x + g.XOffset).Round(),
+				Y:    -g.YOffset.Round(),
+			})
+			x += g.XAdvance
+		}
+		i += count
+	}
+
+	if len(m.shaped) >= maxShapedRuns {
+		m.shaped = make(map[shapeKey][]ShapedGlyph)
+	}
+	m.shaped[key] = glyphs
+	return glyphs
+}
+
+func (s *fontSource) shapingFace() *gotext.Face {
+	if s.parsed {
+		return s.shaping
+	}
+	s.parsed = true
+	if _, err := s.resource.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+	face, err := gotext.ParseTTF(s.resource)
+	if err != nil {
+		return nil
+	}
+	s.shaping = face
+	return face
+}
+
+// indexFace draws glyphs by their index in the font rather than by rune, for glyphs produced by shaping which
+// have no rune of their own. Indices are passed as runes offset by glyphRuneBase.
+type indexFace struct {
+	font    *opentype.Font
+	metrics font.Metrics
+	ppem    fixed.Int26_6
+	buf     sfnt.Buffer
+	rast    vector.Rasterizer
+}
+
+func (f *indexFace) index(r rune) (sfnt.GlyphIndex, bool) {
+	if r < glyphRuneBase || r-glyphRuneBase >= rune(f.font.NumGlyphs()) {
+		return 0, false
+	}
+	return sfnt.GlyphIndex(r - glyphRuneBase), true
+}
+
+func (f *indexFace) Close() error {
+	return nil
+}
+
+func (f *indexFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	index, ok := f.index(r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+
+	// the advance must be read before loading the glyph, as loading invalidates the buffer
+	advance, err := f.font.GlyphAdvance(&f.buf, index, f.ppem, font.HintingFull)
+	if err != nil {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	segments, err := f.font.LoadGlyph(&f.buf, index, f.ppem, nil)
+	if err != nil {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+
+	bounds := segments.Bounds().Add(dot)
+	dr := image.Rect(bounds.Min.X.Floor(), bounds.Min.Y.Floor(), bounds.Max.X.Ceil(), bounds.Max.Y.Ceil())
+	if dr.Dx() < 0 || dr.Dy() < 0 {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+
+	// offset the segments so that the top left of the glyph's pixel bounds is at the origin of the rasterizer
+	biasX := dot.X - fixed.I(dr.Min.X)
+	biasY := dot.Y - fixed.I(dr.Min.Y)
+	point := func(p fixed.Point26_6) (float32, float32) {
+		return float32(p.X+biasX) / 64, float32(p.Y+biasY) / 64
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	f.rast.Reset(dr.Dx(), dr.Dy())
+	f.rast.DrawOp = draw.Src
+	for _, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			f.rast.MoveTo(point(seg.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			f.rast.LineTo(point(seg.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x1, y1 := point(seg.Args[0])
+			x2, y2 := point(seg.Args[1])
+			f.rast.QuadTo(x1, y1, x2, y2)
+		case sfnt.SegmentOpCubeTo:
+			x1, y1 := point(seg.Args[0])
+			x2, y2 := point(seg.Args[1])
+			x3, y3 := point(seg.Args[2])
+			f.rast.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	f.rast.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+	return dr, mask, image.Point{}, advance, true
+}
+
+func (f *indexFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	index, ok := f.index(r)
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	bounds, advance, err := f.font.GlyphBounds(&f.buf, index, f.ppem, font.HintingFull)
+	return bounds, advance, err == nil
+}
+
+func (f *indexFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	index, ok := f.index(r)
+	if !ok {
+		return 0, false
+	}
+	advance, err := f.font.GlyphAdvance(&f.buf, index, f.ppem, font.HintingFull)
+	return advance, err == nil
+}
+
+func (f *indexFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return 0
+}
+
+func (f *indexFace) Metrics() font.Metrics {
+	return f.metrics
+}
@@ -0,0 +1,163 @@
+package font
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// obliqueShear is the horizontal shift per pixel of height used for synthetic italics, roughly a 11° slant
+const obliqueShear = 0.2
+
+// syntheticFace draws the glyphs of another face emboldened and/or slanted, for families without bold or italic
+// styles. Advances are left unchanged so that the face stays on the cell grid.
+type syntheticFace struct {
+	font.Face
+	strength int     // pixels each glyph is smeared to the right by, which thickens vertical strokes
+	shear    float64 // horizontal shift per pixel above the baseline
+}
+
+func newSyntheticFace(face font.Face, strength int, oblique bool) font.Face {
+	if strength <= 0 && !oblique {
+		return face
+	}
+	synthetic := &syntheticFace{
+		Face:     face,
+		strength: strength,
+	}
+	if oblique {
+		synthetic.shear = obliqueShear
+	}
+	return synthetic
+}
+
+func (f *syntheticFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	bounds, advance, ok := f.Face.GlyphBounds(r)
+	if !ok {
+		return bounds, advance, ok
+	}
+	// allow an extra pixel either side for coverage which is split between neighbouring pixels
+	bounds.Min.X += fixed.Int26_6(-float64(bounds.Max.Y)*f.shear) - fixed.I(1)
+	bounds.Max.X += fixed.Int26_6(-float64(bounds.Min.Y)*f.shear) + fixed.I(f.strength+1)
+	return bounds, advance, ok
+}
+
+func (f *syntheticFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	dr, mask, maskp, advance, ok := f.Face.Glyph(dot, r)
+	if !ok || dr.Empty() {
+		return dr, mask, maskp, advance, ok
+	}
+
+	baseline := float64(dot.Y) / 64
+	left := int(math.Floor(-(float64(dr.Max.Y) - baseline) * f.shear))
+	right := int(math.Ceil(-(float64(dr.Min.Y) - baseline) * f.shear))
+	bounds := image.Rect(dr.Min.X+left, dr.Min.Y, dr.Max.X+right+f.strength+1, dr.Max.Y)
+
+	sheared := image.NewAlpha(bounds)
+	for y := dr.Min.Y; y < dr.Max.Y; y++ {
+		shift := -(float64(y) + 0.5 - baseline) * f.shear
+		whole := math.Floor(shift)
+		frac := shift - whole
+		for x := dr.Min.X; x < dr.Max.X; x++ {
+			_, _, _, a := mask.At(maskp.X+x-dr.Min.X, maskp.Y+y-dr.Min.Y).RGBA()
+			if a == 0 {
+				continue
+			}
+			coverage := float64(a >> 8)
+			tx := x + int(whole)
+			addCoverage(sheared, tx, y, coverage*(1-frac))
+			addCoverage(sheared, tx+1, y, coverage*frac)
+		}
+	}
+
+	if f.strength == 0 {
+		return bounds, sheared, bounds.Min, advance, true
+	}
+
+	// offset multi-strike: each pixel takes the strongest coverage of the pixels to its left within the strength
+	emboldened := image.NewAlpha(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var strongest uint8
+			for k := 0; k <= f.strength && x-k >= bounds.Min.X; k++ {
+				if a := sheared.AlphaAt(x-k, y).A; a > strongest {
+					strongest = a
+				}
+			}
+			emboldened.Pix[emboldened.PixOffset(x, y)] = strongest
+		}
+	}
+	return bounds, emboldened, bounds.Min, advance, true
+}
+
+func addCoverage(img *image.Alpha, x, y int, coverage float64) {
+	if coverage <= 0 || !(image.Point{X: x, Y: y}).In(img.Rect) {
+		return
+	}
+	i := img.PixOffset(x, y)
+	img.Pix[i] = uint8(math.Min(255, float64(img.Pix[i])+math.Round(coverage)))
+}
+
+// synthesiseStyles creates emboldened and slanted versions of the regular face for any styles missing from the
+// family, and applies the configured weight to variable fonts
+func (m *Manager) synthesiseStyles(sources map[Style]*fontSource, axis *weightAxis) map[Style]*styleFont {
+
+	// bold is drawn 300 units heavier than the regular style, which is the step from 400 (regular) to 700 (bold).
+	// Variable fonts are drawn at the heavier weight, emboldened by however much of the step is beyond the axis.
+	var regularWeight, boldWeight float64
+	boldStrength := m.emboldenStrength(300)
+	if axis != nil {
+		regularWeight = axis.clamp(m.weight)
+		boldWeight = math.Min(regularWeight+300, axis.max)
+		boldStrength = m.emboldenStrength(regularWeight + 300 - boldWeight)
+		if regularWeight == axis.def {
+			regularWeight = 0
+		}
+	}
+
+	regular := sources[Regular]
+	bold, realBold := sources[Bold]
+	italic, realItalic := sources[Italic]
+	boldItalic, realBoldItalic := sources[BoldItalic]
+
+	styles := map[Style]*styleFont{
+		Regular: m.newStyleFont(regular, regularWeight, 0, false),
+	}
+	if realBold {
+		styles[Bold] = m.newStyleFont(bold, 0, 0, false)
+	} else {
+		styles[Bold] = m.newStyleFont(regular, boldWeight, boldStrength, false)
+	}
+	if realItalic {
+		styles[Italic] = m.newStyleFont(italic, 0, 0, false)
+	} else {
+		styles[Italic] = m.newStyleFont(regular, regularWeight, 0, true)
+	}
+	switch {
+	case realBoldItalic:
+		styles[BoldItalic] = m.newStyleFont(boldItalic, 0, 0, false)
+	case realItalic:
+		styles[BoldItalic] = m.newStyleFont(italic, 0, m.emboldenStrength(300), false)
+	case realBold:
+		styles[BoldItalic] = m.newStyleFont(bold, 0, 0, true)
+	default:
+		styles[BoldItalic] = m.newStyleFont(regular, boldWeight, boldStrength, true)
+	}
+	return styles
+}
+
+// emboldenStrength converts an increase in weight to the number of pixels glyphs are smeared by, scaled with the
+// font size so that bold text is a similar weight at all sizes
+func (m *Manager) emboldenStrength(weight float64) int {
+	if weight <= 0 {
+		return 0
+	}
+	pixelSize := m.size * m.dpi / 72
+	strength := int(math.Round(weight / 300 * math.Max(1, pixelSize/24)))
+	if strength < 1 {
+		return 1
+	}
+	return strength
+}
@@ -0,0 +1,104 @@
+package font
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func fixedPoint(x, y int) fixed.Point26_6 {
+	return fixed.Point26_6{X: fixed.I(x), Y: fixed.I(y)}
+}
+
+// barFace draws every rune as an opaque bar one pixel wide, from the baseline up to the given height
+type barFace struct {
+	font.Face
+	height int
+}
+
+func (f barFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	x, y := dot.X.Round(), dot.Y.Round()
+	dr := image.Rect(x, y-f.height, x+1, y)
+	return dr, image.Opaque, image.Point{}, fixed.I(4), true
+}
+
+func (f barFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	bounds := fixed.Rectangle26_6{Min: fixedPoint(0, -f.height), Max: fixedPoint(1, 0)}
+	return bounds, fixed.I(4), true
+}
+
+// alphaAt returns the coverage of the synthesised mask at a pixel
+func alphaAt(dr image.Rectangle, mask image.Image, maskp image.Point, x, y int) uint8 {
+	if !(image.Point{X: x, Y: y}).In(dr) {
+		return 0
+	}
+	_, _, _, a := mask.At(maskp.X+x-dr.Min.X, maskp.Y+y-dr.Min.Y).RGBA()
+	return uint8(a >> 8)
+}
+
+func TestSyntheticFaceWithoutEffectsIsTheFace(t *testing.T) {
+	face := barFace{height: 4}
+	assert.Equal(t, face, newSyntheticFace(face, 0, false))
+}
+
+func TestSyntheticFaceEmboldens(t *testing.T) {
+	face := newSyntheticFace(barFace{height: 4}, 2, false)
+	dr, mask, maskp, advance, ok := face.Glyph(fixedPoint(10, 20), 'l')
+	require.True(t, ok)
+
+	assert.Equal(t, fixed.I(4), advance, "the advance is unchanged so that the face stays on the grid")
+	for y := 16; y < 20; y++ {
+		assert.Equal(t, uint8(0), alphaAt(dr, mask, maskp, 9, y))
+		for x := 10; x <= 12; x++ {
+			assert.Equal(t, uint8(0xff), alphaAt(dr, mask, maskp, x, y), "pixel %d,%d", x, y)
+		}
+		assert.Equal(t, uint8(0), alphaAt(dr, mask, maskp, 13, y))
+	}
+}
+
+func TestSyntheticFaceSlants(t *testing.T) {
+	face := newSyntheticFace(barFace{height: 10}, 0, true)
+	dr, mask, maskp, _, ok := face.Glyph(fixedPoint(10, 20), 'l')
+	require.True(t, ok)
+
+	// returns the coverage weighted mean x position of a row
+	centre := func(y int) float64 {
+		var sum, total float64
+		for x := dr.Min.X; x < dr.Max.X; x++ {
+			a := float64(alphaAt(dr, mask, maskp, x, y))
+			sum += a * (float64(x) + 0.5)
+			total += a
+		}
+		require.NotZero(t, total, "row %d is empty", y)
+		return sum / total
+	}
+
+	// each row moves right by the shear for each pixel above the baseline
+	bottom, top := centre(19), centre(10)
+	assert.InDelta(t, 10.5+0.5*obliqueShear, bottom, 0.01)
+	assert.InDelta(t, 9*obliqueShear, top-bottom, 0.01)
+}
+
+func TestSyntheticFaceBoundsCoverTheGlyph(t *testing.T) {
+	face := newSyntheticFace(barFace{height: 10}, 2, true)
+	bounds, _, ok := face.GlyphBounds('l')
+	require.True(t, ok)
+	dr, _, _, _, ok := face.Glyph(fixedPoint(0, 0), 'l')
+	require.True(t, ok)
+	assert.LessOrEqual(t, bounds.Min.X.Floor(), dr.Min.X)
+	assert.GreaterOrEqual(t, bounds.Max.X.Ceil(), dr.Max.X)
+}
+
+func TestEmboldenStrengthScalesWithSize(t *testing.T) {
+	m := NewManager()
+	assert.Equal(t, 0, m.emboldenStrength(0))
+	assert.Equal(t, 0, m.emboldenStrength(-100))
+	assert.Equal(t, 1, m.emboldenStrength(10))
+	assert.Equal(t, 1, m.emboldenStrength(300))
+	m.size = 48
+	assert.Equal(t, 2, m.emboldenStrength(300))
+}
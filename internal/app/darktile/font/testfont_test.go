@@ -0,0 +1,293 @@
+package font
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// the metrics of fonts built by testFont.build, in font units
+const (
+	testUnitsPerEm  = 1000
+	testAscent      = 800
+	testDescent     = -200
+	testAdvance     = 600
+	testWeightDelta = 100 // how much wider glyphs are at the maximum weight, and narrower at the minimum
+)
+
+// testGlyph is a glyph of a test font, whose outline is a rectangle in font units. Glyphs with an empty rectangle
+// have no outline.
+type testGlyph struct {
+	r                      rune // the character mapped to the glyph, or 0 for none
+	xMin, yMin, xMax, yMax int16
+}
+
+// testFont describes a small TrueType font for tests, so that no font files need to be kept in the repository
+type testFont struct {
+	glyphs []testGlyph       // glyph 0 is the notdef glyph
+	weight *weightAxis       // adds a weight axis, with the right edge of each glyph moving by testWeightDelta
+	tables map[string][]byte // extra tables, such as COLR and CPAL
+}
+
+func (f testFont) build() []byte {
+	tables := map[string][]byte{
+		"head": f.head(),
+		"hhea": f.hhea(),
+		"maxp": f.maxp(),
+		"hmtx": f.hmtx(),
+		"cmap": f.cmap(),
+		"post": f.post(),
+	}
+	tables["glyf"], tables["loca"] = f.glyf()
+	if f.weight != nil {
+		tables["fvar"] = f.fvar()
+		tables["gvar"] = f.gvar()
+	}
+	for tag, data := range f.tables {
+		tables[tag] = data
+	}
+	return writeTestFont(tables)
+}
+
+func (f testFont) empty(g testGlyph) bool {
+	return g.xMin >= g.xMax || g.yMin >= g.yMax
+}
+
+func (f testFont) head() []byte {
+	b := make([]byte, 54)
+	binary.BigEndian.PutUint32(b[0:], 0x00010000)
+	binary.BigEndian.PutUint32(b[12:], 0x5F0F3CF5)
+	binary.BigEndian.PutUint16(b[18:], testUnitsPerEm)
+	binary.BigEndian.PutUint16(b[38:], uint16(testDescent&0xFFFF))
+	binary.BigEndian.PutUint16(b[40:], testAdvance)
+	binary.BigEndian.PutUint16(b[42:], testAscent)
+	binary.BigEndian.PutUint16(b[48:], 2)
+	binary.BigEndian.PutUint16(b[50:], 1) // long loca offsets
+	return b
+}
+
+func (f testFont) hhea() []byte {
+	b := make([]byte, 36)
+	binary.BigEndian.PutUint32(b[0:], 0x00010000)
+	binary.BigEndian.PutUint16(b[4:], testAscent)
+	binary.BigEndian.PutUint16(b[6:], uint16(testDescent&0xFFFF))
+	binary.BigEndian.PutUint16(b[10:], testAdvance)
+	binary.BigEndian.PutUint16(b[18:], 1)
+	binary.BigEndian.PutUint16(b[34:], uint16(len(f.glyphs)))
+	return b
+}
+
+func (f testFont) maxp() []byte {
+	b := make([]byte, 32)
+	binary.BigEndian.PutUint32(b[0:], 0x00010000)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(f.glyphs)))
+	binary.BigEndian.PutUint16(b[6:], 4)  // points
+	binary.BigEndian.PutUint16(b[8:], 1)  // contours
+	binary.BigEndian.PutUint16(b[14:], 2) // zones
+	return b
+}
+
+func (f testFont) hmtx() []byte {
+	b := make([]byte, 4*len(f.glyphs))
+	for i, g := range f.glyphs {
+		binary.BigEndian.PutUint16(b[4*i:], testAdvance)
+		binary.BigEndian.PutUint16(b[4*i+2:], uint16(g.xMin))
+	}
+	return b
+}
+
+// cmap maps the characters with a format 12 subtable
+func (f testFont) cmap() []byte {
+	type group struct {
+		r   rune
+		gid int
+	}
+	var groups []group
+	for gid, g := range f.glyphs {
+		if g.r != 0 {
+			groups = append(groups, group{g.r, gid})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].r < groups[j].r })
+
+	b := make([]byte, 12+16+12*len(groups))
+	binary.BigEndian.PutUint16(b[2:], 1)
+	binary.BigEndian.PutUint16(b[4:], 3)  // Windows
+	binary.BigEndian.PutUint16(b[6:], 10) // Unicode full repertoire
+	binary.BigEndian.PutUint32(b[8:], 12)
+	sub := b[12:]
+	binary.BigEndian.PutUint16(sub[0:], 12)
+	binary.BigEndian.PutUint32(sub[4:], uint32(len(sub)))
+	binary.BigEndian.PutUint32(sub[12:], uint32(len(groups)))
+	for i, g := range groups {
+		record := sub[16+12*i:]
+		binary.BigEndian.PutUint32(record[0:], uint32(g.r))
+		binary.BigEndian.PutUint32(record[4:], uint32(g.r))
+		binary.BigEndian.PutUint32(record[8:], uint32(g.gid))
+	}
+	return b
+}
+
+func (f testFont) post() []byte {
+	b := make([]byte, 32)
+	binary.BigEndian.PutUint32(b[0:], 0x00030000)
+	binary.BigEndian.PutUint32(b[12:], 1) // monospaced
+	return b
+}
+
+// glyf returns the glyf and loca tables, with each rectangle drawn clockwise from its bottom left corner
+func (f testFont) glyf() ([]byte, []byte) {
+	var glyf []byte
+	loca := make([]byte, 4*(len(f.glyphs)+1))
+	for i, g := range f.glyphs {
+		binary.BigEndian.PutUint32(loca[4*i:], uint32(len(glyf)))
+		if f.empty(g) {
+			continue
+		}
+		b := make([]byte, 10+2+2+4+8+8)
+		binary.BigEndian.PutUint16(b[0:], 1)
+		binary.BigEndian.PutUint16(b[2:], uint16(g.xMin))
+		binary.BigEndian.PutUint16(b[4:], uint16(g.yMin))
+		binary.BigEndian.PutUint16(b[6:], uint16(g.xMax))
+		binary.BigEndian.PutUint16(b[8:], uint16(g.yMax))
+		binary.BigEndian.PutUint16(b[10:], 3) // last point of the contour
+		copy(b[14:], []byte{1, 1, 1, 1})      // on curve, with long coordinates
+		xs := []int16{g.xMin, 0, g.xMax - g.xMin, 0}
+		ys := []int16{g.yMin, g.yMax - g.yMin, 0, g.yMin - g.yMax}
+		for j := 0; j < 4; j++ {
+			binary.BigEndian.PutUint16(b[18+2*j:], uint16(xs[j]))
+			binary.BigEndian.PutUint16(b[26+2*j:], uint16(ys[j]))
+		}
+		glyf = append(glyf, b...)
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*len(f.glyphs):], uint32(len(glyf)))
+	return glyf, loca
+}
+
+func (f testFont) fvar() []byte {
+	b := make([]byte, 16+20)
+	binary.BigEndian.PutUint16(b[0:], 1)
+	binary.BigEndian.PutUint16(b[4:], 16)
+	binary.BigEndian.PutUint16(b[6:], 2)
+	binary.BigEndian.PutUint16(b[8:], 1)
+	binary.BigEndian.PutUint16(b[10:], 20)
+	binary.BigEndian.PutUint16(b[14:], 8)
+	axis := b[16:]
+	copy(axis, "wght")
+	binary.BigEndian.PutUint32(axis[4:], uint32(int32(f.weight.min*65536)))
+	binary.BigEndian.PutUint32(axis[8:], uint32(int32(f.weight.def*65536)))
+	binary.BigEndian.PutUint32(axis[12:], uint32(int32(f.weight.max*65536)))
+	binary.BigEndian.PutUint16(axis[18:], 256)
+	return b
+}
+
+// gvar moves the right edge of each glyph by testWeightDelta at the maximum of the weight axis, and by minus as
+// much at the minimum. The advance is unchanged, so the font stays monospaced.
+func (f testFont) gvar() []byte {
+
+	// deltas for the four corners of the rectangle and the four phantom points
+	tuple := func(delta int16) []byte {
+		xs := []int16{0, 0, delta, delta, 0, 0, 0, 0}
+		b := []byte{0x00, 0x40 | byte(len(xs)-1)} // all points, then the x deltas as words
+		for _, x := range xs {
+			b = append(b, byte(uint16(x)>>8), byte(x))
+		}
+		return append(b, 0x80|byte(len(xs)-1)) // no y deltas
+	}
+	wider, narrower := tuple(testWeightDelta), tuple(-testWeightDelta)
+
+	var variation []byte
+	variation = appendUint16(variation, 2)  // tuples
+	variation = appendUint16(variation, 16) // offset of the serialised data
+	for _, t := range []struct {
+		data []byte
+		peak uint16
+	}{{wider, 0x4000}, {narrower, 0xC000}} {
+		variation = appendUint16(variation, uint16(len(t.data)))
+		variation = appendUint16(variation, 0x8000|0x2000) // embedded peak, private points
+		variation = appendUint16(variation, t.peak)
+	}
+	variation = append(append(variation, wider...), narrower...)
+
+	const headerSize = 20
+	offsets := make([]byte, 4*(len(f.glyphs)+1))
+	var data []byte
+	for i, g := range f.glyphs {
+		binary.BigEndian.PutUint32(offsets[4*i:], uint32(len(data)))
+		if !f.empty(g) {
+			data = append(data, variation...)
+		}
+	}
+	binary.BigEndian.PutUint32(offsets[4*len(f.glyphs):], uint32(len(data)))
+
+	b := make([]byte, headerSize)
+	binary.BigEndian.PutUint16(b[0:], 1)
+	binary.BigEndian.PutUint16(b[4:], 1)
+	binary.BigEndian.PutUint32(b[8:], uint32(headerSize+len(offsets)))
+	binary.BigEndian.PutUint16(b[12:], uint16(len(f.glyphs)))
+	binary.BigEndian.PutUint16(b[14:], 1) // long offsets
+	binary.BigEndian.PutUint32(b[16:], uint32(headerSize+len(offsets)))
+	return append(append(b, offsets...), data...)
+}
+
+// writeTestFont writes the tables into a font file, in order of their tags
+func writeTestFont(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= len(tags) {
+		searchRange *= 2
+		entrySelector++
+	}
+
+	header := make([]byte, 12+16*len(tags))
+	binary.BigEndian.PutUint32(header[0:], 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(len(tags)))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange*16))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16((len(tags)-searchRange)*16))
+
+	var body []byte
+	for i, tag := range tags {
+		data := tables[tag]
+		record := header[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], testChecksum(data))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(header)+len(body)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(data)))
+		body = append(body, data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	return append(header, body...)
+}
+
+func testChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// testGlyphs are the glyphs of a monospaced test font: the notdef glyph, a space and some letters
+var testGlyphs = []testGlyph{
+	{0, 50, 0, 550, 700},
+	{' ', 0, 0, 0, 0},
+	{'a', 100, 0, 500, 500},
+	{'b', 100, 0, 500, 700},
+	{'c', 100, 0, 500, 500},
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
@@ -0,0 +1,249 @@
+package font
+
+import (
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+
+	gotext "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+var tagWght = ot.MustNewTag("wght")
+
+// weightAxis is the range of the weight ('wght') axis of a variable font
+type weightAxis struct {
+	min float64
+	def float64
+	max float64
+}
+
+// clamp limits a weight to the range of the axis, using the default weight for zero
+func (a weightAxis) clamp(weight float64) float64 {
+	switch {
+	case weight == 0:
+		return a.def
+	case weight < a.min:
+		return a.min
+	case weight > a.max:
+		return a.max
+	}
+	return weight
+}
+
+// readWeightAxis reads the weight axis from the 'fvar' table of a font, returning nil unless it is a variable font
+// with one
+func readWeightAxis(r io.ReaderAt) *weightAxis {
+
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil
+	}
+	numTables := int(binary.BigEndian.Uint16(header[4:]))
+
+	records := make([]byte, 16*numTables)
+	if _, err := r.ReadAt(records, 12); err != nil {
+		return nil
+	}
+
+	for i := 0; i < numTables; i++ {
+		record := records[16*i:]
+		if string(record[:4]) != "fvar" {
+			continue
+		}
+		offset := int64(binary.BigEndian.Uint32(record[8:]))
+
+		fvar := make([]byte, 16)
+		if _, err := r.ReadAt(fvar, offset); err != nil {
+			return nil
+		}
+		axesOffset := int64(binary.BigEndian.Uint16(fvar[4:]))
+		axisCount := int(binary.BigEndian.Uint16(fvar[8:]))
+		axisSize := int64(binary.BigEndian.Uint16(fvar[10:]))
+		if axisSize < 16 {
+			return nil
+		}
+
+		axis := make([]byte, 16)
+		for j := 0; j < axisCount; j++ {
+			if _, err := r.ReadAt(axis, offset+axesOffset+int64(j)*axisSize); err != nil {
+				return nil
+			}
+			if string(axis[:4]) != "wght" {
+				continue
+			}
+			return &weightAxis{
+				min: fixed16Dot16(axis[4:]),
+				def: fixed16Dot16(axis[8:]),
+				max: fixed16Dot16(axis[12:]),
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func fixed16Dot16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+// variableFace draws the glyphs of a variable font at a weight other than the default, as the faces of
+// golang.org/x/image can only draw the default instance. Advances are rounded to whole pixels, as with full hinting.
+type variableFace struct {
+	face    *gotext.Face
+	metrics font.Metrics
+	scale   float64 // pixels per font unit
+	byIndex bool    // runes are glyph indices offset by glyphRuneBase, as with indexFace
+	rast    vector.Rasterizer
+}
+
+// newVariableFace returns a face which draws the font of the source at the given weight, or nil if the font can't
+// be parsed
+func newVariableFace(source *fontSource, weight float64, ppem fixed.Int26_6, byIndex bool) font.Face {
+	shaping := source.shapingFace()
+	if shaping == nil {
+		return nil
+	}
+	face := gotext.NewFace(shaping.Font)
+	face.SetVariations([]gotext.Variation{{Tag: tagWght, Value: float32(weight)}})
+	return &variableFace{
+		face:    face,
+		metrics: source.face.Metrics(),
+		scale:   float64(ppem) / 64 / float64(face.Upem()),
+		byIndex: byIndex,
+	}
+}
+
+func (f *variableFace) glyph(r rune) (gotext.GID, bool) {
+	if !f.byIndex {
+		// runes missing from the font are drawn with the notdef glyph, as by the faces of golang.org/x/image
+		gid, _ := f.face.NominalGlyph(r)
+		return gid, true
+	}
+	if r < glyphRuneBase {
+		return 0, false
+	}
+	return gotext.GID(r - glyphRuneBase), true
+}
+
+// outline returns the segments of a glyph at the face's weight, in font units with y increasing upwards
+func (f *variableFace) outline(r rune) (gotext.GID, []gotext.Segment, bool) {
+	gid, ok := f.glyph(r)
+	if !ok {
+		return 0, nil, false
+	}
+	outline, ok := f.face.GlyphData(gid).(gotext.GlyphOutline)
+	if !ok {
+		return 0, nil, false
+	}
+	return gid, outline.Segments, true
+}
+
+func (f *variableFace) advance(gid gotext.GID) fixed.Int26_6 {
+	return fixed.I(int(math.Round(float64(f.face.HorizontalAdvance(gid)) * f.scale)))
+}
+
+// extent returns the bounds of the segments in pixels relative to the glyph origin, with y increasing downwards
+func (f *variableFace) extent(segments []gotext.Segment) (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for i := range segments {
+		for _, p := range segments[i].ArgsSlice() {
+			x, y := float64(p.X)*f.scale, -float64(p.Y)*f.scale
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+func (f *variableFace) Close() error {
+	return nil
+}
+
+func (f *variableFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	gid, segments, ok := f.outline(r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	advance := f.advance(gid)
+	if len(segments) == 0 {
+		return image.Rectangle{}, image.NewAlpha(image.Rectangle{}), image.Point{}, advance, true
+	}
+
+	originX, originY := float64(dot.X)/64, float64(dot.Y)/64
+	minX, minY, maxX, maxY := f.extent(segments)
+	dr := image.Rect(
+		int(math.Floor(originX+minX)), int(math.Floor(originY+minY)),
+		int(math.Ceil(originX+maxX)), int(math.Ceil(originY+maxY)),
+	)
+
+	// offset the segments so that the top left of the glyph's pixel bounds is at the origin of the rasterizer
+	point := func(p gotext.SegmentPoint) (float32, float32) {
+		return float32(originX + float64(p.X)*f.scale - float64(dr.Min.X)), float32(originY - float64(p.Y)*f.scale - float64(dr.Min.Y))
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	f.rast.Reset(dr.Dx(), dr.Dy())
+	f.rast.DrawOp = draw.Src
+	for _, seg := range segments {
+		switch seg.Op {
+		case ot.SegmentOpMoveTo:
+			f.rast.ClosePath()
+			f.rast.MoveTo(point(seg.Args[0]))
+		case ot.SegmentOpLineTo:
+			f.rast.LineTo(point(seg.Args[0]))
+		case ot.SegmentOpQuadTo:
+			x1, y1 := point(seg.Args[0])
+			x2, y2 := point(seg.Args[1])
+			f.rast.QuadTo(x1, y1, x2, y2)
+		case ot.SegmentOpCubeTo:
+			x1, y1 := point(seg.Args[0])
+			x2, y2 := point(seg.Args[1])
+			x3, y3 := point(seg.Args[2])
+			f.rast.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	f.rast.ClosePath()
+	f.rast.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+	return dr, mask, image.Point{}, advance, true
+}
+
+func (f *variableFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	gid, segments, ok := f.outline(r)
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	if len(segments) == 0 {
+		return fixed.Rectangle26_6{}, f.advance(gid), true
+	}
+	minX, minY, maxX, maxY := f.extent(segments)
+	bounds := fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: fixed.Int26_6(math.Floor(minX * 64)), Y: fixed.Int26_6(math.Floor(minY * 64))},
+		Max: fixed.Point26_6{X: fixed.Int26_6(math.Ceil(maxX * 64)), Y: fixed.Int26_6(math.Ceil(maxY * 64))},
+	}
+	return bounds, f.advance(gid), true
+}
+
+func (f *variableFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	gid, ok := f.glyph(r)
+	if !ok {
+		return 0, false
+	}
+	return f.advance(gid), true
+}
+
+func (f *variableFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return 0
+}
+
+func (f *variableFace) Metrics() font.Metrics {
+	return f.metrics
+}
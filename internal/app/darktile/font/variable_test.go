@@ -0,0 +1,111 @@
+package font
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+func variableTestFont() []byte {
+	return testFont{glyphs: testGlyphs, weight: &weightAxis{min: 100, def: 400, max: 900}}.build()
+}
+
+func TestReadWeightAxis(t *testing.T) {
+	axis := readWeightAxis(bytes.NewReader(variableTestFont()))
+	require.NotNil(t, axis)
+	assert.Equal(t, weightAxis{min: 100, def: 400, max: 900}, *axis)
+}
+
+func TestReadWeightAxisOfStaticFont(t *testing.T) {
+	assert.Nil(t, readWeightAxis(bytes.NewReader(gomono.TTF)))
+	assert.Nil(t, readWeightAxis(bytes.NewReader(testFont{glyphs: testGlyphs}.build())))
+}
+
+func TestReadWeightAxisOfTruncatedFont(t *testing.T) {
+	data := variableTestFont()
+	tables := 12 + 16*int(binary.BigEndian.Uint16(data[4:]))
+	for _, length := range []int{0, 8, 20, tables} {
+		assert.Nil(t, readWeightAxis(bytes.NewReader(data[:length])), "length %d", length)
+	}
+}
+
+func TestWeightAxisClamp(t *testing.T) {
+	axis := weightAxis{min: 100, def: 400, max: 900}
+	tests := []struct {
+		weight   float64
+		expected float64
+	}{
+		{0, 400},
+		{1, 100},
+		{300, 300},
+		{700, 700},
+		{1000, 900},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, axis.clamp(test.weight), "weight %g", test.weight)
+	}
+}
+
+// glyphWidth returns the width in pixels of the glyph drawn for 'a' in the regular style
+func glyphWidth(t *testing.T, m *Manager) int {
+	dr, _, _, _, ok := m.RegularFontFace().Glyph(fixedPoint(0, 20), 'a')
+	require.True(t, ok)
+	return dr.Dx()
+}
+
+func TestWeightIsAppliedToVariableFonts(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.SetSize(10))
+	require.NoError(t, m.SetFontData("Variable", variableTestFont()))
+
+	// at size 10 and 72 DPI a font unit is a hundredth of a pixel, so the 400 unit wide glyph is 4 pixels wide
+	assert.Equal(t, 4, glyphWidth(t, m))
+	cellSize := m.CharSize()
+
+	require.NoError(t, m.SetWeight(900))
+	assert.Equal(t, 5, glyphWidth(t, m))
+	assert.Equal(t, cellSize, m.CharSize())
+
+	require.NoError(t, m.SetWeight(100))
+	assert.Equal(t, 3, glyphWidth(t, m))
+	assert.Equal(t, cellSize, m.CharSize())
+
+	// weights are clamped to the axis
+	require.NoError(t, m.SetWeight(1000))
+	assert.Equal(t, 5, glyphWidth(t, m))
+}
+
+func TestSynthesisedBoldOfVariableFontUsesHeavierWeight(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.SetSize(10))
+	require.NoError(t, m.SetFontData("Variable", variableTestFont()))
+
+	// bold is 300 units heavier than the default of 400, which is 60% of the way to the maximum weight of 900
+	dr, _, _, _, ok := m.BoldFontFace().Glyph(fixedPoint(0, 20), 'a')
+	require.True(t, ok)
+	assert.Equal(t, 5, dr.Dx())
+
+	// at the maximum weight, bold can only be drawn by emboldening
+	require.NoError(t, m.SetWeight(900))
+	dr, _, _, _, ok = m.BoldFontFace().Glyph(fixedPoint(0, 20), 'a')
+	require.True(t, ok)
+	assert.Greater(t, dr.Dx(), glyphWidth(t, m))
+}
+
+func TestVariableFaceDrawsShapedGlyphs(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.SetSize(10))
+	require.NoError(t, m.SetFontData("Variable", variableTestFont()))
+	require.NoError(t, m.SetWeight(900))
+
+	glyphs := m.Shape(Regular, []rune("ab"))
+	require.Len(t, glyphs, 2)
+	dr, _, _, advance, ok := m.GlyphFace(Regular).Glyph(fixedPoint(0, 20), glyphs[0].Rune)
+	require.True(t, ok)
+	assert.Equal(t, 5, dr.Dx())
+	assert.Equal(t, 6, advance.Round())
+}
@@ -0,0 +1,64 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// ANSI returns the content of the given lines with SGR escape sequences which reproduce the colours and styles in
+// another terminal. Colours matching the theme defaults are left for the receiving terminal to decide.
+func ANSI(lines [][]termutil.Cell, theme *termutil.Theme) string {
+	var builder strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		var styled bool
+		for _, r := range runs(line, theme) {
+			if r.style.isDefault() {
+				if styled {
+					builder.WriteString("\x1b[0m")
+					styled = false
+				}
+			} else {
+				builder.WriteString(sgr(r.style))
+				styled = true
+			}
+			builder.WriteString(r.text)
+		}
+		if styled {
+			builder.WriteString("\x1b[0m")
+		}
+	}
+	return builder.String()
+}
+
+func sgr(s style) string {
+	params := []string{"0"}
+	if s.bold {
+		params = append(params, "1")
+	}
+	if s.dim {
+		params = append(params, "2")
+	}
+	if s.italic {
+		params = append(params, "3")
+	}
+	if s.underline {
+		params = append(params, "4")
+	}
+	if s.strikethrough {
+		params = append(params, "9")
+	}
+	if s.fg != nil {
+		c := rgb(s.fg)
+		params = append(params, fmt.Sprintf("38;2;%d;%d;%d", c[0], c[1], c[2]))
+	}
+	if s.bg != nil {
+		c := rgb(s.bg)
+		params = append(params, fmt.Sprintf("48;2;%d;%d;%d", c[0], c[1], c[2]))
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
@@ -0,0 +1,121 @@
+package format
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// style is the visual style of a run of cells - colours which match the theme defaults are nil
+type style struct {
+	fg            color.Color
+	bg            color.Color
+	bold          bool
+	dim           bool
+	italic        bool
+	underline     bool
+	strikethrough bool
+}
+
+func styleOf(cell termutil.Cell, theme *termutil.Theme) style {
+	s := style{
+		bold:          cell.Bold(),
+		dim:           cell.Dim(),
+		italic:        cell.Italic(),
+		underline:     cell.Underline(),
+		strikethrough: cell.Strikethrough(),
+	}
+	if fg := theme.Resolve(cell.Fg()); fg != nil && !sameColour(fg, theme.DefaultForeground()) {
+		s.fg = fg
+	}
+	if bg := theme.Resolve(cell.Bg()); bg != nil && !sameColour(bg, theme.DefaultBackground()) {
+		s.bg = bg
+	}
+	return s
+}
+
+func (s style) equals(other style) bool {
+	return sameColour(s.fg, other.fg) &&
+		sameColour(s.bg, other.bg) &&
+		s.bold == other.bold &&
+		s.dim == other.dim &&
+		s.italic == other.italic &&
+		s.underline == other.underline &&
+		s.strikethrough == other.strikethrough
+}
+
+func (s style) isDefault() bool {
+	return s.equals(style{})
+}
+
+func sameColour(a, b color.Color) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return rgb(a) == rgb(b)
+}
+
+func rgb(c color.Color) [3]uint8 {
+	r, g, b, _ := c.RGBA()
+	return [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}
+}
+
+type run struct {
+	style style
+	text  string
+	cells int // number of cells covered, which differs from the length of the text when it has wide characters
+}
+
+// splits a line of cells into runs of text which share the same style
+func runs(cells []termutil.Cell, theme *termutil.Theme) []run {
+	var output []run
+	var current run
+	var text strings.Builder
+	for i, cell := range cells {
+		s := styleOf(cell, theme)
+		if i > 0 && !s.equals(current.style) {
+			current.text = text.String()
+			output = append(output, current)
+			current.cells = 0
+			text.Reset()
+		}
+		current.style = s
+		current.cells++
+		if cell.Continuation() {
+			continue
+		}
+		if r := cell.Rune().Rune; r != 0 {
+			text.WriteRune(r)
+			for _, c := range cell.Combining() {
+				text.WriteRune(c)
+			}
+		} else {
+			text.WriteRune(' ')
+		}
+	}
+	if text.Len() > 0 {
+		current.text = text.String()
+		output = append(output, current)
+	}
+	return output
+}
+
+// Text returns the plain text content of the given lines
+func Text(lines [][]termutil.Cell) string {
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		texts = append(texts, termutil.CellsToString(line))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Markdown returns the plain text content of the given lines wrapped in a markdown code fence
+func Markdown(lines [][]termutil.Cell) string {
+	text := Text(lines)
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + "\n" + text + "\n" + fence + "\n"
+}
@@ -0,0 +1,193 @@
+package format
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTheme() *termutil.Theme {
+	return termutil.NewThemeFactory().
+		WithColour(termutil.ColourForeground, color.RGBA{R: 0xc0, G: 0xc0, B: 0xc0, A: 0xff}).
+		WithColour(termutil.ColourBackground, color.RGBA{R: 0x10, G: 0x10, B: 0x10, A: 0xff}).
+		WithColour(termutil.ColourRed, color.RGBA{R: 0xff, A: 0xff}).
+		WithColour(termutil.ColourBlue, color.RGBA{B: 0xff, A: 0xff}).
+		Build()
+}
+
+// screen returns the cells of a terminal which has processed the given output, with a row for each line of it
+func screen(output string) ([][]termutil.Cell, *termutil.Theme) {
+	theme := testTheme()
+	terminal := termutil.New(termutil.WithTheme(theme))
+	terminal.Resize(uint16(strings.Count(output, "\r\n")+1), 12)
+	terminal.Process([]byte(output))
+	return terminal.GetActiveBuffer().GetViewCells(), theme
+}
+
+func TestANSI(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"plain text is unchanged", `a<&>"b`, `a<&>"b`},
+		{"colours", "\x1b[31mred\x1b[0m ok", "\x1b[0;38;2;255;0;0mred\x1b[0m ok"},
+		{"styles", "\x1b[1;3;4;9mx", "\x1b[0;1;3;4;9mx\x1b[0m"},
+		{"dim", "\x1b[2mx", "\x1b[0;2mx\x1b[0m"},
+		{"default colours are left to the receiving terminal", "\x1b[39;49mx", "x"},
+		{"wide characters", "漢x", "漢x"},
+		{"gaps are filled with spaces", "a\x1b[3Cb", "a   b"},
+		{"coloured trailing blanks are kept", "x\x1b[44m  ", "x\x1b[0;48;2;0;0;255m  \x1b[0m"},
+		{"styles are reset at the end of each line", "\x1b[31ma\r\nb", "\x1b[0;38;2;255;0;0ma\x1b[0m\n\x1b[0;38;2;255;0;0mb\x1b[0m"},
+		{"blank lines are empty", "a\r\n", "a\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, theme := screen(test.output)
+			assert.Equal(t, test.expected, ANSI(lines, theme))
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	const prefix = `<meta charset="utf-8"><pre style="font-family: monospace; color: #c0c0c0; background-color: #101010; padding: 0.5em;">`
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"escaping", `a<&>"b'`, `a&lt;&amp;&gt;&#34;b&#39;`},
+		{"colours", "\x1b[31mred\x1b[0m ok", `<span style="color: #ff0000">red</span> ok`},
+		{"styles", "\x1b[1;3;4;9mx", `<span style="font-weight: bold; font-style: italic; text-decoration: underline line-through">x</span>`},
+		{"dim", "\x1b[2mx", `<span style="opacity: 0.5">x</span>`},
+		{"escaping in styled text", "\x1b[31m<b>", `<span style="color: #ff0000">&lt;b&gt;</span>`},
+		{"wide characters", "漢\x1b[31mx", `漢<span style="color: #ff0000">x</span>`},
+		{"coloured trailing blanks are kept", "x\x1b[44m  ", `x<span style="background-color: #0000ff">  </span>`},
+		{"lines", "a\r\nb", "a\nb"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, theme := screen(test.output)
+			assert.Equal(t, prefix+test.expected+"</pre>", HTML(lines, theme))
+		})
+	}
+}
+
+func TestHTMLDocument(t *testing.T) {
+	lines, theme := screen("\x1b[31m<x>")
+	document := HTMLDocument(lines, theme, "vim <main.go>")
+
+	assert.True(t, strings.HasPrefix(document, "<!DOCTYPE html>\n"))
+	assert.Contains(t, document, "<title>vim &lt;main.go&gt;</title>")
+	assert.Contains(t, document, `<body style="margin: 0; background-color: #101010;">`)
+	assert.Contains(t, document, `<span style="color: #ff0000">&lt;x&gt;</span></pre>`)
+	assert.NotContains(t, document, `<meta charset="utf-8"><pre`, "the fragment's charset is in the head instead")
+}
+
+func TestSVG(t *testing.T) {
+	options := SVGOptions{FontSize: 12, CellWidth: 8, CellHeight: 16, Ascent: 12, Columns: 12}
+	tests := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{
+			"escaping",
+			`a<&>"b`,
+			[]string{`<text x="0" y="12" fill="#c0c0c0" textLength="48" xml:space="preserve">a&lt;&amp;&gt;&#34;b</text>`},
+		},
+		{
+			"colours and styles",
+			"\x1b[1;3;31mred\x1b[0m",
+			[]string{`<text x="0" y="12" fill="#ff0000" textLength="24" xml:space="preserve" font-weight="bold" font-style="italic">red</text>`},
+		},
+		{
+			"decorations",
+			"\x1b[2;4;9mx",
+			[]string{`<text x="0" y="12" fill="#c0c0c0" textLength="8" xml:space="preserve" opacity="0.5" text-decoration="underline line-through">x</text>`},
+		},
+		{
+			"wide characters take two cells",
+			"漢\x1b[31mx",
+			[]string{
+				`<text x="0" y="12" fill="#c0c0c0" textLength="16" xml:space="preserve">漢</text>`,
+				`<text x="16" y="12" fill="#ff0000" textLength="8" xml:space="preserve">x</text>`,
+			},
+		},
+		{
+			"trailing blanks have a background but no text",
+			"x\x1b[44m  ",
+			[]string{
+				`<text x="0" y="12" fill="#c0c0c0" textLength="8" xml:space="preserve">x</text>`,
+				`<rect x="8" y="0" width="16" height="16" fill="#0000ff"/>`,
+			},
+		},
+		{
+			"lines",
+			"a\r\nb",
+			[]string{
+				`<text x="0" y="12" fill="#c0c0c0" textLength="8" xml:space="preserve">a</text>`,
+				`<text x="0" y="28" fill="#c0c0c0" textLength="8" xml:space="preserve">b</text>`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, theme := screen(test.output)
+			height := 16 * len(lines)
+			header := []string{
+				`<svg xmlns="http://www.w3.org/2000/svg" width="96" height="` + strconv.Itoa(height) + `" viewBox="0 0 96 ` + strconv.Itoa(height) + `" font-family="monospace" font-size="12">`,
+				`<rect width="100%" height="100%" fill="#101010"/>`,
+			}
+			expected := append(append(header, test.expected...), "</svg>", "")
+			assert.Equal(t, strings.Join(expected, "\n"), SVG(lines, theme, options))
+		})
+	}
+}
+
+func TestSVGFontFamilyIsEscaped(t *testing.T) {
+	lines, theme := screen("x")
+	svg := SVG(lines, theme, SVGOptions{FontFamily: `A&B "Mono"`, FontSize: 12, CellWidth: 8, CellHeight: 16, Columns: 12})
+	assert.Contains(t, svg, `font-family="&#39;A&amp;B &#34;Mono&#34;&#39;, monospace"`)
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"styles are dropped", "\x1b[31mred\x1b[0m <&>", "red <&>"},
+		{"wide characters", "漢x", "漢x"},
+		{"gaps are filled with spaces", "a\x1b[3Cb", "a   b"},
+		{"lines", "a\r\nb", "a\nb"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, _ := screen(test.output)
+			assert.Equal(t, test.expected, Text(lines))
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"text is fenced", "\x1b[31mred", "```\nred\n```\n"},
+		{"the fence is longer than any in the text", "```go", "````\n```go\n````\n"},
+		{"longer fences in the text", "a ```` b", "`````\na ```` b\n`````\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, _ := screen(test.output)
+			assert.Equal(t, test.expected, Markdown(lines))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"strings"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// HTMLDocument returns a self-contained HTML page showing the content of the given lines
+func HTMLDocument(lines [][]termutil.Cell, theme *termutil.Theme, title string) string {
+	return fmt.Sprintf(
+		"<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body style=\"margin: 0; background-color: %s;\">\n%s\n</body>\n</html>\n",
+		html.EscapeString(title),
+		hexColour(theme.DefaultBackground()),
+		pre(lines, theme),
+	)
+}
+
+// HTML returns the content of the given lines as a preformatted HTML fragment with inline colours and styles
+func HTML(lines [][]termutil.Cell, theme *termutil.Theme) string {
+	return `<meta charset="utf-8">` + pre(lines, theme)
+}
+
+func pre(lines [][]termutil.Cell, theme *termutil.Theme) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf(
+		`<pre style="font-family: monospace; color: %s; background-color: %s; padding: 0.5em;">`,
+		hexColour(theme.DefaultForeground()),
+		hexColour(theme.DefaultBackground()),
+	))
+	for i, line := range lines {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		for _, r := range runs(line, theme) {
+			text := html.EscapeString(r.text)
+			if r.style.isDefault() {
+				builder.WriteString(text)
+				continue
+			}
+			builder.WriteString(fmt.Sprintf(`<span style="%s">%s</span>`, css(r.style), text))
+		}
+	}
+	builder.WriteString("</pre>")
+	return builder.String()
+}
+
+func css(s style) string {
+	var rules []string
+	if s.fg != nil {
+		rules = append(rules, "color: "+hexColour(s.fg))
+	}
+	if s.bg != nil {
+		rules = append(rules, "background-color: "+hexColour(s.bg))
+	}
+	if s.bold {
+		rules = append(rules, "font-weight: bold")
+	}
+	if s.dim {
+		rules = append(rules, "opacity: 0.5")
+	}
+	if s.italic {
+		rules = append(rules, "font-style: italic")
+	}
+	var decorations []string
+	if s.underline {
+		decorations = append(decorations, "underline")
+	}
+	if s.strikethrough {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		rules = append(rules, "text-decoration: "+strings.Join(decorations, " "))
+	}
+	return strings.Join(rules, "; ")
+}
+
+func hexColour(c color.Color) string {
+	v := rgb(c)
+	return fmt.Sprintf("#%02x%02x%02x", v[0], v[1], v[2])
+}
@@ -0,0 +1,95 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+type SVGOptions struct {
+	FontFamily string
+	FontSize   float64
+	CellWidth  int
+	CellHeight int
+	Ascent     int // distance from the top of a cell to the text baseline
+	Columns    int
+}
+
+// SVG returns the given lines drawn as SVG text, with cells laid out on the same grid as the terminal
+func SVG(lines [][]termutil.Cell, theme *termutil.Theme, options SVGOptions) string {
+
+	family := "monospace"
+	if options.FontFamily != "" {
+		family = fmt.Sprintf("'%s', monospace", options.FontFamily)
+	}
+
+	width := options.Columns * options.CellWidth
+	height := len(lines) * options.CellHeight
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="%s" font-size="%g">`+"\n",
+		width, height, width, height, html.EscapeString(family), options.FontSize,
+	))
+	builder.WriteString(fmt.Sprintf(`<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColour(theme.DefaultBackground())))
+
+	for y, line := range lines {
+		var x int
+		pixelY := y * options.CellHeight
+		for _, r := range runs(line, theme) {
+			length := r.cells
+			pixelX := x * options.CellWidth
+			x += length
+
+			if r.style.bg != nil {
+				builder.WriteString(fmt.Sprintf(
+					`<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					pixelX, pixelY, length*options.CellWidth, options.CellHeight, hexColour(r.style.bg),
+				))
+			}
+
+			if strings.TrimSpace(r.text) == "" {
+				continue
+			}
+
+			fg := theme.DefaultForeground()
+			if r.style.fg != nil {
+				fg = r.style.fg
+			}
+
+			attributes := []string{
+				fmt.Sprintf(`x="%d"`, pixelX),
+				fmt.Sprintf(`y="%d"`, pixelY+options.Ascent),
+				fmt.Sprintf(`fill="%s"`, hexColour(fg)),
+				fmt.Sprintf(`textLength="%d"`, length*options.CellWidth),
+				`xml:space="preserve"`,
+			}
+			if r.style.bold {
+				attributes = append(attributes, `font-weight="bold"`)
+			}
+			if r.style.italic {
+				attributes = append(attributes, `font-style="italic"`)
+			}
+			if r.style.dim {
+				attributes = append(attributes, `opacity="0.5"`)
+			}
+			var decorations []string
+			if r.style.underline {
+				decorations = append(decorations, "underline")
+			}
+			if r.style.strikethrough {
+				decorations = append(decorations, "line-through")
+			}
+			if len(decorations) > 0 {
+				attributes = append(attributes, fmt.Sprintf(`text-decoration="%s"`, strings.Join(decorations, " ")))
+			}
+
+			builder.WriteString(fmt.Sprintf("<text %s>%s</text>\n", strings.Join(attributes, " "), html.EscapeString(r.text)))
+		}
+	}
+
+	builder.WriteString("</svg>\n")
+	return builder.String()
+}
@@ -0,0 +1,132 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/clipboard"
+	"github.com/liamg/darktile/internal/app/darktile/format"
+)
+
+type copyFormat uint8
+
+const (
+	copyFormatText copyFormat = iota
+	copyFormatHTML
+	copyFormatANSI
+	copyFormatMarkdown
+)
+
+// copySelection places the current selection on the clipboard in the given format
+func (g *GUI) copySelection(f copyFormat) {
+	lines, selection := g.terminal.GetActiveBuffer().GetSelectedCells()
+	if selection == nil {
+		return
+	}
+
+	var content clipboard.Content
+	var description string
+
+	switch f {
+	case copyFormatHTML:
+		content.Text = format.Text(lines)
+		content.HTML = format.HTML(lines, g.terminal.Theme())
+		description = "HTML"
+	case copyFormatANSI:
+		content.Text = format.ANSI(lines, g.terminal.Theme())
+		description = "ANSI"
+	case copyFormatMarkdown:
+		content.Text = format.Markdown(lines)
+		description = "Markdown"
+	default:
+		content.Text = format.Text(lines)
+	}
+
+	if err := clipboard.Set(clipboard.Clipboard, content); err != nil {
+		g.ShowError(fmt.Sprintf("Copy failed: %s", err))
+		return
+	}
+
+	if description != "" {
+		g.ShowMessage(fmt.Sprintf("Selection copied as %s", description))
+	}
+}
+
+// publishedSelection is selected text waiting to be offered as the primary selection
+type publishedSelection struct {
+	content      clipboard.Content
+	copyOnSelect bool
+}
+
+// publishSelection offers the current selection as the primary selection, and to the clipboard if copy-on-select is
+// enabled. Setting a selection can wait on the X server, so the text is copied out of the buffer and published from
+// watchSelection rather than holding up the game loop.
+func (g *GUI) publishSelection() {
+	text, selection := g.terminal.GetActiveBuffer().GetSelection()
+	if selection == nil || text == "" {
+		return
+	}
+
+	published := publishedSelection{
+		content:      clipboard.Content{Text: text},
+		copyOnSelect: g.copyOnSelect,
+	}
+
+	// only the latest selection is worth publishing, so one still waiting is replaced
+	for {
+		select {
+		case g.selectionChan <- published:
+			return
+		default:
+		}
+		select {
+		case <-g.selectionChan:
+		default:
+		}
+	}
+}
+
+// watchSelection publishes selections as they are made, reporting failures to be shown by the game loop
+func (g *GUI) watchSelection() {
+	for published := range g.selectionChan {
+		if err := clipboard.Set(clipboard.Primary, published.content); err != nil {
+			g.reportSelectionError(fmt.Sprintf("Failed to set primary selection: %s", err))
+			continue
+		}
+		if published.copyOnSelect {
+			if err := clipboard.Set(clipboard.Clipboard, published.content); err != nil {
+				g.reportSelectionError(fmt.Sprintf("Copy failed: %s", err))
+			}
+		}
+	}
+}
+
+func (g *GUI) reportSelectionError(msg string) {
+	select {
+	case g.selectionErrs <- msg:
+		ebiten.ScheduleFrame()
+	default:
+	}
+}
+
+// showSelectionErrors shows any failure to publish a selection since the last update
+func (g *GUI) showSelectionErrors() {
+	select {
+	case msg := <-g.selectionErrs:
+		g.ShowError(msg)
+	default:
+	}
+}
+
+// paste writes the content of the given selection to the terminal
+func (g *GUI) paste(selection clipboard.Selection) error {
+	text, err := clipboard.Get(selection)
+	if err != nil {
+		g.ShowError(fmt.Sprintf("Paste failed: %s", err))
+		return nil
+	}
+	if text == "" {
+		return nil
+	}
+	return g.terminal.Paste(text)
+}
@@ -0,0 +1,66 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// errWindowClosed is returned from Update to stop the game loop when the window has been closed
+var errWindowClosed = errors.New("window closed")
+
+// WithConfirmClose asks for confirmation before closing the window while a program other than the shell is
+// running in the foreground, unless the program is in the allowlist
+func WithConfirmClose(enable bool, allowlist []string) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.confirmClose = enable
+		g.closeAllowlist = make(map[string]bool, len(allowlist))
+		for _, name := range allowlist {
+			g.closeAllowlist[name] = true
+		}
+		return nil
+	}
+}
+
+// handleWindowClose is called when the user tries to close the window - it returns errWindowClosed if the
+// window should close, or shows a confirmation if a program is still running. The terminal must be locked.
+func (g *GUI) handleWindowClose() error {
+	if g.closeConfirmation != nil {
+		// closing the window again while the confirmation is shown confirms it
+		return errWindowClosed
+	}
+	if !g.confirmClose || g.supervisor.Exited() != nil {
+		return errWindowClosed
+	}
+	job, ok := g.terminal.ForegroundJob()
+	if !ok || g.closeAllowlist[job.Name] {
+		return errWindowClosed
+	}
+	g.closeConfirmation = &job
+	return nil
+}
+
+// handleCloseConfirmationInput handles key presses while the close confirmation is shown
+func (g *GUI) handleCloseConfirmationInput() error {
+	switch {
+	case g.keyState.RepeatPressed(ebiten.KeyEnter):
+		return errWindowClosed
+	case g.keyState.RepeatPressed(ebiten.KeyEscape):
+		g.closeConfirmation = nil
+	}
+	return nil
+}
+
+func closeConfirmationOverlay(process termutil.Process) popup.Message {
+	return popup.Message{
+		Text:       fmt.Sprintf("'%s' is still running (pid %d)\nPress Enter to close anyway or Escape to cancel", process.Name, process.PID),
+		Expiry:     time.Now().Add(time.Second),
+		Foreground: color.White,
+		Background: color.RGBA{A: 0xff, R: 0xa0, G: 0x60, B: 0x20},
+	}
+}
@@ -7,8 +7,19 @@ import (
 
 // Draw renders the terminal GUI to the ebtien window. Required to implement the ebiten interface.
 func (g *GUI) Draw(screen *ebiten.Image) {
+	popups := g.popupMessages
+	if status := g.supervisor.Exited(); status != nil {
+		popups = append(popups[:len(popups):len(popups)], exitOverlay(*status))
+	}
+	if g.closeConfirmation != nil {
+		popups = append(popups[:len(popups):len(popups)], closeConfirmationOverlay(*g.closeConfirmation))
+	}
+	if g.search != nil {
+		popups = append(popups[:len(popups):len(popups)], searchOverlay(*g.search, g.terminal.Theme()))
+	}
+
 	render.
-		New(screen, g.terminal, g.fontManager, g.popupMessages, g.opacity, g.enableLigatures, g.cursorImage).
+		New(screen, g.terminal, g.fontManager, popups, g.opacity, g.enableLigatures, g.cursorImage, g.colourOptions, g.glyphOptions, g.renderCache).
 		Draw()
 
 	if g.screenshotRequested {
@@ -0,0 +1,85 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/gui/hold"
+	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+func WithHoldMode(mode hold.Mode) func(g *GUI) error {
+	return func(g *GUI) error {
+		return g.supervisor.SetMode(mode)
+	}
+}
+
+// WithExitCodePropagation makes darktile exit with the same status as the program running in it
+func WithExitCodePropagation(enable bool) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.supervisor.SetPropagateExitCode(enable)
+		return nil
+	}
+}
+
+// runTerminal runs the program in the terminal until darktile exits, restarting it when requested
+func (g *GUI) runTerminal() {
+	run := func(size image.Point) (termutil.ExitStatus, error) {
+		return g.terminal.Run(g.updateChan, uint16(size.Y), uint16(size.X))
+	}
+	code, err := g.supervisor.Run(run, g.gridSize(), ebiten.ScheduleFrame)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Fatal error: %s", err)
+	}
+	os.Exit(code)
+}
+
+// gridSize returns the size of the window in cells
+func (g *GUI) gridSize() image.Point {
+	cellSize := g.fontManager.CharSize()
+	if cellSize.X == 0 || cellSize.Y == 0 {
+		return image.Point{X: 80, Y: 30}
+	}
+	return image.Point{X: g.size.X / cellSize.X, Y: g.size.Y / cellSize.Y}
+}
+
+// restart starts the program again after it has exited
+func (g *GUI) restart() {
+	if g.supervisor.Exited() == nil {
+		g.ShowMessage("The program is still running")
+		return
+	}
+
+	// start the program on a fresh line, leaving the previous output in place
+	_, _ = g.terminal.Write([]byte("\r\n"))
+	g.supervisor.Restart(g.gridSize())
+}
+
+// handleExitedInput handles key presses while the window is held open after the program has exited
+func (g *GUI) handleExitedInput(status termutil.ExitStatus) {
+	switch {
+	case g.keyState.RepeatPressed(ebiten.KeyEnter):
+		g.restart()
+	case g.keyState.RepeatPressed(ebiten.KeyEscape):
+		os.Exit(g.supervisor.ExitCode(status))
+	}
+}
+
+// exitOverlay describes how the program exited, for display until it is restarted or the window is closed
+func exitOverlay(status termutil.ExitStatus) popup.Message {
+	background := color.RGBA{A: 0xff, G: 0x60, R: 0x20, B: 0x20}
+	if !status.Success() {
+		background = color.RGBA{A: 0xff, R: 0xa0, G: 0x20, B: 0x20}
+	}
+	return popup.Message{
+		Text:       fmt.Sprintf("Process %s\nPress Enter to restart or Escape to close", status),
+		Expiry:     time.Now().Add(time.Second),
+		Foreground: color.White,
+		Background: background,
+	}
+}
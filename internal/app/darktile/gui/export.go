@@ -0,0 +1,87 @@
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/liamg/darktile/internal/app/darktile/format"
+)
+
+const (
+	ExportFormatHTML = "html"
+	ExportFormatSVG  = "svg"
+	ExportFormatANSI = "ans"
+)
+
+// RequestExport schedules a text export of the visible screen, or of the entire buffer including the scrollback.
+// The format is taken from the file extension, falling back to the configured export format.
+func (g *GUI) RequestExport(filename string, scrollback bool) {
+	if filename == "" {
+		filename = fmt.Sprintf("darktile-export-%d.%s", time.Now().UnixNano(), g.exportFormat)
+		targetdir, err := os.UserHomeDir()
+		if err != nil {
+			targetdir = "/tmp"
+		}
+		filename = filepath.Join(targetdir, filename)
+	}
+	g.exportFilename = filename
+	g.exportScrollback = scrollback
+	g.exportRequested = true
+}
+
+// export writes the requested export. The cells are copied while the terminal is locked, and formatted and written
+// to the file after it is unlocked, so that output isn't held up by a large scrollback or a slow disk.
+func (g *GUI) export() {
+	g.exportRequested = false
+
+	g.terminal.Lock()
+	buffer := g.terminal.GetActiveBuffer()
+	lines := buffer.GetViewCells()
+	if g.exportScrollback {
+		lines = buffer.GetAllCells()
+	}
+	columns := int(buffer.ViewWidth())
+	theme := g.terminal.Theme()
+	g.terminal.Unlock()
+
+	var output string
+	switch exportFormatFromFilename(g.exportFilename, g.exportFormat) {
+	case ExportFormatHTML:
+		output = format.HTMLDocument(lines, theme, g.terminal.GetTitle())
+	case ExportFormatSVG:
+		cellSize := g.fontManager.CharSize()
+		output = format.SVG(lines, theme, format.SVGOptions{
+			FontFamily: g.fontManager.Family(),
+			FontSize:   g.fontManager.Size() * g.fontManager.DPI() / 72,
+			CellWidth:  cellSize.X,
+			CellHeight: cellSize.Y,
+			Ascent:     g.fontManager.DotDepth(),
+			Columns:    columns,
+		})
+	default:
+		output = format.ANSI(lines, theme) + "\n"
+	}
+
+	if err := ioutil.WriteFile(g.exportFilename, []byte(output), 0600); err != nil {
+		g.ShowError(fmt.Sprintf("Export failed: %s", err))
+		return
+	}
+
+	g.ShowMessage(fmt.Sprintf("Export saved: %s", g.exportFilename))
+}
+
+func exportFormatFromFilename(filename string, fallback string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm":
+		return ExportFormatHTML
+	case ".svg":
+		return ExportFormatSVG
+	case ".ans", ".ansi":
+		return ExportFormatANSI
+	}
+	return fallback
+}
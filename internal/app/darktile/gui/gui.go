@@ -1,15 +1,19 @@
 package gui
 
 import (
-	"fmt"
 	"image"
 	"math/rand"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/gui/hold"
+	"github.com/liamg/darktile/internal/app/darktile/gui/keybinding"
 	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/gui/render"
 	"github.com/liamg/darktile/internal/app/darktile/hinters"
 	"github.com/liamg/darktile/internal/app/darktile/termutil"
 
@@ -37,11 +41,39 @@ type GUI struct {
 	popupMessages       []popup.Message
 	screenshotRequested bool
 	screenshotFilename  string
+	exportRequested     bool
+	exportFilename      string
+	exportScrollback    bool
+	exportFormat        string
 	startupFuncs        []func(g *GUI)
 	keyState            *keyState
 	opacity             float64
 	enableLigatures     bool
 	cursorImage         *ebiten.Image
+	colourOptions       render.ColourOptions
+	glyphOptions        render.GlyphOptions
+	renderCache         *render.Cache
+	wordMatcher         termutil.RuneMatcher
+	smartSelection      []*regexp.Regexp
+	delimitedSelection  []*regexp.Regexp
+	copyOnSelect        bool
+	keyBindings         []keybinding.Binding
+	defaultFontSize     float64
+	themeVariants       []ThemeVariant
+	themeVariant        string
+	supervisor          *hold.Supervisor
+	confirmClose        bool
+	closeAllowlist      map[string]bool
+	closeConfirmation   *termutil.Process
+	search              *search
+	manipulator         *WindowManipulator
+	titleTemplate       string
+	titleChan           chan struct{}
+	selectionChan       chan publishedSelection
+	selectionErrs       chan string
+	windowTitle         string
+	reloadMu            sync.Mutex
+	pendingReload       *reload
 }
 
 type MouseState uint8
@@ -61,47 +93,68 @@ func New(terminal *termutil.Terminal, options ...Option) (*GUI, error) {
 		activeHinter:    -1,
 		keyState:        newKeyState(),
 		enableLigatures: true,
+		wordMatcher:     termutil.WordMatcher(termutil.DefaultWordSeparators),
+		exportFormat:    ExportFormatHTML,
+		supervisor:      hold.NewSupervisor(),
+		confirmClose:    true,
+		titleChan:       make(chan struct{}, 1),
+		selectionChan:   make(chan publishedSelection, 1),
+		selectionErrs:   make(chan string, 1),
+		renderCache:     render.NewCache(),
 	}
 
+	g.keyBindings, _ = keybinding.Parse(nil)
+
 	for _, option := range options {
 		if err := option(g); err != nil {
 			return nil, err
 		}
 	}
 
-	terminal.SetWindowManipulator(NewManipulator(g))
+	if g.defaultFontSize == 0 {
+		g.defaultFontSize = g.fontManager.Size()
+	}
+
+	g.manipulator = NewManipulator(g)
+	terminal.SetWindowManipulator(g.manipulator)
 
 	return g, nil
 }
 
 func (g *GUI) Run() error {
 
-	go func() {
-		if err := g.terminal.Run(g.updateChan, uint16(g.size.X), uint16(g.size.Y)); err != nil {
-			fmt.Fprintf(os.Stderr, "Fatal error: %s", err)
-			os.Exit(1)
-		}
-		os.Exit(0)
-	}()
+	go g.runTerminal()
 
 	ebiten.SetScreenTransparent(true)
 	ebiten.SetScreenClearedEveryFrame(true)
 	ebiten.SetWindowResizable(true)
 	ebiten.SetRunnableOnUnfocused(true)
 	ebiten.SetFPSMode(ebiten.FPSModeVsyncOffMinimum)
+	ebiten.SetWindowClosingHandled(true)
 
 	for _, f := range g.startupFuncs {
 		go f(g)
 	}
 
 	go g.watchForUpdate()
+	go g.watchTitle()
+	go g.watchSelection()
 
-	return ebiten.RunGame(g)
+	if err := ebiten.RunGame(g); err != nil && err != errWindowClosed {
+		return err
+	}
+	// a program which exited while the window was held open decides the exit status when it is propagated
+	if code := g.supervisor.CloseCode(); code != 0 {
+		os.Exit(code)
+	}
+	return nil
 }
 
 func (g *GUI) watchForUpdate() {
 	for range g.updateChan {
 		ebiten.ScheduleFrame()
+		// output may have started or stopped a program, so the title template may need filling in again
+		g.requestTitleRefresh()
 		if g.keyState.AnythingPressed() {
 			go func() {
 				time.Sleep(time.Millisecond * 10)
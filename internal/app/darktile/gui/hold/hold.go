@@ -0,0 +1,137 @@
+// Package hold decides what happens when the program running in the terminal exits: whether the window is held
+// open so that the program can be restarted, and which status darktile exits with. It is kept apart from the GUI so
+// that it can be tested without a display.
+package hold
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// Mode controls whether the window stays open after the program running in it exits
+type Mode string
+
+const (
+	Never   Mode = "never"  // close the window as soon as the program exits
+	Always  Mode = "always" // keep the window open after the program exits
+	OnError Mode = "error"  // keep the window open if the program exits with a non-zero status or is killed
+)
+
+func (m Mode) Validate() error {
+	switch m {
+	case Never, Always, OnError:
+		return nil
+	}
+	return fmt.Errorf("unsupported hold mode '%s' - expected never, always or error", m)
+}
+
+// Supervisor runs the program in the terminal until darktile should exit, holding the window open and restarting
+// the program as configured. Its methods are safe to call from any goroutine.
+type Supervisor struct {
+	mu        sync.Mutex
+	mode      Mode
+	propagate bool
+	exited    *termutil.ExitStatus // the status of the program while the window is held open after it exited
+	restart   chan image.Point
+}
+
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		mode:    Never,
+		restart: make(chan image.Point, 1),
+	}
+}
+
+func (s *Supervisor) SetMode(mode Mode) error {
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode = mode
+	return nil
+}
+
+// SetPropagateExitCode makes darktile exit with the same status as the program running in it
+func (s *Supervisor) SetPropagateExitCode(enable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.propagate = enable
+}
+
+// Run starts the program with run, which returns once the program exits, at the given size in cells. While the
+// window is held open it waits for Restart, and calls run again at the size given. Run returns the code darktile
+// should exit with once the program exits without the window being held, and held is called each time it is.
+func (s *Supervisor) Run(run func(size image.Point) (termutil.ExitStatus, error), size image.Point, held func()) (int, error) {
+	for {
+		status, err := run(size)
+		if err != nil {
+			return 1, err
+		}
+
+		s.mu.Lock()
+		hold := s.shouldHold(status)
+		if hold {
+			s.exited = &status
+		}
+		s.mu.Unlock()
+		if !hold {
+			return s.ExitCode(status), nil
+		}
+
+		held()
+		size = <-s.restart
+	}
+}
+
+func (s *Supervisor) shouldHold(status termutil.ExitStatus) bool {
+	switch s.mode {
+	case Always:
+		return true
+	case OnError:
+		return !status.Success()
+	}
+	return false
+}
+
+// Exited returns the exit status of the program if it has exited and the window is being held open, or nil
+func (s *Supervisor) Exited() *termutil.ExitStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.exited
+}
+
+// Restart starts the program again at the given size after it has exited. It returns false if the program is
+// still running.
+func (s *Supervisor) Restart(size image.Point) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exited == nil {
+		return false
+	}
+	s.exited = nil
+	s.restart <- size
+	return true
+}
+
+// ExitCode returns the code darktile should exit with after the program exited with the given status
+func (s *Supervisor) ExitCode(status termutil.ExitStatus) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.propagate {
+		return status.ShellCode()
+	}
+	return 0
+}
+
+// CloseCode returns the code darktile should exit with when the window is closed. This is the status of the
+// program if it has exited while the window was held open and exit codes are propagated.
+func (s *Supervisor) CloseCode() int {
+	if status := s.Exited(); status != nil {
+		return s.ExitCode(*status)
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package hold
+
+import (
+	"errors"
+	"image"
+	"syscall"
+	"testing"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	exitedOK     = termutil.ExitStatus{}
+	exitedFailed = termutil.ExitStatus{Code: 3}
+	killed       = termutil.ExitStatus{Code: -1, Signal: syscall.SIGKILL}
+)
+
+// fakeProgram exits with each status in turn, recording the sizes it was started at
+type fakeProgram struct {
+	statuses []termutil.ExitStatus
+	sizes    []image.Point
+}
+
+func (p *fakeProgram) run(size image.Point) (termutil.ExitStatus, error) {
+	p.sizes = append(p.sizes, size)
+	status := p.statuses[0]
+	p.statuses = p.statuses[1:]
+	return status, nil
+}
+
+func newSupervisor(t *testing.T, mode Mode, propagate bool) *Supervisor {
+	s := NewSupervisor()
+	require.NoError(t, s.SetMode(mode))
+	s.SetPropagateExitCode(propagate)
+	return s
+}
+
+func TestValidateMode(t *testing.T) {
+	for _, mode := range []Mode{Never, Always, OnError} {
+		assert.NoError(t, mode.Validate())
+	}
+	assert.Error(t, Mode("sometimes").Validate())
+	assert.Error(t, NewSupervisor().SetMode("sometimes"))
+}
+
+func TestRunExitsWhenNotHeld(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      Mode
+		propagate bool
+		status    termutil.ExitStatus
+		code      int
+	}{
+		{"never held", Never, false, exitedFailed, 0},
+		{"never held and propagated", Never, true, exitedFailed, 3},
+		{"killed and propagated", Never, true, killed, 128 + int(syscall.SIGKILL)},
+		{"held only on error", OnError, true, exitedOK, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newSupervisor(t, test.mode, test.propagate)
+			program := &fakeProgram{statuses: []termutil.ExitStatus{test.status}}
+
+			code, err := s.Run(program.run, image.Pt(80, 24), func() { t.Fatal("the window should not be held") })
+			require.NoError(t, err)
+			assert.Equal(t, test.code, code)
+			assert.Nil(t, s.Exited())
+		})
+	}
+}
+
+func TestRunHoldsAndRestarts(t *testing.T) {
+	s := newSupervisor(t, OnError, true)
+	program := &fakeProgram{statuses: []termutil.ExitStatus{exitedFailed, killed, exitedOK}}
+
+	var held []termutil.ExitStatus
+	restartSizes := []image.Point{image.Pt(100, 30), image.Pt(120, 40)}
+	code, err := s.Run(program.run, image.Pt(80, 24), func() {
+		status := s.Exited()
+		require.NotNil(t, status)
+		held = append(held, *status)
+		assert.Equal(t, status.ShellCode(), s.CloseCode(), "closing the held window exits with the program's status")
+
+		assert.True(t, s.Restart(restartSizes[0]))
+		restartSizes = restartSizes[1:]
+		assert.Nil(t, s.Exited())
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []termutil.ExitStatus{exitedFailed, killed}, held)
+	assert.Equal(t, []image.Point{image.Pt(80, 24), image.Pt(100, 30), image.Pt(120, 40)}, program.sizes)
+}
+
+func TestRestartWhileRunning(t *testing.T) {
+	s := NewSupervisor()
+	assert.False(t, s.Restart(image.Pt(80, 24)))
+}
+
+func TestCloseCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		propagate bool
+		held      bool
+		code      int
+	}{
+		{"program running", true, false, 0},
+		{"held and propagated", true, true, 3},
+		{"held without propagation", false, true, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newSupervisor(t, Always, test.propagate)
+			if test.held {
+				s.exited = &exitedFailed
+			}
+			assert.Equal(t, test.code, s.CloseCode())
+		})
+	}
+}
+
+func TestRunReturnsErrorStartingProgram(t *testing.T) {
+	s := NewSupervisor()
+	failed := errors.New("no such program")
+	code, err := s.Run(func(image.Point) (termutil.ExitStatus, error) {
+		return termutil.ExitStatus{}, failed
+	}, image.Pt(80, 24), func() {})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, 1, code)
+}
@@ -3,7 +3,6 @@ package gui
 import (
 	"fmt"
 
-	"github.com/d-tsuji/clipboard"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -40,30 +39,38 @@ func (g *GUI) handleInput() error {
 	g.terminal.Lock()
 	defer g.terminal.Unlock()
 
+	if ebiten.IsWindowBeingClosed() {
+		if err := g.handleWindowClose(); err != nil {
+			return err
+		}
+	}
+
+	if g.closeConfirmation != nil {
+		return g.handleCloseConfirmationInput()
+	}
+
 	if err := g.handleMouse(); err != nil {
 		return err
 	}
 
+	if g.search != nil {
+		g.handleSearchInput()
+		return nil
+	}
+
+	if handled, err := g.handleKeyBindings(); handled || err != nil {
+		return err
+	}
+
+	if status := g.supervisor.Exited(); status != nil {
+		g.handleExitedInput(*status)
+		return nil
+	}
+
 	switch true {
 
 	case ebiten.IsKeyPressed(ebiten.KeyControl) && ebiten.IsKeyPressed(ebiten.KeyShift):
-
-		switch true {
-		case g.keyState.RepeatPressed(ebiten.KeyC):
-			content, selection := g.terminal.GetActiveBuffer().GetSelection()
-			if selection == nil {
-				return nil
-			}
-			return clipboard.Set(content)
-		case g.keyState.RepeatPressed(ebiten.KeyV):
-			paste, err := clipboard.Get()
-			if err != nil {
-				return err
-			}
-			return g.terminal.WriteToPty([]byte(paste))
-		case g.keyState.RepeatPressed(ebiten.KeyBracketLeft):
-			g.RequestScreenshot("")
-		}
+		// unbound ctrl+shift chords are reserved for key bindings
 
 	case ebiten.IsKeyPressed(ebiten.KeyControl):
 
@@ -77,26 +84,7 @@ func (g *GUI) handleInput() error {
 			}
 		}
 
-		switch true {
-		case g.keyState.RepeatPressed(ebiten.KeyMinus):
-			g.fontManager.DecreaseSize()
-			cellSize := g.fontManager.CharSize()
-			cols, rows := g.size.X/cellSize.X, g.size.Y/cellSize.Y
-			if err := g.terminal.SetSize(uint16(rows), uint16(cols)); err != nil {
-				return err
-			}
-			return nil
-		case g.keyState.RepeatPressed(ebiten.KeyEqual):
-			g.fontManager.IncreaseSize()
-			cellSize := g.fontManager.CharSize()
-			cols, rows := g.size.X/cellSize.X, g.size.Y/cellSize.Y
-			if err := g.terminal.SetSize(uint16(rows), uint16(cols)); err != nil {
-				return err
-			}
-			return nil
-		default:
-			return nil
-		}
+		return nil
 
 	case ebiten.IsKeyPressed(ebiten.KeyAlt):
 
@@ -0,0 +1,253 @@
+// Package keybinding parses the key chords and actions of key bindings. It is kept apart from the GUI, which maps
+// the key names to keyboard keys, so that bindings can be validated without a display.
+package keybinding
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Action string
+
+const (
+	ActionNone             Action = "none"
+	ActionCopy             Action = "copy"
+	ActionCopyHTML         Action = "copy-html"
+	ActionCopyANSI         Action = "copy-ansi"
+	ActionCopyMarkdown     Action = "copy-markdown"
+	ActionPaste            Action = "paste"
+	ActionPastePrimary     Action = "paste-primary"
+	ActionZoomIn           Action = "zoom-in"
+	ActionZoomOut          Action = "zoom-out"
+	ActionZoomReset        Action = "zoom-reset"
+	ActionScreenshot       Action = "screenshot"
+	ActionExportScreen     Action = "export-screen"
+	ActionExportScrollback Action = "export-scrollback"
+	ActionScrollPageUp     Action = "scroll-page-up"
+	ActionScrollPageDown   Action = "scroll-page-down"
+	ActionScrollToTop      Action = "scroll-to-top"
+	ActionScrollToBottom   Action = "scroll-to-bottom"
+	ActionClearScrollback  Action = "clear-scrollback"
+	ActionSendText         Action = "send-text" // takes the text to send as an argument, e.g. "send-text:ls\n"
+	ActionNextThemeVariant Action = "next-theme-variant"
+	ActionRestart          Action = "restart"
+	ActionThemeVariant     Action = "theme-variant" // takes the name of the variant as an argument, e.g. "theme-variant:light"
+	ActionSearch           Action = "search"
+)
+
+var actions = map[Action]bool{
+	ActionNone:             true,
+	ActionCopy:             true,
+	ActionCopyHTML:         true,
+	ActionCopyANSI:         true,
+	ActionCopyMarkdown:     true,
+	ActionPaste:            true,
+	ActionPastePrimary:     true,
+	ActionZoomIn:           true,
+	ActionZoomOut:          true,
+	ActionZoomReset:        true,
+	ActionScreenshot:       true,
+	ActionExportScreen:     true,
+	ActionExportScrollback: true,
+	ActionScrollPageUp:     true,
+	ActionScrollPageDown:   true,
+	ActionScrollToTop:      true,
+	ActionScrollToBottom:   true,
+	ActionClearScrollback:  true,
+	ActionSendText:         true,
+	ActionNextThemeVariant: true,
+	ActionRestart:          true,
+	ActionThemeVariant:     true,
+	ActionSearch:           true,
+}
+
+// Defaults maps key chords to actions - entries in the config file are applied on top of these
+var Defaults = map[string]string{
+	"ctrl+shift+c": string(ActionCopy),
+	"ctrl+shift+h": string(ActionCopyHTML),
+	"ctrl+shift+e": string(ActionCopyANSI),
+	"ctrl+shift+m": string(ActionCopyMarkdown),
+	"ctrl+shift+v": string(ActionPaste),
+	"ctrl+shift+[": string(ActionScreenshot),
+	"ctrl+shift+]": string(ActionExportScreen),
+	"ctrl+shift+s": string(ActionExportScrollback),
+	"ctrl+-":       string(ActionZoomOut),
+	"ctrl+=":       string(ActionZoomIn),
+}
+
+// Keys are the names of the keys which can be used in chords
+var Keys = []string{
+	"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m",
+	"n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z",
+	"0", "1", "2", "3", "4", "5", "6", "7", "8", "9",
+	"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12",
+	"-", "=", "[", "]", ";", "'", ",", ".", "/", "\\", "`",
+	"space", "enter", "tab", "escape", "backspace", "insert", "delete",
+	"home", "end", "pageup", "pagedown", "up", "down", "left", "right",
+}
+
+// keyAliases are other names for some of the keys
+var keyAliases = map[string]string{
+	"minus":        "-",
+	"equal":        "=",
+	"bracketleft":  "[",
+	"bracketright": "]",
+	"semicolon":    ";",
+	"quote":        "'",
+	"comma":        ",",
+	"period":       ".",
+	"slash":        "/",
+	"backslash":    "\\",
+	"backquote":    "`",
+	"return":       "enter",
+	"esc":          "escape",
+}
+
+var keys = func() map[string]bool {
+	names := make(map[string]bool, len(Keys))
+	for _, name := range Keys {
+		names[name] = true
+	}
+	return names
+}()
+
+// Chord is a key pressed while holding an exact set of modifiers. The key is one of Keys.
+type Chord struct {
+	Key     string
+	Control bool
+	Shift   bool
+	Alt     bool
+	Super   bool
+}
+
+type Binding struct {
+	Chord    Chord
+	Action   Action
+	Argument string
+}
+
+// Error describes a problem with the binding for a single chord in the config
+type Error struct {
+	Chord string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ParseChord parses a chord such as "ctrl+shift+c" - modifiers and key names are case-insensitive
+func ParseChord(input string) (Chord, error) {
+	var chord Chord
+
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(input)), "+")
+	// "ctrl++" - there is no dedicated + key, it is shift and = on most layouts
+	if len(parts) > 1 && parts[len(parts)-1] == "" && parts[len(parts)-2] == "" {
+		return chord, fmt.Errorf("invalid key chord '%s': the + key cannot be bound, use = with shift instead", input)
+	}
+
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i < len(parts)-1 {
+			switch part {
+			case "ctrl", "control":
+				chord.Control = true
+			case "shift":
+				chord.Shift = true
+			case "alt", "option":
+				chord.Alt = true
+			case "super", "meta", "cmd":
+				chord.Super = true
+			default:
+				return chord, fmt.Errorf("invalid key chord '%s': unknown modifier '%s'", input, part)
+			}
+			continue
+		}
+		if alias, ok := keyAliases[part]; ok {
+			part = alias
+		}
+		if !keys[part] {
+			return chord, fmt.Errorf("invalid key chord '%s': unknown key '%s'", input, part)
+		}
+		chord.Key = part
+	}
+
+	return chord, nil
+}
+
+func parseAction(input string) (Action, string, error) {
+	parts := strings.SplitN(input, ":", 2)
+	action := Action(strings.TrimSpace(parts[0]))
+	if !actions[action] {
+		return "", "", fmt.Errorf("unknown action '%s'", action)
+	}
+	var argument string
+	if len(parts) > 1 {
+		argument = parts[1]
+	}
+	if action == ActionSendText && argument == "" {
+		return "", "", fmt.Errorf("the %s action requires the text to send, e.g. '%s:hello'", action, action)
+	}
+	if action == ActionThemeVariant && argument == "" {
+		return "", "", fmt.Errorf("the %s action requires the name of a variant, e.g. '%s:light'", action, action)
+	}
+	return action, argument, nil
+}
+
+// Parse applies the given chord to action mappings on top of the defaults.
+// Invalid entries and chords which are bound more than once are reported and skipped, and
+// a chord mapped to "none" is removed entirely.
+func Parse(overrides map[string]string) ([]Binding, []error) {
+
+	var errs []error
+
+	bindings := make(map[Chord]Binding)
+	for chordStr, actionStr := range Defaults {
+		chord, _ := ParseChord(chordStr)
+		action, argument, _ := parseAction(actionStr)
+		bindings[chord] = Binding{Chord: chord, Action: action, Argument: argument}
+	}
+
+	// iterate in a stable order so that conflicts are always resolved the same way
+	var chordStrs []string
+	for chordStr := range overrides {
+		chordStrs = append(chordStrs, chordStr)
+	}
+	sort.Strings(chordStrs)
+
+	seen := make(map[Chord]string)
+	for _, chordStr := range chordStrs {
+		chord, err := ParseChord(chordStr)
+		if err != nil {
+			errs = append(errs, &Error{Chord: chordStr, Err: err})
+			continue
+		}
+		action, argument, err := parseAction(overrides[chordStr])
+		if err != nil {
+			errs = append(errs, &Error{Chord: chordStr, Err: fmt.Errorf("invalid key binding for '%s': %w", chordStr, err)})
+			continue
+		}
+		if previous, ok := seen[chord]; ok {
+			errs = append(errs, &Error{Chord: chordStr, Err: fmt.Errorf("key binding '%s' conflicts with '%s' and has been ignored", chordStr, previous)})
+			continue
+		}
+		seen[chord] = chordStr
+		if action == ActionNone {
+			delete(bindings, chord)
+			continue
+		}
+		bindings[chord] = Binding{Chord: chord, Action: action, Argument: argument}
+	}
+
+	output := make([]Binding, 0, len(bindings))
+	for _, binding := range bindings {
+		output = append(output, binding)
+	}
+
+	return output, errs
+}
@@ -0,0 +1,169 @@
+package keybinding
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// find returns the binding for a chord, if there is one
+func find(bindings []Binding, chord string) (Binding, bool) {
+	parsed, err := ParseChord(chord)
+	if err != nil {
+		return Binding{}, false
+	}
+	for _, binding := range bindings {
+		if binding.Chord == parsed {
+			return binding, true
+		}
+	}
+	return Binding{}, false
+}
+
+func TestParseChord(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Chord
+	}{
+		{"a", Chord{Key: "a"}},
+		{"ctrl+shift+c", Chord{Key: "c", Control: true, Shift: true}},
+		{"Shift+Ctrl+C", Chord{Key: "c", Control: true, Shift: true}},
+		{"control+alt+super+f5", Chord{Key: "f5", Control: true, Alt: true, Super: true}},
+		{"cmd+option+pageup", Chord{Key: "pageup", Alt: true, Super: true}},
+		{" ctrl + - ", Chord{Key: "-", Control: true}},
+		{"ctrl+minus", Chord{Key: "-", Control: true}},
+		{"ctrl+esc", Chord{Key: "escape", Control: true}},
+		{"alt+return", Chord{Key: "enter", Alt: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			chord, err := ParseChord(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, chord)
+		})
+	}
+}
+
+func TestParseBadChord(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"hyper+c", "unknown modifier 'hyper'"},
+		{"ctrl+shift", "unknown key 'shift'"},
+		{"ctrl+capslock", "unknown key 'capslock'"},
+		{"ctrl++", "the + key cannot be bound"},
+		{"", "unknown key ''"},
+		{"ctrl+", "unknown key ''"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := ParseChord(test.input)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.message)
+		})
+	}
+}
+
+func TestDefaultsAreValid(t *testing.T) {
+	bindings, errs := Parse(nil)
+	assert.Empty(t, errs)
+	assert.Len(t, bindings, len(Defaults))
+
+	for _, chord := range []string{"ctrl+0", "ctrl+shift+t", "ctrl+shift+f"} {
+		_, ok := find(bindings, chord)
+		assert.False(t, ok, "%s is left for programs running in the terminal", chord)
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	bindings, errs := Parse(map[string]string{
+		"ctrl+shift+c": "copy-html",
+		"ctrl+shift+v": "none",
+		"ctrl+0":       "zoom-reset",
+		"alt+l":        "send-text:ls -la\n",
+		"ctrl+shift+l": "theme-variant:light",
+	})
+	require.Empty(t, errs)
+
+	binding, ok := find(bindings, "ctrl+shift+c")
+	require.True(t, ok)
+	assert.Equal(t, ActionCopyHTML, binding.Action)
+
+	_, ok = find(bindings, "ctrl+shift+v")
+	assert.False(t, ok, "bindings to none are removed")
+
+	binding, ok = find(bindings, "ctrl+0")
+	require.True(t, ok)
+	assert.Equal(t, ActionZoomReset, binding.Action)
+
+	binding, ok = find(bindings, "alt+l")
+	require.True(t, ok)
+	assert.Equal(t, ActionSendText, binding.Action)
+	assert.Equal(t, "ls -la\n", binding.Argument)
+
+	binding, ok = find(bindings, "ctrl+shift+l")
+	require.True(t, ok)
+	assert.Equal(t, ActionThemeVariant, binding.Action)
+	assert.Equal(t, "light", binding.Argument)
+
+	// the remaining defaults are kept
+	binding, ok = find(bindings, "ctrl+=")
+	require.True(t, ok)
+	assert.Equal(t, ActionZoomIn, binding.Action)
+}
+
+func TestParseConflictingChords(t *testing.T) {
+	bindings, errs := Parse(map[string]string{
+		"ctrl+shift+c": "copy-html",
+		"shift+ctrl+c": "copy-ansi",
+		"ctrl+shift+j": "paste",
+		"Ctrl+Shift+J": "none",
+	})
+	require.Len(t, errs, 2)
+
+	// the chords are applied in sorted order, so the first of each pair wins
+	var bindingErr *Error
+	require.True(t, errors.As(errs[0], &bindingErr))
+	assert.Equal(t, "ctrl+shift+j", bindingErr.Chord)
+	assert.Contains(t, bindingErr.Error(), "conflicts with 'Ctrl+Shift+J'")
+	require.True(t, errors.As(errs[1], &bindingErr))
+	assert.Equal(t, "shift+ctrl+c", bindingErr.Chord)
+	assert.Contains(t, bindingErr.Error(), "conflicts with 'ctrl+shift+c'")
+
+	binding, ok := find(bindings, "ctrl+shift+c")
+	require.True(t, ok)
+	assert.Equal(t, ActionCopyHTML, binding.Action)
+	_, ok = find(bindings, "ctrl+shift+j")
+	assert.False(t, ok)
+}
+
+func TestParseInvalidBindings(t *testing.T) {
+	tests := []struct {
+		chord   string
+		action  string
+		message string
+	}{
+		{"hyper+c", "copy", "unknown modifier 'hyper'"},
+		{"ctrl++", "zoom-in", "the + key cannot be bound"},
+		{"ctrl+shift+k", "explode", "unknown action 'explode'"},
+		{"ctrl+shift+k", "send-text", "requires the text to send"},
+		{"ctrl+shift+k", "send-text:", "requires the text to send"},
+		{"ctrl+shift+k", "theme-variant", "requires the name of a variant"},
+	}
+	for _, test := range tests {
+		t.Run(test.chord+" "+test.action, func(t *testing.T) {
+			bindings, errs := Parse(map[string]string{test.chord: test.action})
+			require.Len(t, errs, 1)
+			var bindingErr *Error
+			require.True(t, errors.As(errs[0], &bindingErr))
+			assert.Equal(t, test.chord, bindingErr.Chord)
+			assert.Contains(t, bindingErr.Error(), test.message)
+
+			// the invalid binding is skipped and the defaults are kept
+			assert.Len(t, bindings, len(Defaults))
+		})
+	}
+}
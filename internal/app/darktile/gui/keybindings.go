@@ -0,0 +1,126 @@
+package gui
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/clipboard"
+	"github.com/liamg/darktile/internal/app/darktile/gui/keybinding"
+)
+
+var keys = map[string]ebiten.Key{
+	"a": ebiten.KeyA, "b": ebiten.KeyB, "c": ebiten.KeyC, "d": ebiten.KeyD, "e": ebiten.KeyE,
+	"f": ebiten.KeyF, "g": ebiten.KeyG, "h": ebiten.KeyH, "i": ebiten.KeyI, "j": ebiten.KeyJ,
+	"k": ebiten.KeyK, "l": ebiten.KeyL, "m": ebiten.KeyM, "n": ebiten.KeyN, "o": ebiten.KeyO,
+	"p": ebiten.KeyP, "q": ebiten.KeyQ, "r": ebiten.KeyR, "s": ebiten.KeyS, "t": ebiten.KeyT,
+	"u": ebiten.KeyU, "v": ebiten.KeyV, "w": ebiten.KeyW, "x": ebiten.KeyX, "y": ebiten.KeyY,
+	"z": ebiten.KeyZ,
+	"0": ebiten.KeyDigit0, "1": ebiten.KeyDigit1, "2": ebiten.KeyDigit2, "3": ebiten.KeyDigit3,
+	"4": ebiten.KeyDigit4, "5": ebiten.KeyDigit5, "6": ebiten.KeyDigit6, "7": ebiten.KeyDigit7,
+	"8": ebiten.KeyDigit8, "9": ebiten.KeyDigit9,
+	"f1": ebiten.KeyF1, "f2": ebiten.KeyF2, "f3": ebiten.KeyF3, "f4": ebiten.KeyF4,
+	"f5": ebiten.KeyF5, "f6": ebiten.KeyF6, "f7": ebiten.KeyF7, "f8": ebiten.KeyF8,
+	"f9": ebiten.KeyF9, "f10": ebiten.KeyF10, "f11": ebiten.KeyF11, "f12": ebiten.KeyF12,
+	"-":         ebiten.KeyMinus,
+	"=":         ebiten.KeyEqual,
+	"[":         ebiten.KeyBracketLeft,
+	"]":         ebiten.KeyBracketRight,
+	";":         ebiten.KeySemicolon,
+	"'":         ebiten.KeyQuote,
+	",":         ebiten.KeyComma,
+	".":         ebiten.KeyPeriod,
+	"/":         ebiten.KeySlash,
+	"\\":        ebiten.KeyBackslash,
+	"`":         ebiten.KeyBackquote,
+	"space":     ebiten.KeySpace,
+	"enter":     ebiten.KeyEnter,
+	"tab":       ebiten.KeyTab,
+	"escape":    ebiten.KeyEscape,
+	"backspace": ebiten.KeyBackspace,
+	"insert":    ebiten.KeyInsert,
+	"delete":    ebiten.KeyDelete,
+	"home":      ebiten.KeyHome,
+	"end":       ebiten.KeyEnd,
+	"pageup":    ebiten.KeyPageUp,
+	"pagedown":  ebiten.KeyPageDown,
+	"up":        ebiten.KeyArrowUp,
+	"down":      ebiten.KeyArrowDown,
+	"left":      ebiten.KeyArrowLeft,
+	"right":     ebiten.KeyArrowRight,
+}
+
+func modifiersPressed(chord keybinding.Chord) bool {
+	return ebiten.IsKeyPressed(ebiten.KeyControl) == chord.Control &&
+		ebiten.IsKeyPressed(ebiten.KeyShift) == chord.Shift &&
+		ebiten.IsKeyPressed(ebiten.KeyAlt) == chord.Alt &&
+		ebiten.IsKeyPressed(ebiten.KeyMeta) == chord.Super
+}
+
+func (g *GUI) handleKeyBindings() (bool, error) {
+	for _, binding := range g.keyBindings {
+		if !modifiersPressed(binding.Chord) || !g.keyState.RepeatPressed(keys[binding.Chord.Key]) {
+			continue
+		}
+		return true, g.runAction(binding.Action, binding.Argument)
+	}
+	return false, nil
+}
+
+func (g *GUI) runAction(action keybinding.Action, argument string) error {
+	buffer := g.terminal.GetActiveBuffer()
+	switch action {
+	case keybinding.ActionCopy:
+		g.copySelection(copyFormatText)
+	case keybinding.ActionCopyHTML:
+		g.copySelection(copyFormatHTML)
+	case keybinding.ActionCopyANSI:
+		g.copySelection(copyFormatANSI)
+	case keybinding.ActionCopyMarkdown:
+		g.copySelection(copyFormatMarkdown)
+	case keybinding.ActionPaste:
+		return g.paste(clipboard.Clipboard)
+	case keybinding.ActionPastePrimary:
+		return g.paste(clipboard.Primary)
+	case keybinding.ActionZoomIn:
+		g.fontManager.IncreaseSize()
+		return g.resizeToFont()
+	case keybinding.ActionZoomOut:
+		g.fontManager.DecreaseSize()
+		return g.resizeToFont()
+	case keybinding.ActionZoomReset:
+		g.fontManager.SetSize(g.defaultFontSize)
+		return g.resizeToFont()
+	case keybinding.ActionScreenshot:
+		g.RequestScreenshot("")
+	case keybinding.ActionExportScreen:
+		g.RequestExport("", false)
+	case keybinding.ActionExportScrollback:
+		g.RequestExport("", true)
+	case keybinding.ActionScrollPageUp:
+		buffer.ScrollUp(uint(buffer.ViewHeight()))
+	case keybinding.ActionScrollPageDown:
+		buffer.ScrollDown(uint(buffer.ViewHeight()))
+	case keybinding.ActionScrollToTop:
+		buffer.ScrollUp(uint(buffer.Height()))
+	case keybinding.ActionScrollToBottom:
+		buffer.ScrollToEnd()
+	case keybinding.ActionClearScrollback:
+		buffer.ClearScrollback()
+	case keybinding.ActionSendText:
+		return g.terminal.WriteToPty([]byte(argument))
+	case keybinding.ActionRestart:
+		g.restart()
+	case keybinding.ActionNextThemeVariant:
+		g.switchThemeVariant("")
+	case keybinding.ActionThemeVariant:
+		g.switchThemeVariant(argument)
+	case keybinding.ActionSearch:
+		g.startSearch()
+	}
+	return nil
+}
+
+// resizeToFont recalculates the terminal size in cells after the cell size has changed
+func (g *GUI) resizeToFont() error {
+	cellSize := g.fontManager.CharSize()
+	cols, rows := g.size.X/cellSize.X, g.size.Y/cellSize.Y
+	return g.terminal.SetSize(uint16(rows), uint16(cols))
+}
@@ -2,13 +2,13 @@ package gui
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/gui/title"
 	"github.com/liamg/darktile/internal/app/darktile/termutil"
 )
 
 type WindowManipulator struct {
-	g          *GUI
-	title      string
-	titleStack []string
+	g     *GUI
+	title title.Stack // read when refreshing the window title
 }
 
 func NewManipulator(g *GUI) *WindowManipulator {
@@ -30,27 +30,23 @@ func (m *WindowManipulator) Position() (int, int) {
 	return ebiten.WindowPosition()
 }
 
+// GetTitle returns the title set by the application, which may differ from the window title if a title template is used
 func (m *WindowManipulator) GetTitle() string {
-	return m.title
+	return m.title.Get()
 }
 
-func (m *WindowManipulator) SetTitle(title string) {
-	m.title = title
-	ebiten.SetWindowTitle(m.title)
+func (m *WindowManipulator) SetTitle(t string) {
+	m.title.Set(t)
+	m.g.requestTitleRefresh()
 }
 
 func (m *WindowManipulator) SaveTitleToStack() {
-	m.titleStack = append(m.titleStack, m.title)
+	m.title.Save()
 }
 
 func (m *WindowManipulator) RestoreTitleFromStack() {
-	if len(m.titleStack) == 0 {
-		m.SetTitle("")
-	}
-
-	title := m.titleStack[len(m.titleStack)-1]
-	m.titleStack = m.titleStack[:len(m.titleStack)-1]
-	m.SetTitle(title)
+	m.title.Restore()
+	m.g.requestTitleRefresh()
 }
 
 func (m *WindowManipulator) State() termutil.WindowState {
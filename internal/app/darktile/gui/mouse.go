@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/clipboard"
 	"github.com/liamg/darktile/internal/app/darktile/hinters"
 	"github.com/liamg/darktile/internal/app/darktile/termutil"
 )
@@ -50,6 +51,7 @@ func (g *GUI) handleMouse() error {
 	pressedLeft := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && g.mouseStateLeft != MouseStatePressed
 	pressedMiddle := ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) && g.mouseStateMiddle != MouseStatePressed
 	pressedRight := ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) && g.mouseStateRight != MouseStatePressed
+	releasedLeft := !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && g.mouseStateLeft == MouseStatePressed
 	released := (!ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && g.mouseStateLeft == MouseStatePressed) ||
 		(!ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) && g.mouseStateMiddle == MouseStatePressed) ||
 		(!ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) && g.mouseStateRight == MouseStatePressed)
@@ -80,6 +82,15 @@ func (g *GUI) handleMouse() error {
 		}
 	}
 
+	if pressedMiddle {
+		return g.paste(clipboard.Primary)
+	}
+
+	// a selection has been made by clicking or dragging
+	if releasedLeft {
+		g.publishSelection()
+	}
+
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		if g.mouseStateLeft == MouseStatePressed {
 
@@ -221,50 +232,28 @@ func (g *GUI) handleClick(clickCount, x, y int) (bool, error) {
 		}
 
 	case 2: //double click
-		col := uint16(x / g.fontManager.CharSize().X)
-		line := uint64(y / g.fontManager.CharSize().Y)
-		g.terminal.GetActiveBuffer().SelectWordAt(termutil.Position{Col: col, Line: line}, wordMatcher)
+		pos := g.cellPositionAt(x, y)
+		// smart selection rules take priority over plain word selection, and delimited rules such as quoted strings
+		// only apply when their quotes are clicked
+		buffer := g.terminal.GetActiveBuffer()
+		if !buffer.SelectPatternAt(pos, g.smartSelection...) && !buffer.SelectDelimitedAt(pos, g.delimitedSelection...) {
+			buffer.SelectWordAt(pos, g.wordMatcher)
+		}
 		return true, nil
 	default: // triple click (or more!)
-		g.terminal.GetActiveBuffer().ExtendSelectionToEntireLines()
+		g.terminal.GetActiveBuffer().SelectLineAt(g.cellPositionAt(x, y))
 		return true, nil
 	}
 
 	return false, nil
 }
 
-func alphaMatcher(r rune) bool {
-	if r >= 65 && r <= 90 {
-		return true
-	}
-	if r >= 97 && r <= 122 {
-		return true
-	}
-	return false
-}
-
-func numberMatcher(r rune) bool {
-	if r >= 48 && r <= 57 {
-		return true
-	}
-	return false
-}
-
-func alphaNumericMatcher(r rune) bool {
-	return alphaMatcher(r) || numberMatcher(r)
-}
-
-func wordMatcher(r rune) bool {
-
-	if alphaNumericMatcher(r) {
-		return true
-	}
-
-	if r == '_' {
-		return true
+// converts pixel coords to a cell position in view coords
+func (g *GUI) cellPositionAt(x, y int) termutil.Position {
+	return termutil.Position{
+		Col:  uint16(x / g.fontManager.CharSize().X),
+		Line: uint64(y / g.fontManager.CharSize().Y),
 	}
-
-	return false
 }
 
 func (g *GUI) handleMouseRemotely(x, y int, pressedLeft, pressedMiddle, pressedRight, released, moved bool) bool {
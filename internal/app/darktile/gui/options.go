@@ -1,9 +1,13 @@
 package gui
 
 import (
+	"fmt"
 	"image"
+	"regexp"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/gui/keybinding"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
 )
 
 type Option func(g *GUI) error
@@ -14,6 +18,28 @@ func WithFontFamily(family string) func(g *GUI) error {
 	}
 }
 
+// WithFontWeight sets the weight used for variable fonts, or zero for the font's default
+func WithFontWeight(weight float64) func(g *GUI) error {
+	return func(g *GUI) error {
+		return g.fontManager.SetWeight(weight)
+	}
+}
+
+// WithFontFeatures sets the OpenType features applied when text is shaped with ligatures enabled
+func WithFontFeatures(features []string) func(g *GUI) error {
+	return func(g *GUI) error {
+		return g.fontManager.SetFeatures(features)
+	}
+}
+
+// WithFontFallbacks sets the font families used for characters missing from the main font. When discover is
+// enabled, the installed fonts are searched for characters missing from all of them.
+func WithFontFallbacks(families []string, discover bool) func(g *GUI) error {
+	return func(g *GUI) error {
+		return g.fontManager.SetFallbackFamilies(families, discover)
+	}
+}
+
 func WithOpacity(opacity float64) func(g *GUI) error {
 	return func(g *GUI) error {
 		g.opacity = opacity
@@ -23,15 +49,17 @@ func WithOpacity(opacity float64) func(g *GUI) error {
 
 func WithFontSize(size float64) func(g *GUI) error {
 	return func(g *GUI) error {
-		g.fontManager.SetSize(size)
+		if err := g.fontManager.SetSize(size); err != nil {
+			return err
+		}
+		g.defaultFontSize = size
 		return nil
 	}
 }
 
 func WithFontDPI(dpi float64) func(g *GUI) error {
 	return func(g *GUI) error {
-		g.fontManager.SetSize(dpi)
-		return nil
+		return g.fontManager.SetDPI(dpi)
 	}
 }
 
@@ -42,6 +70,46 @@ func WithLigatures(enable bool) func(g *GUI) error {
 	}
 }
 
+// WithBoxDrawing draws box drawing, block, braille and Powerline characters procedurally so that they fill their
+// cells exactly and join up with their neighbours. A thickness of 0 scales lines with the font size.
+func WithBoxDrawing(enable bool, thickness int) func(g *GUI) error {
+	return func(g *GUI) error {
+		if thickness < 0 {
+			return fmt.Errorf("line thickness %d is out of range, must not be negative", thickness)
+		}
+		g.glyphOptions.Procedural = enable
+		g.glyphOptions.LineThickness = thickness
+		return nil
+	}
+}
+
+// WithMinimumContrast sets the WCAG contrast ratio which text is adjusted to reach against its background when drawn
+func WithMinimumContrast(ratio float64) func(g *GUI) error {
+	return func(g *GUI) error {
+		if ratio > 21 {
+			return fmt.Errorf("minimum contrast %g is out of range, must be at most 21", ratio)
+		}
+		g.colourOptions.MinimumContrast = ratio
+		return nil
+	}
+}
+
+// WithBoldIsBright draws bold text in bright colours using the regular font
+func WithBoldIsBright(enable bool) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.colourOptions.BoldIsBright = enable
+		return nil
+	}
+}
+
+// WithBoldInBrightColours draws bold text in bright colours as well as the bold font
+func WithBoldInBrightColours(enable bool) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.colourOptions.BoldInBrightColours = enable
+		return nil
+	}
+}
+
 func WithCursorImage(img image.Image) func(g *GUI) error {
 	return func(g *GUI) error {
 		g.cursorImage = ebiten.NewImageFromImage(img)
@@ -49,6 +117,49 @@ func WithCursorImage(img image.Image) func(g *GUI) error {
 	}
 }
 
+func WithWordSeparators(separators string) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.wordMatcher = termutil.WordMatcher(separators)
+		return nil
+	}
+}
+
+// WithSmartSelection sets the patterns which take priority over word selection on double-click, in order. The
+// delimited patterns are only used when the first or last character of a match is double-clicked.
+func WithSmartSelection(patterns []*regexp.Regexp, delimited []*regexp.Regexp) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.smartSelection = patterns
+		g.delimitedSelection = delimited
+		return nil
+	}
+}
+
+func WithCopyOnSelect(enable bool) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.copyOnSelect = enable
+		return nil
+	}
+}
+
+func WithExportFormat(format string) func(g *GUI) error {
+	return func(g *GUI) error {
+		switch format {
+		case ExportFormatHTML, ExportFormatSVG, ExportFormatANSI:
+		default:
+			return fmt.Errorf("unsupported export format '%s' - expected html, svg or ans", format)
+		}
+		g.exportFormat = format
+		return nil
+	}
+}
+
+func WithKeyBindings(bindings []keybinding.Binding) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.keyBindings = bindings
+		return nil
+	}
+}
+
 func WithStartupFunc(f func(g *GUI)) Option {
 	return func(g *GUI) error {
 		g.startupFuncs = append(g.startupFuncs, f)
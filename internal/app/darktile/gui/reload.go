@@ -0,0 +1,57 @@
+package gui
+
+type reload struct {
+	errs    []error
+	options []Option
+}
+
+// RequestReload schedules options to be applied at the start of the next update, along with any errors
+// encountered while loading them. A new theme is applied with WithThemeVariants. It is safe to call from
+// any goroutine.
+func (g *GUI) RequestReload(errs []error, options ...Option) {
+	g.reloadMu.Lock()
+	defer g.reloadMu.Unlock()
+	g.pendingReload = &reload{
+		errs:    errs,
+		options: options,
+	}
+}
+
+func (g *GUI) applyPendingReload() {
+	g.reloadMu.Lock()
+	pending := g.pendingReload
+	g.pendingReload = nil
+	g.reloadMu.Unlock()
+
+	if pending == nil {
+		return
+	}
+
+	for _, err := range pending.errs {
+		g.ShowError(err.Error())
+	}
+
+	g.terminal.Lock()
+	defer g.terminal.Unlock()
+
+	for _, option := range pending.options {
+		if err := option(g); err != nil {
+			g.ShowError(err.Error())
+		}
+	}
+
+	// options such as font features change how text is drawn without changing the font faces
+	g.renderCache.Invalidate()
+
+	cellSize := g.fontManager.CharSize()
+	if g.terminal.IsRunning() && cellSize.X > 0 && cellSize.Y > 0 {
+		if err := g.resizeToFont(); err != nil {
+			g.ShowError(err.Error())
+			return
+		}
+	}
+
+	if len(pending.errs) == 0 {
+		g.ShowMessage("Configuration reloaded")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
 )
 
 func (r *Render) drawAnnotation() {
@@ -56,6 +57,28 @@ func (r *Render) drawAnnotation() {
 		}
 	}
 
+	// underline the highlighted content if the theme has a colour for links
+	if linkColour, ok := r.theme.Colour(termutil.ColourLink); ok {
+		for line := int(highlightStart.Line); line <= int(highlightEnd.Line) && line < int(r.buffer.ViewHeight()); line++ {
+			startCol, endCol := 0, int(r.buffer.ViewWidth())-1
+			if line == int(highlightStart.Line) {
+				startCol = int(highlightStart.Col)
+			}
+			if line == int(highlightEnd.Line) {
+				endCol = int(highlightEnd.Col)
+			}
+			underlinePixelY := float64(line*r.font.CellSize.Y + (r.font.DotDepth+r.font.CellSize.Y)/2)
+			ebitenutil.DrawLine(
+				r.frame,
+				float64(startCol*r.font.CellSize.X),
+				underlinePixelY,
+				float64((endCol+1)*r.font.CellSize.X),
+				underlinePixelY,
+				linkColour,
+			)
+		}
+	}
+
 	// 3. annotate the highlighted area (if there is an annotation)
 	annotation := r.buffer.GetHighlightAnnotation()
 	if annotation == nil {
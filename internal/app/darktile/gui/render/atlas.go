@@ -0,0 +1,160 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	imagefont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	atlasPageSize = 1024 // width and height in pixels of each page of the atlas
+	maxAtlasPages = 4    // the atlas is emptied when this many pages are full
+)
+
+// glyphAtlas holds glyphs already drawn in their colour, packed into rows on shared pages. Each glyph is then
+// only rasterised once, and glyphs drawn from the same page can be batched together.
+type glyphAtlas struct {
+	pages     []*ebiten.Image
+	x, y      int // where the next glyph is placed on the last page
+	rowHeight int // height of the tallest glyph on the current row of the last page
+	glyphs    map[glyphKey]atlasGlyph
+}
+
+type glyphKey struct {
+	face   imagefont.Face // nil for colour glyphs, such as emoji
+	r      rune
+	colour color.RGBA64
+	cells  int // number of cells a colour glyph is fitted to
+}
+
+type atlasGlyph struct {
+	image  *ebiten.Image // nil for glyphs with nothing to draw, such as spaces
+	offset image.Point   // offset of the top left of the image from the glyph origin
+}
+
+// reset empties the atlas, keeping its pages for reuse
+func (a *glyphAtlas) reset() {
+	for _, page := range a.pages {
+		page.Clear()
+	}
+	a.pages = a.pages[:0:len(a.pages)]
+	a.x, a.y, a.rowHeight = 0, 0, 0
+	a.glyphs = nil
+}
+
+// draw draws a glyph from the face with its origin at the given pixel position
+func (a *glyphAtlas) draw(dst *ebiten.Image, face imagefont.Face, r rune, x int, y int, colour color.Color) {
+	cr, cg, cb, ca := colour.RGBA()
+	if ca == 0 {
+		return
+	}
+	key := glyphKey{
+		face:   face,
+		r:      r,
+		colour: color.RGBA64{R: uint16(cr), G: uint16(cg), B: uint16(cb), A: uint16(ca)},
+	}
+	glyph, ok := a.glyphs[key]
+	if !ok {
+		glyph = a.add(face, r, key.colour)
+		a.store(key, glyph)
+	}
+	glyph.draw(dst, x, y)
+}
+
+// drawColour draws the colour glyph of a rune, such as an emoji, fitted to the given number of cells with its top
+// left at the given pixel position. It returns false if the rune has no colour glyph, so should be drawn as text.
+func (a *glyphAtlas) drawColour(dst *ebiten.Image, fontManager *font.Manager, r rune, cells int, x int, y int, colour color.Color) bool {
+	cr, cg, cb, ca := colour.RGBA()
+	if ca == 0 {
+		return true
+	}
+	key := glyphKey{
+		r:      r,
+		colour: color.RGBA64{R: uint16(cr), G: uint16(cg), B: uint16(cb), A: uint16(ca)},
+		cells:  cells,
+	}
+	glyph, ok := a.glyphs[key]
+	if !ok {
+		pixels := fontManager.ColourGlyph(r, cells, colour)
+		if pixels == nil {
+			return false
+		}
+		glyph = a.place(pixels, image.Point{})
+		a.store(key, glyph)
+	}
+	glyph.draw(dst, x, y)
+	return true
+}
+
+func (a *glyphAtlas) store(key glyphKey, glyph atlasGlyph) {
+	if a.glyphs == nil {
+		a.glyphs = make(map[glyphKey]atlasGlyph)
+	}
+	a.glyphs[key] = glyph
+}
+
+func (glyph atlasGlyph) draw(dst *ebiten.Image, x int, y int) {
+	if glyph.image == nil {
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x+glyph.offset.X), float64(y+glyph.offset.Y))
+	dst.DrawImage(glyph.image, op)
+}
+
+// add rasterises a glyph in the given colour and places it on a page
+func (a *glyphAtlas) add(face imagefont.Face, r rune, colour color.Color) atlasGlyph {
+	dr, mask, maskp, _, ok := face.Glyph(fixed.Point26_6{}, r)
+	if !ok || dr.Empty() {
+		return atlasGlyph{}
+	}
+
+	pixels := image.NewRGBA(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	draw.DrawMask(pixels, pixels.Bounds(), image.NewUniform(colour), image.Point{}, mask, maskp, draw.Over)
+
+	return a.place(pixels, dr.Min)
+}
+
+// place copies the pixels of a glyph onto a page, with the offset of their top left from the glyph origin
+func (a *glyphAtlas) place(pixels *image.RGBA, offset image.Point) atlasGlyph {
+
+	// glyphs which can't fit on a page, at very large font sizes, get their own image
+	w, h := pixels.Bounds().Dx(), pixels.Bounds().Dy()
+	if w > atlasPageSize || h > atlasPageSize {
+		return atlasGlyph{image: ebiten.NewImageFromImage(pixels), offset: offset}
+	}
+
+	// glyphs are separated by a pixel so that neighbouring glyphs can't bleed into each other when drawn
+	if a.x+w > atlasPageSize {
+		a.x = 0
+		a.y += a.rowHeight + 1
+		a.rowHeight = 0
+	}
+	if len(a.pages) == 0 || a.y+h > atlasPageSize {
+		if len(a.pages) == maxAtlasPages {
+			a.reset()
+		}
+		if len(a.pages) < cap(a.pages) {
+			a.pages = a.pages[:len(a.pages)+1]
+		} else {
+			a.pages = append(a.pages, ebiten.NewImage(atlasPageSize, atlasPageSize))
+		}
+		a.x, a.y, a.rowHeight = 0, 0, 0
+	}
+
+	page := a.pages[len(a.pages)-1]
+	bounds := image.Rect(a.x, a.y, a.x+w, a.y+h)
+	img := page.SubImage(bounds).(*ebiten.Image)
+	img.ReplacePixels(pixels.Pix)
+
+	a.x += w + 1
+	if h > a.rowHeight {
+		a.rowHeight = h
+	}
+	return atlasGlyph{image: img, offset: offset}
+}
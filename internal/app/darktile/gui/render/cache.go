@@ -0,0 +1,61 @@
+package render
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	imagefont "golang.org/x/image/font"
+)
+
+// Cache holds what has been drawn between frames, so that each frame only redraws the rows which have changed
+// rather than every cell. It is owned by the GUI and passed to New for each frame.
+type Cache struct {
+	content  *ebiten.Image // the rows of the terminal, without the cursor, selection or popups
+	frame    *ebiten.Image // the content with everything else drawn over it
+	damage   termutil.Damage
+	settings cacheSettings
+	cursor   cursorState
+	atlas    glyphAtlas
+}
+
+// cacheSettings are everything other than the rows themselves which changes how the content is drawn
+type cacheSettings struct {
+	size      image.Point
+	cellSize  image.Point
+	faces     [4]imagefont.Face
+	theme     *termutil.Theme
+	ligatures bool
+	colours   ColourOptions
+	glyphs    GlyphOptions
+}
+
+// cursorState is where the cursor was when the content was drawn, as runs of text are broken at the cursor
+type cursorState struct {
+	visible bool
+	row     uint16
+	col     uint16
+}
+
+func NewCache() *Cache {
+	return &Cache{}
+}
+
+// Invalidate causes everything to be redrawn on the next frame, e.g. after the config is reloaded
+func (c *Cache) Invalidate() {
+	c.settings = cacheSettings{}
+}
+
+// images returns the content and frame images, replacing them if the size of the screen has changed
+func (c *Cache) images(w int, h int) (content *ebiten.Image, frame *ebiten.Image) {
+	if c.content != nil {
+		if cw, ch := c.content.Size(); cw == w && ch == h {
+			return c.content, c.frame
+		}
+		c.content.Dispose()
+		c.frame.Dispose()
+	}
+	c.content = ebiten.NewImage(w, h)
+	c.frame = ebiten.NewImage(w, h)
+	return c.content, c.frame
+}
@@ -0,0 +1,99 @@
+//go:build display
+
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/gui/render/software"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// compareTolerance is the largest difference allowed in each channel of a pixel between the two renderers,
+	// which blend antialiased glyph edges slightly differently
+	compareTolerance = 32
+	// compareMaxDiffering is the fraction of pixels allowed to differ by more than compareTolerance, which is only
+	// ever the odd pixel at the edge of a glyph
+	compareMaxDiffering = 0.002
+)
+
+// TestSoftwareRendererMatchesGUI draws the same terminal with this package and with the software renderer, and checks
+// that they draw the same image
+func TestSoftwareRendererMatchesGUI(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		popups []popup.Message
+	}{
+		{name: "text", output: "plain \x1b[1mbold\x1b[0m \x1b[3mitalic\x1b[0m \x1b[2mdim\x1b[0m\r\n" +
+			"\x1b[4munderline\x1b[0m \x1b[9mstrike\x1b[0m \x1b[7minverse\x1b[0m\r\n" +
+			"\x1b[31mred \x1b[32mgreen \x1b[34mblue \x1b[93mbright\x1b[0m\r\n" +
+			"\x1b[38;5;208m256 \x1b[38;2;10;200;250mtruecolour\x1b[0m \x1b[44mback\x1b[0m"},
+		{name: "ligatures", output: "a -> b != c <= d === e"},
+		{name: "wide characters", output: "\x1b[4ma界b\x1b[0m\r\n界x"},
+		{name: "box drawing", output: "┌──┬──┐ ░▒▓\r\n│ab│╳╳│ ▀▄█\r\n├──┼──┤ \r\n╰──┴──╯ ⣿⠁⢕"},
+		{name: "popup", output: "copied", popups: []popup.Message{{
+			Text:       "Copied ééé",
+			Expiry:     time.Now().Add(time.Minute),
+			Foreground: color.White,
+			Background: color.RGBA{R: 0x33, G: 0x33, B: 0x99, A: 0xff},
+		}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the cursor is hidden as the GUI draws it depending on whether the window has focus
+			term := newTestTerminal(t, 6, 30, "\x1b[?25l"+test.output)
+			fontManager := newTestFontManager(t)
+
+			screen := newTestScreen(term, fontManager)
+			screen.Clear()
+			New(screen, term, fontManager, test.popups, 1, true, nil, ColourOptions{}, GlyphOptions{Procedural: true}, NewCache()).Draw()
+			gui := pixels(screen)
+
+			soft := image.NewRGBA(gui.Bounds())
+			software.Draw(soft, term, fontManager, software.Options{
+				Ligatures: true,
+				Glyphs:    software.GlyphOptions{Procedural: true},
+				Popups:    test.popups,
+			})
+
+			assertSimilar(t, gui, soft)
+		})
+	}
+}
+
+func assertSimilar(t *testing.T, expected, actual *image.RGBA) {
+	require.Equal(t, expected.Bounds(), actual.Bounds())
+
+	var differences int
+	var first string
+	bounds := actual.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			e, a := expected.RGBAAt(x, y), actual.RGBAAt(x, y)
+			if channelDiff(e.R, a.R) > compareTolerance || channelDiff(e.G, a.G) > compareTolerance ||
+				channelDiff(e.B, a.B) > compareTolerance || channelDiff(e.A, a.A) > compareTolerance {
+				if differences == 0 {
+					first = fmt.Sprintf("(%d,%d) GUI drew %v, software renderer drew %v", x, y, e, a)
+				}
+				differences++
+			}
+		}
+	}
+	if limit := int(float64(bounds.Dx()*bounds.Dy()) * compareMaxDiffering); differences > limit {
+		t.Errorf("%d pixels differ between the renderers, more than the %d allowed, starting at %s", differences, limit, first)
+	}
+}
+
+func channelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -1,10 +1,64 @@
 package render
 
+import (
+	"image"
+
+	"github.com/liamg/darktile/internal/app/darktile/gui/render/software"
+	imagefont "golang.org/x/image/font"
+)
+
+// drawContent draws the rows which have changed since they were last drawn onto the cached content, or every row
+// if anything else which affects how they are drawn has changed
 func (r *Render) drawContent() {
-	// draw base content for each row
-	defBg := r.theme.DefaultBackground()
-	defFg := r.theme.DefaultForeground()
-	for viewY := int(r.buffer.ViewHeight() - 1); viewY >= 0; viewY-- {
-		r.drawRow(viewY, defBg, defFg)
+	settings := cacheSettings{
+		size:      image.Point{X: r.pixelWidth, Y: r.pixelHeight},
+		cellSize:  r.font.CellSize,
+		faces:     [4]imagefont.Face{r.font.Regular, r.font.Bold, r.font.Italic, r.font.BoldItalic},
+		theme:     r.theme,
+		ligatures: r.enableLigatures,
+		colours:   r.colours,
+		glyphs:    r.glyphs,
+	}
+	if settings != r.cache.settings {
+		r.cache.settings = settings
+		r.cache.damage.Invalidate()
+		r.cache.atlas.reset()
+		r.content.Fill(r.theme.DefaultBackground())
+	}
+
+	height := int(r.buffer.ViewHeight())
+	dirty := make([]bool, height)
+	mark := func(viewY int) {
+		if viewY >= 0 && viewY < height {
+			dirty[viewY] = true
+		}
+	}
+
+	// glyphs can overhang the rows above and below their own, so those rows are redrawn too
+	for _, viewY := range r.cache.damage.Collect(r.buffer) {
+		mark(int(viewY) - 1)
+		mark(int(viewY))
+		mark(int(viewY) + 1)
+	}
+
+	// runs of text are broken at the cursor, so the rows it has moved from and to are redrawn
+	cursor := cursorState{
+		visible: r.buffer.IsCursorVisible(),
+		row:     r.buffer.CursorLine(),
+		col:     r.buffer.CursorColumn(),
+	}
+	if cursor != r.cache.cursor {
+		mark(int(r.cache.cursor.row))
+		mark(int(cursor.row))
+		r.cache.cursor = cursor
+	}
+
+	// rows are drawn from the bottom up, so that glyphs overhanging the row below are drawn over it
+	canvas := r.canvas(r.content)
+	options := r.rowOptions()
+	for viewY := height - 1; viewY >= 0; viewY-- {
+		if dirty[viewY] {
+			software.DrawRow(canvas, r.buffer, viewY, options)
+		}
 	}
 }
@@ -3,7 +3,8 @@ package render
 import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/gui/render/software"
 	"github.com/liamg/darktile/internal/app/darktile/termutil"
 )
 
@@ -17,18 +18,18 @@ func (r *Render) drawCursor() {
 	pixelY := float64(int(r.buffer.CursorLine()) * r.font.CellSize.Y)
 	cell := r.buffer.GetCell(r.buffer.CursorColumn(), r.buffer.CursorLine())
 
-	useFace := r.font.Regular
+	style := font.Regular
 	if cell != nil {
-		if cell.Bold() && cell.Italic() {
-			useFace = r.font.BoldItalic
-		} else if cell.Bold() {
-			useFace = r.font.Bold
-		} else if cell.Italic() {
-			useFace = r.font.Italic
-		}
+		style = software.CellStyle(cell, r.colours)
+	}
+
+	// the cursor covers both cells of a wide character
+	cells := 1
+	if cell != nil && cell.Wide() {
+		cells = 2
 	}
 
-	pixelW, pixelH := float64(r.font.CellSize.X), float64(r.font.CellSize.Y)
+	pixelW, pixelH := float64(r.font.CellSize.X*cells), float64(r.font.CellSize.Y)
 
 	// empty rect without focus
 	if !ebiten.IsFocused() {
@@ -61,7 +62,7 @@ func (r *Render) drawCursor() {
 
 		// we've drawn over the cell contents, so we need to draw it again in the cursor colours
 		if cell != nil && cell.Rune().Rune > 0 {
-			text.Draw(r.frame, string(cell.Rune().Rune), useFace, int(pixelX), int(pixelY)+r.font.DotDepth, r.theme.CursorForeground())
+			software.DrawCell(r.canvas(r.frame), cell, r.buffer.CursorColumn(), int(r.buffer.CursorLine()), style, r.theme.CursorForeground(), r.rowOptions())
 		}
 	}
 }
@@ -0,0 +1,91 @@
+//go:build display
+
+package render
+
+import (
+	"errors"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/config"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+// The tests of this package draw with ebiten, which needs a display, so they are only built with the display tag:
+//
+//	xvfb-run go test -tags display ./internal/app/darktile/gui/render/
+//
+// They run inside the game loop, where images can be drawn to and read back.
+
+var errTestsDone = errors.New("tests done")
+
+type testGame struct {
+	m    *testing.M
+	code int
+}
+
+func (g *testGame) Update() error {
+	g.code = g.m.Run()
+	return errTestsDone
+}
+
+func (g *testGame) Draw(*ebiten.Image) {}
+
+func (g *testGame) Layout(int, int) (int, int) {
+	return 320, 240
+}
+
+func TestMain(m *testing.M) {
+	g := &testGame{m: m}
+	if err := ebiten.RunGame(g); err != nil && err != errTestsDone {
+		panic(err)
+	}
+	os.Exit(g.code)
+}
+
+func newTestTerminal(t testing.TB, rows, cols uint16, output string) *termutil.Terminal {
+	theme, err := config.DefaultTheme(config.DefaultConfig())
+	require.NoError(t, err)
+	term := termutil.New(termutil.WithTheme(theme))
+	term.Resize(rows, cols)
+	term.Process([]byte(output))
+	return term
+}
+
+func newTestFontManager(t testing.TB) *font.Manager {
+	m := font.NewManager()
+	require.NoError(t, m.SetSize(14))
+	require.NoError(t, m.SetFontData("Go Mono", gomono.TTF))
+	return m
+}
+
+// newTestScreen returns an image which fits the view of the terminal
+func newTestScreen(term *termutil.Terminal, fontManager *font.Manager) *ebiten.Image {
+	buffer := term.GetActiveBuffer()
+	size := fontManager.CharSize()
+	return ebiten.NewImage(size.X*int(buffer.ViewWidth()), size.Y*int(buffer.ViewHeight()))
+}
+
+// drawFrame draws a frame as the GUI does, and waits for it to be drawn by reading a pixel back
+func drawFrame(screen *ebiten.Image, term *termutil.Terminal, fontManager *font.Manager, cache *Cache) {
+	screen.Clear()
+	New(screen, term, fontManager, nil, 1, true, nil, ColourOptions{}, GlyphOptions{}, cache).Draw()
+	_ = screen.At(0, 0)
+}
+
+// pixels copies the pixels of an ebiten image
+func pixels(img *ebiten.Image) *image.RGBA {
+	w, h := img.Size()
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			rgba.Set(x, y, img.At(x, y))
+		}
+	}
+	return rgba
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/gui/render/software"
+)
+
+// GlyphOptions control how characters which need to join up with their neighbours are drawn
+type GlyphOptions = software.GlyphOptions
+
+type proceduralKey struct {
+	r         rune
+	size      image.Point
+	thickness int
+}
+
+// maxProceduralImages is the number of procedurally drawn characters which are kept before the cache is cleared
+const maxProceduralImages = 1024
+
+// proceduralImages holds procedurally drawn characters as white masks, which are tinted when drawn
+var proceduralImages = map[proceduralKey]*ebiten.Image{}
+
+// drawProcedural draws a box drawing, block, braille or Powerline character to fill the cell at the given pixel
+// position, returning false if the rune should be drawn from the font instead
+func (r *Render) drawProcedural(dst *ebiten.Image, ru rune, pixelX int, pixelY int, colour color.Color) bool {
+	if !r.glyphs.Procedural || !font.IsProcedural(ru) {
+		return false
+	}
+
+	key := proceduralKey{r: ru, size: r.font.CellSize, thickness: r.glyphs.LineThickness}
+	img, ok := proceduralImages[key]
+	if !ok {
+		mask, ok := font.ProceduralGlyph(ru, r.font.CellSize, r.glyphs.LineThickness)
+		if !ok {
+			return false
+		}
+		if len(proceduralImages) >= maxProceduralImages {
+			for k, existing := range proceduralImages {
+				existing.Dispose()
+				delete(proceduralImages, k)
+			}
+		}
+		img = ebiten.NewImageFromImage(mask)
+		proceduralImages[key] = img
+	}
+
+	cr, cg, cb, ca := colour.RGBA()
+	if ca == 0 {
+		return true
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(pixelX), float64(pixelY))
+	op.ColorM.Scale(float64(cr)/float64(ca), float64(cg)/float64(ca), float64(cb)/float64(ca), float64(ca)/0xffff)
+	dst.DrawImage(img, op)
+	return true
+}
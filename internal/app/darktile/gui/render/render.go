@@ -6,12 +6,15 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/liamg/darktile/internal/app/darktile/font"
 	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/gui/render/software"
 	"github.com/liamg/darktile/internal/app/darktile/termutil"
 	imagefont "golang.org/x/image/font"
 )
 
 type Render struct {
 	frame           *ebiten.Image
+	content         *ebiten.Image
+	cache           *Cache
 	screen          *ebiten.Image
 	terminal        *termutil.Terminal
 	buffer          *termutil.Buffer
@@ -24,8 +27,13 @@ type Render struct {
 	popups          []popup.Message
 	enableLigatures bool
 	cursorImage     *ebiten.Image
+	colours         ColourOptions
+	glyphs          GlyphOptions
 }
 
+// ColourOptions control how the stored colours of cells are adjusted when they are drawn
+type ColourOptions = software.ColourOptions
+
 type Font struct {
 	Regular    imagefont.Face
 	Bold       imagefont.Face
@@ -35,11 +43,14 @@ type Font struct {
 	DotDepth   int
 }
 
-func New(screen *ebiten.Image, terminal *termutil.Terminal, fontManager *font.Manager, popups []popup.Message, opacity float64, enableLigatures bool, cursorImage *ebiten.Image) *Render {
+func New(screen *ebiten.Image, terminal *termutil.Terminal, fontManager *font.Manager, popups []popup.Message, opacity float64, enableLigatures bool, cursorImage *ebiten.Image, colours ColourOptions, glyphs GlyphOptions, cache *Cache) *Render {
 	w, h := screen.Size()
+	content, frame := cache.images(w, h)
 	return &Render{
 		screen:      screen,
-		frame:       ebiten.NewImage(w, h),
+		frame:       frame,
+		content:     content,
+		cache:       cache,
 		terminal:    terminal,
 		buffer:      terminal.GetActiveBuffer(),
 		theme:       terminal.Theme(),
@@ -58,6 +69,8 @@ func New(screen *ebiten.Image, terminal *termutil.Terminal, fontManager *font.Ma
 		popups:          popups,
 		enableLigatures: enableLigatures,
 		cursorImage:     cursorImage,
+		colours:         colours,
+		glyphs:          glyphs,
 	}
 }
 
@@ -65,34 +78,36 @@ func (r *Render) Draw() {
 	r.terminal.Lock()
 	defer r.terminal.Unlock()
 
-	// 1. fill frame with default background colour
-	r.frame.Fill(r.theme.DefaultBackground())
-
-	// 2. draw content (each row, each cell)
+	// 1. redraw the rows which have changed since the last frame
 	r.drawContent()
 
+	// 2. start the frame from the content (each row, each cell)
+	r.frame.DrawImage(r.content, &ebiten.DrawImageOptions{CompositeMode: ebiten.CompositeModeCopy})
+
 	// 3. draw cursor
 	r.drawCursor()
 
 	// // 4. draw sixels
 	r.drawSixels()
 
-	// // 5. draw selection
+	// // 5. draw search matches
+	r.drawSearchMatches()
+
+	// // 6. draw selection
 	r.drawSelection()
 
-	// // 6. draw highlight/annotations
+	// // 7. draw highlight/annotations
 	r.drawAnnotation()
 
-	// // 7. draw popups
+	// // 8. draw popups
 	r.drawPopups()
 
-	// // 8. apply effects (e.g. transparency)
+	// // 9. apply effects (e.g. transparency)
 	r.finalise()
 
 }
 
 func (r *Render) finalise() {
-	defer r.frame.Dispose()
 	opt := &ebiten.DrawImageOptions{}
 	opt.ColorM.Scale(1, 1, 1, r.opacity)
 	r.screen.DrawImage(r.frame, opt)
@@ -0,0 +1,82 @@
+//go:build display
+
+package render
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// benchmarkOutput is a line of output with a mix of styles and colours, as a build or log would write
+const benchmarkOutput = "\x1b[32mok\x1b[0m   github.com/liamg/darktile/\x1b[1minternal\x1b[0m \x1b[38;5;244m(cached)\x1b[0m " +
+	"\x1b[31merror:\x1b[0m \x1b[4mexpected\x1b[0m 3 got 4\r\n"
+
+func newBenchmarkFrame(b *testing.B) (*termutil.Terminal, *font.Manager, *ebiten.Image, *Cache) {
+	term := newTestTerminal(b, 60, 200, "")
+	for i := 0; i < 60; i++ {
+		term.Process([]byte(benchmarkOutput))
+	}
+	fontManager := newTestFontManager(b)
+	screen := newTestScreen(term, fontManager)
+	cache := NewCache()
+	drawFrame(screen, term, fontManager, cache)
+	return term, fontManager, screen, cache
+}
+
+// BenchmarkRenderIdle measures a frame where nothing has changed, which only copies the cached rows to the screen.
+// Compare it with BenchmarkRenderFullRedraw.
+func BenchmarkRenderIdle(b *testing.B) {
+	term, fontManager, screen, cache := newBenchmarkFrame(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		drawFrame(screen, term, fontManager, cache)
+	}
+}
+
+// BenchmarkRenderScrolling measures a frame after a line of output has scrolled the view. Every row has moved, so
+// this is the worst case for the cache short of a full redraw.
+func BenchmarkRenderScrolling(b *testing.B) {
+	term, fontManager, screen, cache := newBenchmarkFrame(b)
+	output := []byte(benchmarkOutput)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		term.Process(output)
+		drawFrame(screen, term, fontManager, cache)
+	}
+}
+
+// BenchmarkRenderTyping measures a frame after a character has been typed at a prompt, which redraws the rows
+// around the cursor
+func BenchmarkRenderTyping(b *testing.B) {
+	term, fontManager, screen, cache := newBenchmarkFrame(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%100 == 0 {
+			term.Process([]byte("\r\n$ "))
+		}
+		term.Process([]byte("x"))
+		drawFrame(screen, term, fontManager, cache)
+	}
+}
+
+// BenchmarkRenderFullRedraw measures a frame where every row is drawn again, as every frame was before rows were
+// cached, for comparison with the benchmarks above
+func BenchmarkRenderFullRedraw(b *testing.B) {
+	term, fontManager, screen, cache := newBenchmarkFrame(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Invalidate()
+		drawFrame(screen, term, fontManager, cache)
+	}
+}
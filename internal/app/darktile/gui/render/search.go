@@ -0,0 +1,36 @@
+package render
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+func (r *Render) drawSearchMatches() {
+	matches, current := r.buffer.GetViewSearchMatches()
+
+	for i, match := range matches {
+		bg, fg := r.theme.SearchMatchBackground(), r.theme.SearchMatchForeground()
+		if i == current {
+			// the current match is drawn in reverse so that it stands out from the others
+			bg, fg = fg, bg
+		}
+		for y := match.Start.Line; y <= match.End.Line; y++ {
+			xStart, xEnd := 0, int(r.buffer.ViewWidth())-1
+			if y == match.Start.Line {
+				xStart = int(match.Start.Col)
+			}
+			if y == match.End.Line {
+				xEnd = int(match.End.Col)
+			}
+			for x := xStart; x <= xEnd; x++ {
+				pX, pY := float64(x*r.font.CellSize.X), float64(y*uint64(r.font.CellSize.Y))
+				ebitenutil.DrawRect(r.frame, pX, pY, float64(r.font.CellSize.X), float64(r.font.CellSize.Y), bg)
+				cell := r.buffer.GetCell(uint16(x), uint16(y))
+				if cell == nil || cell.Rune().Rune == 0 {
+					continue
+				}
+				text.Draw(r.frame, string(cell.Rune().Rune), r.font.Regular, int(pX), int(pY)+r.font.DotDepth, fg)
+			}
+		}
+	}
+}
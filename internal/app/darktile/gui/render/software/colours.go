@@ -0,0 +1,52 @@
+package software
+
+import (
+	"image/color"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// ForegroundColour returns the colour to draw the cell content in. The stored colour is adjusted for bold and
+// dim text and to reach the minimum contrast against the cell background, without modifying the cell.
+func ForegroundColour(theme *termutil.Theme, cell *termutil.Cell, defaultForegroundColour color.Color, defaultBackgroundColour color.Color, options ColourOptions) color.Color {
+	colour := styledColour(theme, cell, defaultForegroundColour, options)
+	if options.MinimumContrast <= 1 {
+		return colour
+	}
+	background := theme.Resolve(cell.Bg())
+	if background == nil {
+		background = defaultBackgroundColour
+	}
+	return termutil.EnsureContrast(colour, background, options.MinimumContrast)
+}
+
+// styledColour applies the theme's bold and dim colours to text in the default foreground colour, and
+// brightens bold text in one of the 8 normal ANSI colours if required
+func styledColour(theme *termutil.Theme, cell *termutil.Cell, defaultForegroundColour color.Color, options ColourOptions) color.Color {
+	colour := theme.Resolve(cell.Fg())
+	if colour != nil && !SameColour(colour, defaultForegroundColour) {
+		if cell.Bold() && (options.BoldIsBright || options.BoldInBrightColours) {
+			if bright, ok := theme.BrightVersion(cell.Fg()); ok {
+				return bright
+			}
+		}
+		return colour
+	}
+	if cell.Bold() {
+		if bold, ok := theme.Colour(termutil.ColourBold); ok {
+			return bold
+		}
+	} else if cell.Dim() {
+		if dim, ok := theme.Colour(termutil.ColourDim); ok {
+			return dim
+		}
+	}
+	return defaultForegroundColour
+}
+
+// SameColour returns true if the colours are identical once converted to RGBA
+func SameColour(a color.Color, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
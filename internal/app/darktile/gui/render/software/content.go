@@ -0,0 +1,81 @@
+package software
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	imagefont "golang.org/x/image/font"
+)
+
+func (r *renderer) drawContent() {
+	// draw base content for each row
+	canvas := r.canvas(r.frame)
+	options := r.rowOptions()
+	for viewY := int(r.buffer.ViewHeight() - 1); viewY >= 0; viewY-- {
+		DrawRow(canvas, r.buffer, viewY, options)
+	}
+}
+
+func (r *renderer) rowOptions() RowOptions {
+	return RowOptions{
+		Theme:       r.theme,
+		FontManager: r.fontManager,
+		CellSize:    r.cellSize,
+		DotDepth:    r.dotDepth,
+		Width:       r.pixelWidth,
+		Ligatures:   r.options.Ligatures,
+		Colours:     r.options.Colours,
+		Glyphs:      r.options.Glyphs,
+	}
+}
+
+// imageCanvas rasterises rows onto an image on the CPU
+type imageCanvas struct {
+	dst         draw.Image
+	fontManager *font.Manager
+	cellSize    image.Point
+	glyphs      GlyphOptions
+}
+
+func (r *renderer) canvas(dst draw.Image) *imageCanvas {
+	return &imageCanvas{dst: dst, fontManager: r.fontManager, cellSize: r.cellSize, glyphs: r.options.Glyphs}
+}
+
+func (c *imageCanvas) FillRect(x, y, width, height float64, colour color.Color) {
+	drawRect(c.dst, x, y, width, height, colour)
+}
+
+func (c *imageCanvas) DrawLine(x1, y1, x2, y2 float64, colour color.Color) {
+	drawLine(c.dst, x1, y1, x2, y2, colour)
+}
+
+func (c *imageCanvas) DrawGlyph(face imagefont.Face, r rune, x, y int, colour color.Color) {
+	drawGlyph(c.dst, face, r, x, y, colour)
+}
+
+func (c *imageCanvas) DrawColourGlyph(r rune, cells int, x, y int, colour color.Color) bool {
+	if _, _, _, a := colour.RGBA(); a == 0 {
+		return true
+	}
+	glyph := c.fontManager.ColourGlyph(r, cells, colour)
+	if glyph == nil {
+		return false
+	}
+	draw.Draw(c.dst, glyph.Bounds().Add(image.Pt(x, y)), glyph, image.Point{}, draw.Over)
+	return true
+}
+
+func (c *imageCanvas) DrawProcedural(r rune, x, y int, colour color.Color) bool {
+	if !c.glyphs.Procedural || !font.IsProcedural(r) {
+		return false
+	}
+	mask, ok := font.ProceduralGlyph(r, c.cellSize, c.glyphs.LineThickness)
+	if !ok {
+		return false
+	}
+	bounds := image.Rect(x, y, x+c.cellSize.X, y+c.cellSize.Y)
+	draw.DrawMask(c.dst, bounds, image.NewUniform(colour), image.Point{}, mask, image.Point{}, draw.Over)
+	return true
+}
@@ -0,0 +1,67 @@
+package software
+
+import (
+	"image"
+
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	xdraw "golang.org/x/image/draw"
+)
+
+func (r *renderer) drawCursor() {
+	//draw cursor
+	if !r.buffer.IsCursorVisible() {
+		return
+	}
+
+	pixelX := float64(int(r.buffer.CursorColumn()) * r.cellSize.X)
+	pixelY := float64(int(r.buffer.CursorLine()) * r.cellSize.Y)
+	cell := r.buffer.GetCell(r.buffer.CursorColumn(), r.buffer.CursorLine())
+
+	style := font.Regular
+	if cell != nil {
+		style = CellStyle(cell, r.options.Colours)
+	}
+
+	// the cursor covers both cells of a wide character
+	cells := 1
+	if cell != nil && cell.Wide() {
+		cells = 2
+	}
+
+	pixelW, pixelH := float64(r.cellSize.X*cells), float64(r.cellSize.Y)
+
+	// empty rect without focus
+	if !r.options.Focused {
+		drawRect(r.frame, pixelX, pixelY, pixelW, pixelH, r.theme.CursorBackground())
+		drawRect(r.frame, pixelX+1, pixelY+1, pixelW-2, pixelH-2, r.theme.CursorForeground())
+		return
+	}
+
+	// draw the cursor shape
+	switch r.buffer.GetCursorShape() {
+	case termutil.CursorShapeBlinkingBar, termutil.CursorShapeSteadyBar:
+		drawRect(r.frame, pixelX, pixelY, 2, pixelH, r.theme.CursorBackground())
+	case termutil.CursorShapeBlinkingUnderline, termutil.CursorShapeSteadyUnderline:
+		drawRect(r.frame, pixelX, pixelY+pixelH-2, pixelW, 2, r.theme.CursorBackground())
+	default:
+		// draw a custom cursor if we have one and there are no characters in the way
+		if r.options.CursorImage != nil && (cell == nil || cell.Rune().Rune == 0) {
+			bounds := r.options.CursorImage.Bounds()
+			ratio := 1 / (float64(bounds.Dy()) / float64(r.cellSize.Y))
+			actualHeight := float64(bounds.Dy()) * ratio
+			offsetY := (float64(r.cellSize.Y) - actualHeight) / 2
+			x, y := int(pixelX), int(pixelY+offsetY)
+			target := image.Rect(x, y, x+int(float64(bounds.Dx())*ratio), y+int(actualHeight))
+			xdraw.NearestNeighbor.Scale(r.frame, target, r.options.CursorImage, bounds, xdraw.Over, nil)
+			return
+		}
+
+		drawRect(r.frame, pixelX, pixelY, pixelW, pixelH, r.theme.CursorBackground())
+
+		// we've drawn over the cell contents, so we need to draw it again in the cursor colours
+		if cell != nil && cell.Rune().Rune > 0 {
+			DrawCell(r.canvas(r.frame), cell, r.buffer.CursorColumn(), int(r.buffer.CursorLine()), style, r.theme.CursorForeground(), r.rowOptions())
+		}
+	}
+}
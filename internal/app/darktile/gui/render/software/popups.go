@@ -0,0 +1,60 @@
+package software
+
+import (
+	"strings"
+
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+func (r *renderer) drawPopups() {
+	DrawPopups(r.canvas(r.frame), r.options.Popups, r.buffer.ViewWidth(), r.buffer.ViewHeight(), r.rowOptions())
+}
+
+// DrawPopups draws popup messages stacked up from the bottom left of the view, each in a box sized to its widest
+// line in cells
+func DrawPopups(c Canvas, popups []popup.Message, viewWidth, viewHeight uint16, options RowOptions) {
+
+	if len(popups) == 0 {
+		return
+	}
+
+	cellSize := options.CellSize
+	pad := cellSize.Y / 2 // horizontal and vertical padding
+	maxPixelX := float64(cellSize.X * int(viewWidth))
+	maxPixelY := float64(cellSize.Y * int(viewHeight))
+
+	for _, msg := range popups {
+
+		lines := strings.Split(msg.Text, "\n")
+		var cells int
+		for _, line := range lines {
+			if width := termutil.StringWidth(line); width > cells {
+				cells = width
+			}
+		}
+
+		msgX := pad
+		msgY := maxPixelY - float64(pad*3) - float64(cellSize.Y*len(lines))
+		boxWidth := float64(pad*2) + float64(cellSize.X*cells)
+		boxHeight := float64(pad*2) + float64(cellSize.Y*len(lines))
+
+		if boxWidth < maxPixelX/8 {
+			boxWidth = maxPixelX / 8
+		}
+
+		c.FillRect(float64(msgX-1), msgY-1, boxWidth+2, boxHeight+2, msg.Foreground)
+		c.FillRect(float64(msgX), msgY, boxWidth, boxHeight, msg.Background)
+		for y, line := range lines {
+			x := 0
+			for _, ru := range line {
+				face := options.FontManager.Face(font.Regular, ru)
+				c.DrawGlyph(face, ru, msgX+pad+(x*cellSize.X), pad+(y*cellSize.Y)+int(msgY)+options.DotDepth, msg.Foreground)
+				x += termutil.RuneWidth(ru)
+			}
+		}
+		maxPixelY = maxPixelY - float64(pad*4) - float64(len(lines)*cellSize.Y)
+	}
+
+}
@@ -0,0 +1,52 @@
+package software
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	imagefont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// pixelSpan returns the pixels from start to end which have their centres within the span, matching how the GPU
+// fills shapes with fractional coordinates
+func pixelSpan(start float64, length float64) (int, int) {
+	return int(math.Ceil(start - 0.5)), int(math.Ceil(start + length - 0.5))
+}
+
+// drawRect fills a rectangle, in the same way as ebitenutil.DrawRect
+func drawRect(dst draw.Image, x, y, width, height float64, colour color.Color) {
+	minX, maxX := pixelSpan(x, width)
+	minY, maxY := pixelSpan(y, height)
+	draw.Draw(dst, image.Rect(minX, minY, maxX, maxY), image.NewUniform(colour), image.Point{}, draw.Over)
+}
+
+// drawLine draws a one pixel wide horizontal or vertical line, in the same way as ebitenutil.DrawLine. Vertical
+// lines are drawn to the left of x.
+func drawLine(dst draw.Image, x1, y1, x2, y2 float64, colour color.Color) {
+	if x1 == x2 {
+		drawRect(dst, x1-1, y1, 1, y2-y1, colour)
+		return
+	}
+	drawRect(dst, x1, y1, x2-x1, 1, colour)
+}
+
+// drawImage draws an image with its top left at the given position
+func drawImage(dst draw.Image, src image.Image, x, y int) {
+	bounds := src.Bounds()
+	draw.Draw(dst, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), src, bounds.Min, draw.Over)
+}
+
+// drawGlyph draws the glyph for a rune with its origin at the given position, in the same way as text.Draw
+func drawGlyph(dst draw.Image, face imagefont.Face, r rune, x, y int, colour color.Color) {
+	if _, _, _, a := colour.RGBA(); a == 0 {
+		return
+	}
+	dr, mask, maskp, _, ok := face.Glyph(fixed.P(x, y), r)
+	if !ok || dr.Empty() {
+		return
+	}
+	draw.DrawMask(dst, dr, image.NewUniform(colour), image.Point{}, mask, maskp, draw.Over)
+}
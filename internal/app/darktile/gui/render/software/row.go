@@ -0,0 +1,227 @@
+package software
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	imagefont "golang.org/x/image/font"
+)
+
+// Canvas is what rows of the terminal are drawn onto. Rows are laid out in the same way for this package and the
+// GUI, which only differ in how they rasterise shapes and glyphs.
+type Canvas interface {
+	FillRect(x, y, width, height float64, colour color.Color)
+	// DrawLine draws a one pixel wide horizontal line
+	DrawLine(x1, y1, x2, y2 float64, colour color.Color)
+	// DrawGlyph draws the glyph for a rune from the face with its origin at the given pixel position
+	DrawGlyph(face imagefont.Face, r rune, x, y int, colour color.Color)
+	// DrawColourGlyph draws the colour glyph of a rune, such as an emoji, fitted to the given number of cells with
+	// its top left at the given pixel position. It returns false if the rune has no colour glyph.
+	DrawColourGlyph(r rune, cells int, x, y int, colour color.Color) bool
+	// DrawProcedural draws a box drawing, block, braille or Powerline character to fill the cell at the given
+	// pixel position, returning false if the rune should be drawn from the font instead
+	DrawProcedural(r rune, x, y int, colour color.Color) bool
+}
+
+// RowOptions are everything other than the cells which decides how a row is drawn
+type RowOptions struct {
+	Theme       *termutil.Theme
+	FontManager *font.Manager
+	CellSize    image.Point
+	DotDepth    int
+	Width       int // width of the row in pixels, which may be more than its cells cover
+	Ligatures   bool
+	Colours     ColourOptions
+	Glyphs      GlyphOptions
+}
+
+// DrawRow draws the backgrounds and text of a row of the view of the buffer
+func DrawRow(c Canvas, buffer *termutil.Buffer, viewY int, options RowOptions) {
+
+	defaultBackgroundColour := options.Theme.DefaultBackground()
+	defaultForegroundColour := options.Theme.DefaultForeground()
+	pixelY := options.CellSize.Y * viewY
+
+	// draw a default colour background across the entire row background
+	c.FillRect(0, float64(pixelY), float64(options.Width), float64(options.CellSize.Y), defaultBackgroundColour)
+
+	var colour color.Color
+
+	// draw background for each cell in row - cells past the end of the line have the default background
+	for viewX := uint16(0); viewX < buffer.ViewWidth(); viewX++ {
+		cell := buffer.GetCell(viewX, uint16(viewY))
+		pixelX := options.CellSize.X * int(viewX)
+		colour = nil
+		if cell != nil {
+			colour = options.Theme.Resolve(cell.Bg())
+		}
+		if colour == nil {
+			colour = defaultBackgroundColour
+		}
+		c.FillRect(float64(pixelX), float64(pixelY), float64(options.CellSize.X), float64(options.CellSize.Y), colour)
+	}
+
+	// draw text content of each cell in row, in runs of cells which share a style and colour
+	run := textRun{canvas: c, options: &options, pixelY: pixelY}
+	cursorOnRow := buffer.IsCursorVisible() && int(buffer.CursorLine()) == viewY
+	for viewX := uint16(0); viewX < buffer.ViewWidth(); viewX++ {
+
+		cell := buffer.GetCell(viewX, uint16(viewY))
+
+		// we don't need to draw empty cells
+		if cell == nil || cell.Rune().Rune == 0 {
+			run.draw()
+			continue
+		}
+		colour = ForegroundColour(options.Theme, cell, defaultForegroundColour, defaultBackgroundColour, options.Colours)
+		style := CellStyle(cell, options.Colours)
+		pixelX := options.CellSize.X * int(viewX)
+
+		// wide characters cover the cell after their own too
+		cells := 1
+		if cell.Wide() {
+			cells = 2
+		}
+		cellWidth := options.CellSize.X * cells
+
+		// underline the cell content if required
+		if cell.Underline() {
+			underlinePixelY := float64(pixelY + (options.DotDepth+options.CellSize.Y)/2)
+			c.DrawLine(float64(pixelX), underlinePixelY, float64(pixelX+cellWidth), underlinePixelY, colour)
+		}
+
+		// strikethrough the cell if required
+		if cell.Strikethrough() {
+			strikethroughPixelY := float64(pixelY + (options.CellSize.Y / 2))
+			c.DrawLine(float64(pixelX), strikethroughPixelY, float64(pixelX+cellWidth), strikethroughPixelY, colour)
+		}
+
+		// runs are broken at the cursor so that ligatures don't hide the characters being edited, and around
+		// characters which are wide, have combining marks or are drawn procedurally or with a fallback font
+		ru := cell.Rune().Rune
+		marks := combiningMarks(cell)
+		shape := cells == 1 && len(marks) == 0 && options.shapes(ru)
+		isolated := !shape || (cursorOnRow && viewX == buffer.CursorColumn())
+		if isolated || !run.continues(viewX, style, colour) {
+			run.draw()
+		}
+		run.add(viewX, style, colour, ru, cells, shape, marks)
+		if isolated {
+			run.draw()
+		}
+	}
+	run.draw()
+}
+
+// DrawCell draws the text of a single cell, such as the cell under the cursor in the cursor colours
+func DrawCell(c Canvas, cell *termutil.Cell, viewX uint16, viewY int, style font.Style, colour color.Color, options RowOptions) {
+	cells := 1
+	if cell.Wide() {
+		cells = 2
+	}
+	r := cell.Rune().Rune
+	marks := combiningMarks(cell)
+	run := textRun{canvas: c, options: &options, pixelY: options.CellSize.Y * viewY}
+	run.add(viewX, style, colour, r, cells, cells == 1 && len(marks) == 0 && options.shapes(r), marks)
+	run.draw()
+}
+
+// combiningMarks returns the marks drawn over the rune of a cell, leaving out joiners and variation selectors
+func combiningMarks(cell *termutil.Cell) []rune {
+	var marks []rune
+	for _, r := range cell.Combining() {
+		if termutil.IsCombiningMark(r) {
+			marks = append(marks, r)
+		}
+	}
+	return marks
+}
+
+// CellStyle returns the font style a cell is drawn in - bold is shown using colour alone if bold is bright
+func CellStyle(cell *termutil.Cell, colours ColourOptions) font.Style {
+	bold := cell.Bold() && !colours.BoldIsBright
+	switch {
+	case bold && cell.Italic():
+		return font.BoldItalic
+	case bold:
+		return font.Bold
+	case cell.Italic():
+		return font.Italic
+	}
+	return font.Regular
+}
+
+// shapes returns true if the rune can be shaped with the runes around it
+func (options *RowOptions) shapes(r rune) bool {
+	return options.Ligatures && options.FontManager.Covers(r) && !(options.Glyphs.Procedural && font.IsProcedural(r))
+}
+
+// textRun is a run of adjacent cells with the same style and colour. When ligatures are enabled, runs are shaped
+// with the font so that its ligatures, contextual alternates and other features are applied.
+type textRun struct {
+	canvas  Canvas
+	options *RowOptions
+	pixelY  int
+	start   uint16
+	style   font.Style
+	colour  color.Color
+	runes   []rune
+	shape   bool
+	cells   int    // cells covered by each rune - wide characters cover two, and are always drawn alone
+	marks   []rune // combining marks drawn over the rune, which is always drawn alone
+}
+
+func (run *textRun) continues(x uint16, style font.Style, colour color.Color) bool {
+	return len(run.runes) > 0 && run.shape && run.start+uint16(len(run.runes)) == x && run.style == style && SameColour(run.colour, colour)
+}
+
+func (run *textRun) add(x uint16, style font.Style, colour color.Color, r rune, cells int, shape bool, marks []rune) {
+	if len(run.runes) == 0 {
+		run.start = x
+		run.style = style
+		run.colour = colour
+		run.shape = shape
+		run.cells = cells
+		run.marks = marks
+	}
+	run.runes = append(run.runes, r)
+}
+
+// draw draws the text of the run, and empties it
+func (run *textRun) draw() {
+	if len(run.runes) == 0 {
+		return
+	}
+	defer func() {
+		run.runes = run.runes[:0]
+		run.marks = nil
+	}()
+
+	options := run.options
+	if run.shape {
+		if glyphs := options.FontManager.Shape(run.style, run.runes); glyphs != nil {
+			face := options.FontManager.GlyphFace(run.style)
+			for _, glyph := range glyphs {
+				pixelX := options.CellSize.X*(int(run.start)+glyph.Cell) + glyph.X
+				run.canvas.DrawGlyph(face, glyph.Rune, pixelX, run.pixelY+options.DotDepth+glyph.Y, run.colour)
+			}
+			return
+		}
+	}
+
+	// draw each rune in its own cell, using a fallback font for any runes missing from the primary font, and
+	// colour glyphs for emoji
+	for i, ru := range run.runes {
+		pixelX := options.CellSize.X * (int(run.start) + i)
+		if !run.canvas.DrawProcedural(ru, pixelX, run.pixelY, run.colour) &&
+			!run.canvas.DrawColourGlyph(ru, run.cells, pixelX, run.pixelY, run.colour) {
+			run.canvas.DrawGlyph(options.FontManager.Face(run.style, ru), ru, pixelX, run.pixelY+options.DotDepth, run.colour)
+		}
+		// marks have no advance of their own, so are drawn from the same origin as the rune they modify
+		for _, mark := range run.marks {
+			run.canvas.DrawGlyph(options.FontManager.Face(run.style, mark), mark, pixelX, run.pixelY+options.DotDepth, run.colour)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package software
+
+func (r *renderer) drawSearchMatches() {
+	matches, current := r.buffer.GetViewSearchMatches()
+
+	for i, match := range matches {
+		bg, fg := r.theme.SearchMatchBackground(), r.theme.SearchMatchForeground()
+		if i == current {
+			// the current match is drawn in reverse so that it stands out from the others
+			bg, fg = fg, bg
+		}
+		for y := match.Start.Line; y <= match.End.Line; y++ {
+			xStart, xEnd := 0, int(r.buffer.ViewWidth())-1
+			if y == match.Start.Line {
+				xStart = int(match.Start.Col)
+			}
+			if y == match.End.Line {
+				xEnd = int(match.End.Col)
+			}
+			for x := xStart; x <= xEnd; x++ {
+				pX, pY := float64(x*r.cellSize.X), float64(y*uint64(r.cellSize.Y))
+				drawRect(r.frame, pX, pY, float64(r.cellSize.X), float64(r.cellSize.Y), bg)
+				cell := r.buffer.GetCell(uint16(x), uint16(y))
+				if cell == nil || cell.Rune().Rune == 0 {
+					continue
+				}
+				drawGlyph(r.frame, r.fontManager.RegularFontFace(), cell.Rune().Rune, int(pX), int(pY)+r.dotDepth, fg)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package software
+
+func (r *renderer) drawSelection() {
+	_, selection := r.buffer.GetSelection()
+	if selection == nil {
+		// nothing selected
+		return
+	}
+
+	bg, fg := r.theme.SelectionBackground(), r.theme.SelectionForeground()
+
+	for y := selection.Start.Line; y <= selection.End.Line; y++ {
+		xStart, xEnd := 0, int(r.buffer.ViewWidth())
+		if y == selection.Start.Line {
+			xStart = int(selection.Start.Col)
+		}
+		if y == selection.End.Line {
+			xEnd = int(selection.End.Col)
+		}
+		for x := xStart; x <= xEnd; x++ {
+			pX, pY := float64(x*r.cellSize.X), float64(y*uint64(r.cellSize.Y))
+			drawRect(r.frame, pX, pY, float64(r.cellSize.X), float64(r.cellSize.Y), bg)
+			cell := r.buffer.GetCell(uint16(x), uint16(y))
+			if cell == nil || cell.Rune().Rune == 0 {
+				continue
+			}
+			drawGlyph(r.frame, r.fontManager.RegularFontFace(), cell.Rune().Rune, int(pX), int(pY)+r.dotDepth, fg)
+		}
+	}
+}
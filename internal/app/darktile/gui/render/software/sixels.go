@@ -0,0 +1,12 @@
+package software
+
+func (r *renderer) drawSixels() {
+	for _, sixel := range r.buffer.GetVisibleSixels() {
+		drawImage(
+			r.frame,
+			sixel.Sixel.Image,
+			int(sixel.Sixel.X)*r.cellSize.X,
+			sixel.ViewLineOffset*r.cellSize.Y,
+		)
+	}
+}
@@ -0,0 +1,110 @@
+// Package software draws a terminal to an image on the CPU, without a window or GPU. It draws the same as the
+// ebiten renderer used by the GUI, to within a small tolerance per pixel for antialiasing, so that rendering can be
+// tested and screenshots taken headlessly. The two renderers are compared by the display tests of gui/render.
+package software
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// ColourOptions control how the stored colours of cells are adjusted when they are drawn
+type ColourOptions struct {
+	MinimumContrast     float64 // WCAG contrast ratio between text and its background, 1 or less disables adjustment
+	BoldIsBright        bool    // draw bold text in bright colours instead of the bold font
+	BoldInBrightColours bool    // draw bold text in bright colours as well as the bold font
+}
+
+// GlyphOptions control how characters which need to join up with their neighbours are drawn
+type GlyphOptions struct {
+	Procedural    bool // draw box drawing, block, braille and Powerline characters to fill their cells exactly
+	LineThickness int  // thickness in pixels of light lines in procedurally drawn characters, 0 to fit the font size
+}
+
+// Options control how the terminal is drawn, matching the settings of the GUI
+type Options struct {
+	Opacity     float64 // opacity of the whole image, where 0 is treated as fully opaque
+	Ligatures   bool
+	Colours     ColourOptions
+	Glyphs      GlyphOptions
+	CursorImage image.Image
+	Popups      []popup.Message
+	Focused     bool        // draw the cursor as when the window has focus, rather than as a hollow block
+	Pointer     image.Point // position of the mouse pointer, which annotations are drawn next to
+}
+
+type renderer struct {
+	frame       *image.RGBA
+	buffer      *termutil.Buffer
+	theme       *termutil.Theme
+	fontManager *font.Manager
+	pixelWidth  int
+	pixelHeight int
+	cellSize    image.Point
+	dotDepth    int
+	options     Options
+}
+
+// Draw draws the active buffer of the terminal over the whole of the image, in the same way that the GUI draws it
+// to the window. The terminal is locked while it is drawn.
+func Draw(dst *image.RGBA, terminal *termutil.Terminal, fontManager *font.Manager, options Options) {
+	terminal.Lock()
+	defer terminal.Unlock()
+
+	bounds := dst.Bounds()
+	r := &renderer{
+		frame:       dst,
+		buffer:      terminal.GetActiveBuffer(),
+		theme:       terminal.Theme(),
+		fontManager: fontManager,
+		pixelWidth:  bounds.Dx(),
+		pixelHeight: bounds.Dy(),
+		cellSize:    fontManager.CharSize(),
+		dotDepth:    fontManager.DotDepth(),
+		options:     options,
+	}
+
+	// the frame is drawn with its origin at the top left, so images with other bounds are drawn via a copy
+	translucent := options.Opacity > 0 && options.Opacity < 1
+	if translucent || bounds.Min != (image.Point{}) {
+		r.frame = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	}
+
+	// 1. fill frame with default background colour
+	draw.Draw(r.frame, r.frame.Bounds(), image.NewUniform(r.theme.DefaultBackground()), image.Point{}, draw.Src)
+
+	// 2. draw content (each row, each cell)
+	r.drawContent()
+
+	// 3. draw cursor
+	r.drawCursor()
+
+	// 4. draw sixels
+	r.drawSixels()
+
+	// 5. draw search matches
+	r.drawSearchMatches()
+
+	// 6. draw selection
+	r.drawSelection()
+
+	// 7. draw highlight/annotations
+	r.drawAnnotation()
+
+	// 8. draw popups
+	r.drawPopups()
+
+	// 9. apply effects (e.g. transparency)
+	if r.frame != dst {
+		var mask image.Image
+		if translucent {
+			mask = image.NewUniform(color.Alpha16{A: uint16(options.Opacity * 0xffff)})
+		}
+		draw.DrawMask(dst, bounds, r.frame, image.Point{}, mask, image.Point{}, draw.Src)
+	}
+}
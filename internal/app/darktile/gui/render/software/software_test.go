@@ -0,0 +1,277 @@
+package software
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/liamg/darktile/internal/app/darktile/config"
+	"github.com/liamg/darktile/internal/app/darktile/font"
+	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+var update = flag.Bool("update", false, "update the golden images in testdata")
+
+// goldenTolerance is the largest difference allowed in each channel of each pixel, as rasterisation can vary
+// slightly between architectures
+const goldenTolerance = 8
+
+func newTestTerminal(t testing.TB, rows, cols uint16, output string) *termutil.Terminal {
+	theme, err := config.DefaultTheme(config.DefaultConfig())
+	require.NoError(t, err)
+	term := termutil.New(termutil.WithTheme(theme))
+	term.Resize(rows, cols)
+	term.Process([]byte(output))
+	return term
+}
+
+func newTestFontManager(t testing.TB) *font.Manager {
+	m := font.NewManager()
+	require.NoError(t, m.SetSize(14))
+	require.NoError(t, m.SetFontData("Go Mono", gomono.TTF))
+	return m
+}
+
+func render(t *testing.T, term *termutil.Terminal, options Options) *image.RGBA {
+	fontManager := newTestFontManager(t)
+	buffer := term.GetActiveBuffer()
+	size := fontManager.CharSize()
+	img := image.NewRGBA(image.Rect(0, 0, size.X*int(buffer.ViewWidth()), size.Y*int(buffer.ViewHeight())))
+	Draw(img, term, fontManager, options)
+	return img
+}
+
+// assertGolden compares the image with testdata/<name>.png, or updates it when the tests are run with -update
+func assertGolden(t *testing.T, name string, actual *image.RGBA) {
+	path := filepath.Join("testdata", name+".png")
+
+	if *update {
+		require.NoError(t, os.MkdirAll("testdata", 0755))
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		defer f.Close()
+		require.NoError(t, png.Encode(f, actual))
+		return
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	expected, err := png.Decode(f)
+	require.NoError(t, err)
+
+	require.Equal(t, expected.Bounds(), actual.Bounds(), "image size differs from %s", path)
+
+	var differences int
+	var first string
+	bounds := actual.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			e := color.RGBAModel.Convert(expected.At(x, y)).(color.RGBA)
+			a := actual.RGBAAt(x, y)
+			if channelDiff(e.R, a.R) > goldenTolerance || channelDiff(e.G, a.G) > goldenTolerance ||
+				channelDiff(e.B, a.B) > goldenTolerance || channelDiff(e.A, a.A) > goldenTolerance {
+				if differences == 0 {
+					first = fmt.Sprintf("(%d,%d) expected %v, got %v", x, y, e, a)
+				}
+				differences++
+			}
+		}
+	}
+	if differences > 0 {
+		t.Errorf("%d pixels differ from %s, starting at %s - run the tests with -update if the change is intended", differences, path, first)
+	}
+}
+
+func channelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func TestRenderText(t *testing.T) {
+	term := newTestTerminal(t, 6, 30, "plain \x1b[1mbold\x1b[0m \x1b[3mitalic\x1b[0m \x1b[2mdim\x1b[0m\r\n"+
+		"\x1b[4munderline\x1b[0m \x1b[9mstrike\x1b[0m \x1b[7minverse\x1b[0m\r\n"+
+		"\x1b[31mred \x1b[32mgreen \x1b[34mblue \x1b[93mbright\x1b[0m\r\n"+
+		"\x1b[38;5;208m256 \x1b[38;2;10;200;250mtruecolour\x1b[0m \x1b[44mback\x1b[0m\r\n"+
+		"$ ")
+	assertGolden(t, "text", render(t, term, Options{Ligatures: true, Focused: true}))
+}
+
+func TestRenderUnfocusedCursor(t *testing.T) {
+	term := newTestTerminal(t, 2, 10, "$ ")
+	assertGolden(t, "unfocused_cursor", render(t, term, Options{}))
+}
+
+func TestRenderCursorShapes(t *testing.T) {
+	term := newTestTerminal(t, 2, 10, "$ ls\x1b[D\x1b[6 q")
+	assertGolden(t, "bar_cursor", render(t, term, Options{Focused: true}))
+}
+
+func TestRenderBoxDrawing(t *testing.T) {
+	term := newTestTerminal(t, 5, 12, "┌──┬──┐ ░▒▓\r\n│ab│╳╳│ ▀▄█\r\n├──┼──┤ \r\n╰──┴──╯ ⣿⠁⢕\r\n")
+	options := Options{Focused: true, Glyphs: GlyphOptions{Procedural: true}}
+	assertGolden(t, "box_drawing", render(t, term, options))
+}
+
+func TestRenderMinimumContrast(t *testing.T) {
+	term := newTestTerminal(t, 2, 20, "\x1b[38;2;20;20;20mlow contrast\x1b[0m")
+	options := Options{Focused: true, Colours: ColourOptions{MinimumContrast: 4.5}}
+	assertGolden(t, "minimum_contrast", render(t, term, options))
+}
+
+func TestRenderSelectionAndPopup(t *testing.T) {
+	term := newTestTerminal(t, 6, 30, "select some of this text\r\nand some of this line\r\n")
+	buffer := term.GetActiveBuffer()
+	buffer.SetSelectionStart(termutil.Position{Line: 0, Col: 7})
+	buffer.SetSelectionEnd(termutil.Position{Line: 1, Col: 7})
+	options := Options{
+		Focused: true,
+		Popups: []popup.Message{{
+			Text:       "Copied",
+			Expiry:     time.Now().Add(time.Minute),
+			Foreground: color.White,
+			Background: color.RGBA{R: 0x33, G: 0x33, B: 0x99, A: 0xff},
+		}},
+	}
+	assertGolden(t, "selection_popup", render(t, term, options))
+}
+
+func TestRenderPopupSizedInCells(t *testing.T) {
+	term := newTestTerminal(t, 6, 30, "\x1b[?25l")
+	background := color.RGBA{R: 0x33, G: 0x33, B: 0x99, A: 0xff}
+	img := render(t, term, Options{Popups: []popup.Message{{
+		Text:       "ééééé\n日本",
+		Expiry:     time.Now().Add(time.Minute),
+		Foreground: color.White,
+		Background: background,
+	}}})
+
+	// the box fits the widest line, of five cells, rather than the bytes of the whole message
+	cellSize := newTestFontManager(t).CharSize()
+	pad := cellSize.Y / 2
+	right := pad + pad*2 + cellSize.X*5
+	top := cellSize.Y*6 - pad*3 - cellSize.Y*2
+	rgba := func(c color.Color) color.RGBA {
+		return color.RGBAModel.Convert(c).(color.RGBA)
+	}
+	require.Equal(t, background, rgba(img.At(right-1, top+1)))
+	require.Equal(t, rgba(term.Theme().DefaultBackground()), rgba(img.At(right+2, top+1)))
+}
+
+func TestRenderOpacity(t *testing.T) {
+	term := newTestTerminal(t, 2, 10, "faded")
+	assertGolden(t, "opacity", render(t, term, Options{Opacity: 0.5, Focused: true}))
+}
+
+func TestRenderWideCharacters(t *testing.T) {
+	term := newTestTerminal(t, 3, 10, "\x1b[4ma界b\x1b[0m\r\n界x\x1b[3D")
+	assertGolden(t, "wide_characters", render(t, term, Options{Focused: true}))
+}
+
+func TestRenderCombiningMarks(t *testing.T) {
+	plain := render(t, newTestTerminal(t, 1, 4, "\x1b[?25lex"), Options{Focused: true})
+	accented := render(t, newTestTerminal(t, 1, 4, "\x1b[?25le\u0301x"), Options{Focused: true})
+
+	// the accent is drawn over the first cell, and doesn't move the character after it. Like the e itself, its
+	// antialiased edge can reach a pixel into the next cell.
+	cellWidth := newTestFontManager(t).CharSize().X + 1
+	var inFirstCell, elsewhere int
+	bounds := plain.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if plain.RGBAAt(x, y) == accented.RGBAAt(x, y) {
+				continue
+			}
+			if x < cellWidth {
+				inFirstCell++
+			} else {
+				elsewhere++
+			}
+		}
+	}
+	require.NotZero(t, inFirstCell, "the accent is not drawn")
+	require.Zero(t, elsewhere)
+}
+
+func TestRenderBoldItalicDiffersFromItalic(t *testing.T) {
+	italic := render(t, newTestTerminal(t, 1, 4, "\x1b[?25l\x1b[3mab"), Options{Focused: true})
+	boldItalic := render(t, newTestTerminal(t, 1, 4, "\x1b[?25l\x1b[1;3mab"), Options{Focused: true})
+	require.NotEqual(t, italic.Pix, boldItalic.Pix, "bold italic text is drawn in the italic style")
+}
+
+func TestRenderSearchMatches(t *testing.T) {
+	term := newTestTerminal(t, 2, 20, "\x1b[?25lfind me, find you")
+	buffer := term.GetActiveBuffer()
+	require.Len(t, buffer.Search("find"), 2)
+	buffer.SetCurrentSearchMatch(1)
+	img := render(t, term, Options{Focused: true})
+
+	cellSize := newTestFontManager(t).CharSize()
+	theme := term.Theme()
+	pixel := func(col int) color.RGBA {
+		return color.RGBAModel.Convert(img.At(col*cellSize.X, 0)).(color.RGBA)
+	}
+	rgba := func(c color.Color) color.RGBA {
+		return color.RGBAModel.Convert(c).(color.RGBA)
+	}
+
+	require.Equal(t, rgba(theme.SearchMatchBackground()), pixel(0))
+	require.Equal(t, rgba(theme.SearchMatchBackground()), pixel(3))
+	require.Equal(t, rgba(theme.DefaultBackground()), pixel(4))
+	// the current match is drawn in reverse
+	require.Equal(t, rgba(theme.SearchMatchForeground()), pixel(9))
+	require.Equal(t, rgba(theme.SearchMatchForeground()), pixel(12))
+	require.Equal(t, rgba(theme.DefaultBackground()), pixel(13))
+}
+
+// benchmarkOutput is a line of output with a mix of styles and colours, as a build or log would write
+const benchmarkOutput = "\x1b[32mok\x1b[0m   github.com/liamg/darktile/\x1b[1minternal\x1b[0m \x1b[38;5;244m(cached)\x1b[0m " +
+	"\x1b[31merror:\x1b[0m \x1b[4mexpected\x1b[0m 3 got 4\r\n"
+
+func newBenchmarkFrame(b *testing.B, rows, cols uint16) (*termutil.Terminal, *font.Manager, *image.RGBA) {
+	term := newTestTerminal(b, rows, cols, "")
+	for i := uint16(0); i < rows; i++ {
+		term.Process([]byte(benchmarkOutput))
+	}
+	fontManager := newTestFontManager(b)
+	size := fontManager.CharSize()
+	return term, fontManager, image.NewRGBA(image.Rect(0, 0, size.X*int(cols), size.Y*int(rows)))
+}
+
+// BenchmarkDrawIdle measures drawing a full screen of output which isn't changing, as the software renderer
+// redraws every row of each frame
+func BenchmarkDrawIdle(b *testing.B) {
+	term, fontManager, img := newBenchmarkFrame(b, 60, 200)
+	options := Options{Ligatures: true, Focused: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Draw(img, term, fontManager, options)
+	}
+}
+
+// BenchmarkDrawScrolling measures drawing a frame after each line of output has scrolled the view
+func BenchmarkDrawScrolling(b *testing.B) {
+	term, fontManager, img := newBenchmarkFrame(b, 60, 200)
+	options := Options{Ligatures: true, Focused: true}
+	output := []byte(benchmarkOutput)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		term.Process(output)
+		Draw(img, term, fontManager, options)
+	}
+}
@@ -0,0 +1,85 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/gui/popup"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// search is the state of a search of the active buffer, from when the search action is run until it is closed
+type search struct {
+	query   string
+	matches int
+	current int // index of the current match, counting from the top of the scrollback
+}
+
+// startSearch opens the search prompt. Text typed while it is open is searched for instead of being sent to the
+// terminal.
+func (g *GUI) startSearch() {
+	g.search = &search{}
+}
+
+// handleSearchInput handles key presses while the search prompt is open. The terminal must be locked.
+func (g *GUI) handleSearchInput() {
+	buffer := g.terminal.GetActiveBuffer()
+
+	switch {
+	case g.keyState.RepeatPressed(ebiten.KeyEscape):
+		buffer.ClearSearch()
+		g.search = nil
+	case g.keyState.RepeatPressed(ebiten.KeyEnter):
+		// the buffer may have changed since the last key press, so the matches are found again
+		g.runSearch(buffer, false)
+		if g.search.matches == 0 {
+			return
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.search.current = (g.search.current + 1) % g.search.matches
+		} else {
+			g.search.current = (g.search.current + g.search.matches - 1) % g.search.matches
+		}
+		buffer.SetCurrentSearchMatch(g.search.current)
+	case g.keyState.RepeatPressed(ebiten.KeyBackspace):
+		if query := []rune(g.search.query); len(query) > 0 {
+			g.search.query = string(query[:len(query)-1])
+			g.runSearch(buffer, true)
+		}
+	default:
+		if input := ebiten.AppendInputChars(nil); len(input) > 0 {
+			g.search.query += string(input)
+			g.runSearch(buffer, true)
+		}
+	}
+}
+
+// runSearch finds the matches of the query. When the query has changed, the match closest to the bottom of the
+// scrollback becomes the current match.
+func (g *GUI) runSearch(buffer *termutil.Buffer, changed bool) {
+	g.search.matches = len(buffer.Search(g.search.query))
+	if changed || g.search.current >= g.search.matches {
+		g.search.current = g.search.matches - 1
+	}
+	if g.search.matches > 0 {
+		buffer.SetCurrentSearchMatch(g.search.current)
+	}
+}
+
+func searchOverlay(s search, theme *termutil.Theme) popup.Message {
+	var count string
+	switch {
+	case s.query == "":
+	case s.matches == 0:
+		count = " (no matches)"
+	default:
+		count = fmt.Sprintf(" (%d/%d)", s.current+1, s.matches)
+	}
+	return popup.Message{
+		Text:       fmt.Sprintf("Search: %s%s\nEnter for the match above, Shift+Enter for the match below, Escape to close", s.query, count),
+		Expiry:     time.Now().Add(time.Second),
+		Foreground: theme.SearchMatchForeground(),
+		Background: theme.SearchMatchBackground(),
+	}
+}
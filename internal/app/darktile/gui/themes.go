@@ -0,0 +1,74 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// ThemeVariant is one of the named colour schemes defined in a theme file, e.g. "dark" or "light"
+type ThemeVariant struct {
+	Name  string
+	Theme *termutil.Theme
+}
+
+// WithThemeVariants sets the theme variants which can be switched between at runtime. The current variant is
+// kept if it still exists, otherwise the preferred variant is used, or the first variant if preferred is empty.
+func WithThemeVariants(variants []ThemeVariant, preferred string) func(g *GUI) error {
+	return func(g *GUI) error {
+		if len(variants) == 0 {
+			return fmt.Errorf("no theme variants were provided")
+		}
+		g.themeVariants = variants
+
+		if index := g.themeVariantIndex(g.themeVariant); g.themeVariant != "" && index >= 0 {
+			g.setThemeVariant(index)
+			return nil
+		}
+
+		index := 0
+		if preferred != "" {
+			if index = g.themeVariantIndex(preferred); index < 0 {
+				g.setThemeVariant(0)
+				return fmt.Errorf("unknown theme variant '%s'", preferred)
+			}
+		}
+		g.setThemeVariant(index)
+		return nil
+	}
+}
+
+func (g *GUI) themeVariantIndex(name string) int {
+	for i, variant := range g.themeVariants {
+		if variant.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// setThemeVariant switches the terminal to the theme variant at the given index - the terminal must be locked by the caller
+func (g *GUI) setThemeVariant(index int) {
+	variant := g.themeVariants[index]
+	g.themeVariant = variant.Name
+	g.terminal.SetTheme(variant.Theme)
+}
+
+// switchThemeVariant switches to the named theme variant, or the next one if name is empty
+func (g *GUI) switchThemeVariant(name string) {
+	if len(g.themeVariants) == 0 {
+		return
+	}
+
+	var index int
+	if name == "" {
+		index = (g.themeVariantIndex(g.themeVariant) + 1) % len(g.themeVariants)
+	} else if index = g.themeVariantIndex(name); index < 0 {
+		// the variant may have been removed from the theme file, so this isn't fatal
+		g.ShowError(fmt.Sprintf("Unknown theme variant '%s'", name))
+		return
+	}
+
+	g.setThemeVariant(index)
+	g.ShowMessage(fmt.Sprintf("Theme: %s", g.themeVariant))
+}
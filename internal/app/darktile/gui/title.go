@@ -0,0 +1,73 @@
+package gui
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/liamg/darktile/internal/app/darktile/gui/title"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+const (
+	defaultWindowTitle = "darktile"
+	// the title is refreshed at least this often to pick up directory changes and programs starting
+	titlePollInterval = time.Second
+	// output can change the title many times a second, so refreshes are limited to this rate
+	titleRefreshInterval = time.Millisecond * 100
+)
+
+// WithTitleTemplate sets the window title from a template, where {process} is replaced by the name of the
+// foreground process, {cwd} by its working directory and {title} by the title set by the application. An
+// empty template shows the title set by the application alone.
+func WithTitleTemplate(template string) func(g *GUI) error {
+	return func(g *GUI) error {
+		g.titleTemplate = template
+		g.requestTitleRefresh()
+		return nil
+	}
+}
+
+func (g *GUI) requestTitleRefresh() {
+	select {
+	case g.titleChan <- struct{}{}:
+	default:
+	}
+}
+
+// watchTitle keeps the window title up to date
+func (g *GUI) watchTitle() {
+	ticker := time.NewTicker(titlePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-g.titleChan:
+		}
+		g.refreshTitle()
+		time.Sleep(titleRefreshInterval)
+	}
+}
+
+func (g *GUI) refreshTitle() {
+	windowTitle := g.manipulator.GetTitle()
+
+	g.terminal.Lock()
+	template := g.titleTemplate
+	var process termutil.Process
+	if template != "" {
+		process, _ = g.terminal.ForegroundProcess()
+	}
+	g.terminal.Unlock()
+
+	if template != "" {
+		windowTitle = title.Expand(template, process, windowTitle)
+	}
+	if windowTitle == "" {
+		windowTitle = defaultWindowTitle
+	}
+
+	if windowTitle != g.windowTitle {
+		g.windowTitle = windowTitle
+		ebiten.SetWindowTitle(windowTitle)
+	}
+}
@@ -0,0 +1,129 @@
+// Package title keeps the window title set by the application and fills in title templates. It is kept apart from
+// the GUI so that it can be tested without a display, and is shared with headless windows.
+package title
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// Stack holds the title set by the application, along with the titles it has saved to be restored later. It is
+// safe for concurrent use.
+type Stack struct {
+	mu    sync.Mutex
+	title string
+	stack []string
+}
+
+func (s *Stack) Get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.title
+}
+
+func (s *Stack) Set(title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.title = title
+}
+
+// Save pushes the current title onto the stack
+func (s *Stack) Save() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stack = append(s.stack, s.title)
+}
+
+// Restore pops the last saved title off the stack and sets it, or clears the title if none has been saved
+func (s *Stack) Restore() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.stack) == 0 {
+		s.title = ""
+		return
+	}
+	s.title = s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+}
+
+// separators are the characters which join the values in a template, which are removed along with empty values
+const separators = " -—–|:·"
+
+// Expand fills in a title template, where {process} is replaced by the name of the foreground process, {cwd} by
+// its working directory and {title} by the title set by the application. When a value is empty, the separator
+// which joins it to the rest of the title is removed too, e.g. "{process} - {title}" becomes "vim" rather than
+// "vim - " when there is no title. The values themselves are left as they are.
+func Expand(template string, process termutil.Process, title string) string {
+	values := map[string]string{
+		"{process}": process.Name,
+		"{cwd}":     AbbreviateHome(process.Cwd),
+		"{title}":   title,
+	}
+
+	type part struct {
+		text        string
+		placeholder bool
+		dropped     bool
+	}
+	var parts []part
+	for len(template) > 0 {
+		next, placeholder := len(template), ""
+		for name := range values {
+			if i := strings.Index(template, name); i >= 0 && i < next {
+				next, placeholder = i, name
+			}
+		}
+		if next > 0 {
+			parts = append(parts, part{text: template[:next]})
+		}
+		if placeholder == "" {
+			break
+		}
+		parts = append(parts, part{text: values[placeholder], placeholder: true})
+		template = template[next+len(placeholder):]
+	}
+
+	isSeparator := func(i int) bool {
+		return i >= 0 && i < len(parts) && !parts[i].placeholder && !parts[i].dropped &&
+			strings.Trim(parts[i].text, separators) == ""
+	}
+	for i := range parts {
+		if !parts[i].placeholder || parts[i].text != "" {
+			continue
+		}
+		// the separator before an empty value joins it to what comes before, otherwise the one after joins it to
+		// what follows
+		if isSeparator(i - 1) {
+			parts[i-1].dropped = true
+		} else if isSeparator(i + 1) {
+			parts[i+1].dropped = true
+		}
+	}
+
+	var expanded strings.Builder
+	for _, p := range parts {
+		if !p.dropped {
+			expanded.WriteString(p.text)
+		}
+	}
+	return expanded.String()
+}
+
+// AbbreviateHome replaces the home directory at the start of a path with ~
+func AbbreviateHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" || path == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
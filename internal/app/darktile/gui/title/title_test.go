@@ -0,0 +1,81 @@
+package title
+
+import (
+	"testing"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackRestoresSavedTitles(t *testing.T) {
+	var s Stack
+	s.Set("first")
+	s.Save()
+	s.Set("second")
+	s.Save()
+	s.Set("third")
+
+	s.Restore()
+	assert.Equal(t, "second", s.Get())
+	s.Restore()
+	assert.Equal(t, "first", s.Get())
+}
+
+func TestStackRestoreWithEmptyStackClearsTitle(t *testing.T) {
+	var s Stack
+	s.Set("vim")
+	s.Restore()
+	assert.Equal(t, "", s.Get())
+
+	// the stack is still usable afterwards
+	s.Set("less")
+	s.Save()
+	s.Set("man")
+	s.Restore()
+	assert.Equal(t, "less", s.Get())
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		process  termutil.Process
+		title    string
+		expected string
+	}{
+		{"all values", "{process} - {title}", termutil.Process{Name: "vim"}, "notes.md", "vim - notes.md"},
+		{"empty value at the end", "{process} - {title}", termutil.Process{Name: "vim"}, "", "vim"},
+		{"empty value at the start", "{title} - {process}", termutil.Process{Name: "vim"}, "", "vim"},
+		{"empty value in the middle", "{process}: {cwd} | {title}", termutil.Process{Name: "vim"}, "notes.md", "vim | notes.md"},
+		{"every value empty", "{process} - {cwd} - {title}", termutil.Process{}, "", ""},
+		{"trailing characters of a value", "{title}", termutil.Process{}, "make -", "make -"},
+		{"separators inside a value", "{process} - {title}", termutil.Process{Name: "sh"}, "- a | b :", "sh - - a | b :"},
+		{"literal text around an empty value", "[{title}] {process}", termutil.Process{Name: "vim"}, "", "[] vim"},
+		{"literal text kept", "darktile: {process}", termutil.Process{Name: "vim"}, "", "darktile: vim"},
+		{"no placeholders", "darktile -", termutil.Process{}, "", "darktile -"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Expand(test.template, test.process, test.title))
+		})
+	}
+}
+
+func TestExpandAbbreviatesCwd(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+	assert.Equal(t, "vim ~/src", Expand("{process} {cwd}", termutil.Process{Name: "vim", Cwd: "/home/test/src"}, ""))
+}
+
+func TestAbbreviateHome(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+	tests := map[string]string{
+		"/home/test":         "~",
+		"/home/test/src/app": "~/src/app",
+		"/home/tester":       "/home/tester",
+		"/tmp":               "/tmp",
+		"":                   "",
+	}
+	for path, expected := range tests {
+		assert.Equal(t, expected, AbbreviateHome(path), path)
+	}
+}
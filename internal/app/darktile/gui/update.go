@@ -31,10 +31,22 @@ func (g *GUI) getModifierStr() string {
 // Update changes the terminal GUI state - all user-initiated modification should happen here.
 func (g *GUI) Update() error {
 
+	g.applyPendingReload()
+	g.showSelectionErrors()
+
+	// runes drawn before the installed fonts were indexed may now be drawn from a fallback font
+	if g.fontManager.FallbackDiscoveryCompleted() {
+		g.renderCache.Invalidate()
+	}
+
 	if err := g.handleInput(); err != nil {
 		return err
 	}
 
+	if g.exportRequested {
+		g.export()
+	}
+
 	g.filterPopupMessages()
 
 	return nil
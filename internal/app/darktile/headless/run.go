@@ -0,0 +1,76 @@
+// Package headless runs programs in terminals which aren't shown in a window, so that their screens can be saved
+package headless
+
+import (
+	"errors"
+	"time"
+
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// ErrTimeout is returned by Run when the program was still producing output once the timeout had passed. The
+// screen can still be used, but may not be complete.
+var ErrTimeout = errors.New("the program was still producing output")
+
+// Options control how long Run waits for a program
+type Options struct {
+	Rows    uint16
+	Cols    uint16
+	Quiet   time.Duration // the screen is finished once the program has produced no output for this long
+	Timeout time.Duration // the screen is used as it is once this has passed
+}
+
+// Run runs the program of a terminal created with termutil.WithHeadless until it has exited and all of its output
+// has been processed, or until it has stopped producing output. A program which hasn't exited is left running, so
+// that it isn't able to change the screen as it is hung up.
+func Run(terminal *termutil.Terminal, options Options) error {
+
+	updates := make(chan struct{}, 1)
+	exited := make(chan error, 1)
+	go func() {
+		_, err := terminal.Run(updates, options.Rows, options.Cols)
+		exited <- err
+	}()
+
+	quiet := time.NewTimer(options.Quiet)
+	defer quiet.Stop()
+	timeout := time.NewTimer(options.Timeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-updates:
+			resetTimer(quiet, options.Quiet)
+		case err := <-exited:
+			if err != nil {
+				return err
+			}
+			// the output has all been read from the pty, but it may still be queued for processing
+			flushed := make(chan struct{})
+			go func() {
+				terminal.Flush()
+				close(flushed)
+			}()
+			select {
+			case <-flushed:
+				return nil
+			case <-timeout.C:
+				return ErrTimeout
+			}
+		case <-quiet.C:
+			return nil
+		case <-timeout.C:
+			return ErrTimeout
+		}
+	}
+}
+
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
@@ -0,0 +1,113 @@
+package headless
+
+import (
+	"image"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/term"
+)
+
+func runHeadless(t *testing.T, quiet, timeout time.Duration, args ...string) (*termutil.Terminal, error) {
+	terminal := termutil.New(
+		termutil.WithWindowManipulator(NewWindow(80, 24, image.Pt(8, 16))),
+		termutil.WithCommand(args[0], args[1:]...),
+		termutil.WithHeadless(true),
+	)
+	err := Run(terminal, Options{Rows: 24, Cols: 80, Quiet: quiet, Timeout: timeout})
+	t.Cleanup(func() { _ = terminal.Hangup() })
+	return terminal, err
+}
+
+// screen returns the visible lines, without trailing blank lines
+func screen(terminal *termutil.Terminal) []string {
+	var lines []string
+	for _, line := range terminal.GetActiveBuffer().GetVisibleLines() {
+		lines = append(lines, strings.TrimRight(line.String(), " \x00"))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func TestRunProcessesAllOutputOfExitedProgram(t *testing.T) {
+	terminal, err := runHeadless(t, 10*time.Second, 20*time.Second, "sh", "-c", "seq 1 3000; printf done")
+	require.NoError(t, err)
+
+	lines := screen(terminal)
+	require.NotEmpty(t, lines)
+	assert.Equal(t, "done", lines[len(lines)-1])
+	assert.Equal(t, "3000", lines[len(lines)-2])
+}
+
+func TestRunReturnsOnceProgramIsQuiet(t *testing.T) {
+	start := time.Now()
+	terminal, err := runHeadless(t, 200*time.Millisecond, 20*time.Second, "sh", "-c", "printf hi; sleep 5")
+	require.NoError(t, err)
+
+	assert.Less(t, int64(time.Since(start)), int64(4*time.Second))
+	assert.Equal(t, []string{"hi"}, screen(terminal))
+	assert.True(t, terminal.IsRunning(), "the program is left running until the screen has been saved")
+}
+
+func TestRunTimesOutWhileProgramIsWriting(t *testing.T) {
+	_, err := runHeadless(t, time.Second, 300*time.Millisecond, "sh", "-c", "while true; do echo x; sleep 0.01; done")
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestRunLeavesTerminalModeOfStdinAlone(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	require.NoError(t, err)
+	defer func() {
+		_ = tty.Close()
+		_ = ptmx.Close()
+	}()
+	stdin := os.Stdin
+	os.Stdin = tty
+	defer func() { os.Stdin = stdin }()
+
+	before, err := term.GetState(int(tty.Fd()))
+	require.NoError(t, err)
+	_, err = runHeadless(t, 200*time.Millisecond, 20*time.Second, "sleep", "5")
+	require.NoError(t, err)
+	after, err := term.GetState(int(tty.Fd()))
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestWriteToPtyWhileProgramStartsAndExits(t *testing.T) {
+	terminal := termutil.New(
+		termutil.WithWindowManipulator(NewWindow(80, 24, image.Pt(8, 16))),
+		termutil.WithCommand("sh", "-c", `read line; printf "got %s" "$line"`),
+		termutil.WithHeadless(true),
+	)
+
+	// input is written from another goroutine throughout, as key presses are, and discarded while nothing runs
+	stop := make(chan struct{})
+	writing := make(chan struct{})
+	go func() {
+		defer close(writing)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = terminal.WriteToPty([]byte("hi\n"))
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+	}()
+
+	err := Run(terminal, Options{Rows: 24, Cols: 80, Quiet: 10 * time.Second, Timeout: 20 * time.Second})
+	close(stop)
+	<-writing
+	require.NoError(t, err)
+	assert.Contains(t, strings.Join(screen(terminal), "\n"), "got hi")
+}
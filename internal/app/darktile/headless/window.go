@@ -0,0 +1,64 @@
+package headless
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/liamg/darktile/internal/app/darktile/gui/title"
+	"github.com/liamg/darktile/internal/app/darktile/termutil"
+)
+
+// Window stands in for the window of a terminal which isn't shown. It has a fixed size, and keeps the title so
+// that it can be used in exports.
+type Window struct {
+	title    title.Stack
+	cols     int
+	rows     int
+	cellSize image.Point
+}
+
+func NewWindow(cols, rows int, cellSize image.Point) *Window {
+	return &Window{
+		cols:     cols,
+		rows:     rows,
+		cellSize: cellSize,
+	}
+}
+
+func (w *Window) State() termutil.WindowState { return termutil.StateNormal }
+func (w *Window) Minimise()                   {}
+func (w *Window) Maximise()                   {}
+func (w *Window) Restore()                    {}
+func (w *Window) Position() (int, int)        { return 0, 0 }
+func (w *Window) Move(x, y int)               {}
+func (w *Window) ResizeInPixels(int, int)     {}
+func (w *Window) ResizeInChars(int, int)      {}
+func (w *Window) IsFullscreen() bool          { return false }
+func (w *Window) SetFullscreen(enabled bool)  {}
+func (w *Window) ReportError(err error)       { fmt.Fprintf(os.Stderr, "warning: %s\n", err) }
+
+func (w *Window) SizeInPixels() (int, int) {
+	return w.cols * w.cellSize.X, w.rows * w.cellSize.Y
+}
+
+func (w *Window) CellSizeInPixels() (int, int) {
+	return w.cellSize.X, w.cellSize.Y
+}
+
+func (w *Window) SizeInChars() (int, int) {
+	return w.cols, w.rows
+}
+
+func (w *Window) ScreenSizeInPixels() (int, int) {
+	return w.SizeInPixels()
+}
+
+func (w *Window) ScreenSizeInChars() (int, int) {
+	return w.SizeInChars()
+}
+
+func (w *Window) GetTitle() string       { return w.title.Get() }
+func (w *Window) SetTitle(t string)      { w.title.Set(t) }
+func (w *Window) SaveTitleToStack()      { w.title.Save() }
+func (w *Window) RestoreTitleFromStack() { w.title.Restore() }
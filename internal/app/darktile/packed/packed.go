@@ -0,0 +1,43 @@
+// Package packed holds the font families compiled into darktile. Each family is generated by cmd/packfont, which
+// embeds its font files and registers it from an init function.
+package packed
+
+import "strings"
+
+// DefaultFamily is the packed family used when no font family is configured
+const DefaultFamily = "MesloLGS NF"
+
+// Family is a font family compiled into darktile, holding the contents of the font file of each style. Styles
+// which the family doesn't include are nil, and are synthesised from the others.
+type Family struct {
+	Name       string
+	Regular    []byte
+	Bold       []byte
+	Italic     []byte
+	BoldItalic []byte
+}
+
+var families []Family
+
+func register(family Family) {
+	families = append(families, family)
+}
+
+// Families returns the names of the packed families
+func Families() []string {
+	names := make([]string, 0, len(families))
+	for _, family := range families {
+		names = append(names, family.Name)
+	}
+	return names
+}
+
+// Lookup returns the packed family with the given name, which is matched without regard to case
+func Lookup(name string) (Family, bool) {
+	for _, family := range families {
+		if strings.EqualFold(family.Name, name) {
+			return family, true
+		}
+	}
+	return Family{}, false
+}
@@ -2,7 +2,6 @@ package termutil
 
 import (
 	"image"
-	"image/color"
 	"sync"
 )
 
@@ -44,6 +43,8 @@ type Buffer struct {
 	highlightStart        *Position
 	highlightEnd          *Position
 	highlightAnnotation   *Annotation
+	searchMatches         []SearchMatch // raw
+	searchCurrent         int
 	sixels                []Sixel
 	selectionMu           sync.Mutex
 }
@@ -66,7 +67,7 @@ type Position struct {
 }
 
 // NewBuffer creates a new terminal buffer
-func NewBuffer(width, height uint16, maxLines uint64, fg color.Color, bg color.Color) *Buffer {
+func NewBuffer(width, height uint16, maxLines uint64) *Buffer {
 	b := &Buffer{
 		lines:        []Line{},
 		viewHeight:   height,
@@ -74,18 +75,15 @@ func NewBuffer(width, height uint16, maxLines uint64, fg color.Color, bg color.C
 		maxLines:     maxLines,
 		topMargin:    0,
 		bottomMargin: uint(height - 1),
-		cursorAttr: CellAttributes{
-			fgColour: fg,
-			bgColour: bg,
-		},
-		charsets: []*map[rune]rune{nil, nil},
+		charsets:     []*map[rune]rune{nil, nil},
 		modes: Modes{
 			LineFeedMode:   true,
 			AutoWrap:       true,
 			ShowCursor:     true,
 			SixelScrolling: true,
 		},
-		cursorShape: CursorShapeDefault,
+		cursorShape:   CursorShapeDefault,
+		searchCurrent: -1,
 	}
 	return b
 }
@@ -200,6 +198,21 @@ func (buffer *Buffer) getRawCell(viewCol uint16, rawLine uint64) *Cell {
 	return &line.cells[viewCol]
 }
 
+// touchViewLine records that the content of the line shown in the given row of the view has changed
+func (buffer *Buffer) touchViewLine(viewRow uint16) {
+	if rawLine := buffer.convertViewLineToRawLine(viewRow); rawLine < uint64(len(buffer.lines)) {
+		buffer.lines[rawLine].touch()
+	}
+}
+
+// lineVersion returns the version of the line shown in the given row of the view, or 0 if the row is empty
+func (buffer *Buffer) lineVersion(viewRow uint16) uint64 {
+	if rawLine := buffer.convertViewLineToRawLine(viewRow); rawLine < uint64(len(buffer.lines)) {
+		return buffer.lines[rawLine].version
+	}
+	return 0
+}
+
 // Column returns cursor column
 func (buffer *Buffer) CursorColumn() uint16 {
 	// @todo originMode and left margin
@@ -328,6 +341,7 @@ func (buffer *Buffer) insertBlankCharacters(count int) {
 		cells := buffer.lines[index].cells
 		buffer.lines[index].cells = append(cells[:buffer.cursorPosition.Col], append([]Cell{buffer.defaultCell(true)}, cells[buffer.cursorPosition.Col:]...)...)
 	}
+	buffer.lines[index].touch()
 }
 
 func (buffer *Buffer) insertLines(count int) {
@@ -408,6 +422,30 @@ func (buffer *Buffer) write(runes ...MeasuredRune) {
 
 	for _, r := range runes {
 
+		// characters which only modify the one before them, such as combining accents, are kept with it
+		if r.Width == 0 {
+			buffer.combine(r.Rune)
+			continue
+		}
+
+		if r.Width == 2 && buffer.Width() < 2 {
+			r.Width = 1
+		}
+
+		// a wide character which doesn't fit in the last column is moved onto the next line, or back a column
+		// if wrapping is disabled
+		if r.Width == 2 && buffer.CursorColumn() == buffer.Width()-1 {
+			if buffer.modes.AutoWrap {
+				// the last column is marked so that the line is still joined to the next one when it is copied
+				line := buffer.getCurrentLine()
+				buffer.putRune(line, buffer.CursorColumn(), MeasuredRune{Rune: 0})
+				line.cells[buffer.CursorColumn()].padding = true
+				buffer.cursorPosition.Col = buffer.Width()
+			} else {
+				buffer.cursorPosition.Col--
+			}
+		}
+
 		line := buffer.getCurrentLine()
 
 		if buffer.modes.ReplaceMode {
@@ -417,6 +455,7 @@ func (buffer *Buffer) write(runes ...MeasuredRune) {
 					buffer.cursorPosition.Line++
 					buffer.cursorPosition.Col = 0
 					line = buffer.getCurrentLine()
+					line.softWrapped = true
 
 				} else {
 					// no more room on line and wrapping is disabled
@@ -424,12 +463,8 @@ func (buffer *Buffer) write(runes ...MeasuredRune) {
 				}
 			}
 
-			for int(buffer.CursorColumn()) >= len(line.cells) {
-				line.append(buffer.defaultCell(int(buffer.CursorColumn()) == len(line.cells)))
-			}
-			line.cells[buffer.cursorPosition.Col].attr = buffer.cursorAttr
-			line.cells[buffer.cursorPosition.Col].setRune(r)
-			buffer.incrementCursorPosition()
+			buffer.putRune(line, buffer.CursorColumn(), r)
+			buffer.advanceCursor(r)
 			continue
 		}
 
@@ -438,14 +473,8 @@ func (buffer *Buffer) write(runes ...MeasuredRune) {
 			if buffer.modes.AutoWrap {
 
 				buffer.newLineEx(true)
-
-				newLine := buffer.getCurrentLine()
-				if len(newLine.cells) == 0 {
-					newLine.append(buffer.defaultCell(true))
-				}
-				cell := &newLine.cells[0]
-				cell.setRune(r)
-				cell.attr = buffer.cursorAttr
+				buffer.getCurrentLine().softWrapped = true
+				buffer.putRune(buffer.getCurrentLine(), 0, r)
 
 			} else {
 				// no more room on line and wrapping is disabled
@@ -453,16 +482,68 @@ func (buffer *Buffer) write(runes ...MeasuredRune) {
 			}
 
 		} else {
+			buffer.putRune(line, buffer.CursorColumn(), r)
+		}
 
-			for int(buffer.CursorColumn()) >= len(line.cells) {
-				line.append(buffer.defaultCell(int(buffer.CursorColumn()) == len(line.cells)))
-			}
+		buffer.advanceCursor(r)
+	}
+}
 
-			cell := &line.cells[buffer.CursorColumn()]
-			cell.setRune(r)
-			cell.attr = buffer.cursorAttr
-		}
+// combine attaches a zero width character to the character before the cursor, if there is one on the line
+func (buffer *Buffer) combine(r rune) {
+	line := buffer.getCurrentLine()
+	col := int(buffer.CursorColumn())
+	if col > len(line.cells) {
+		col = len(line.cells)
+	}
+	col--
+	if col >= 0 && line.cells[col].continuation {
+		col--
+	}
+	if col < 0 || line.cells[col].r.Rune == 0 {
+		return
+	}
+	cell := &line.cells[col]
+	if len(cell.combining) >= maxCombining {
+		return
+	}
+	// cells are copied by value, so the runes are copied rather than appended in place
+	cell.combining = append(cell.combining[:len(cell.combining):len(cell.combining)], r)
+	line.touch()
+}
 
+// putRune sets the rune of a cell in the line, covering the cell after it as well if the rune is wide. Any wide
+// character which is partly overwritten is removed.
+func (buffer *Buffer) putRune(line *Line, col uint16, r MeasuredRune) {
+
+	last := int(col)
+	if r.Width == 2 {
+		last++
+	}
+	for last >= len(line.cells) {
+		line.append(buffer.defaultCell(int(col) == len(line.cells)))
+	}
+
+	line.clearWide(int(col))
+	cell := &line.cells[col]
+	cell.setRune(r)
+	cell.attr = buffer.cursorAttr
+
+	if r.Width == 2 {
+		line.clearWide(int(col) + 1)
+		next := &line.cells[col+1]
+		next.setRune(MeasuredRune{Rune: 0})
+		next.attr = buffer.cursorAttr
+		next.continuation = true
+	}
+
+	line.touch()
+}
+
+// advanceCursor moves the cursor past a rune which has just been written
+func (buffer *Buffer) advanceCursor(r MeasuredRune) {
+	buffer.incrementCursorPosition()
+	if r.Width == 2 {
 		buffer.incrementCursorPosition()
 	}
 }
@@ -636,6 +717,26 @@ func (buffer *Buffer) GetVisibleLines() []Line {
 	return lines
 }
 
+// GetViewCells returns a copy of the cells of each line currently in view
+func (buffer *Buffer) GetViewCells() [][]Cell {
+	return copyCells(buffer.GetVisibleLines())
+}
+
+// GetAllCells returns a copy of the cells of every line in the buffer, including the scrollback
+func (buffer *Buffer) GetAllCells() [][]Cell {
+	return copyCells(buffer.lines)
+}
+
+func copyCells(lines []Line) [][]Cell {
+	output := make([][]Cell, 0, len(lines))
+	for _, line := range lines {
+		cells := make([]Cell, len(line.cells))
+		copy(cells, line.cells)
+		output = append(output, cells)
+	}
+	return output
+}
+
 // tested to here
 
 func (buffer *Buffer) clear() {
@@ -684,6 +785,8 @@ func (buffer *Buffer) eraseLine() {
 			line.cells[i] = buffer.defaultCell(false)
 		}
 	}
+	line.softWrapped = false
+	line.touch()
 }
 
 func (buffer *Buffer) eraseLineToCursor() {
@@ -694,6 +797,7 @@ func (buffer *Buffer) eraseLineToCursor() {
 			line.cells[i].erase(buffer.cursorAttr.bgColour)
 		}
 	}
+	line.touch()
 }
 
 func (buffer *Buffer) eraseLineFromCursor() {
@@ -707,6 +811,7 @@ func (buffer *Buffer) eraseLineFromCursor() {
 			line.cells[i] = buffer.defaultCell(false)
 		}
 	}
+	line.touch()
 }
 
 func (buffer *Buffer) eraseDisplay() {
@@ -714,7 +819,7 @@ func (buffer *Buffer) eraseDisplay() {
 		rawLine := buffer.convertViewLineToRawLine(i)
 		buffer.clearSixelsAtRawLine(rawLine)
 		if int(rawLine) < len(buffer.lines) {
-			buffer.lines[int(rawLine)].cells = []Cell{}
+			buffer.lines[int(rawLine)].clear()
 		}
 	}
 }
@@ -731,6 +836,7 @@ func (buffer *Buffer) deleteChars(n int) {
 	}
 	after := line.cells[int(buffer.cursorPosition.Col)+n:]
 	line.cells = append(before, after...)
+	line.touch()
 }
 
 func (buffer *Buffer) eraseCharacters(n int) {
@@ -745,6 +851,7 @@ func (buffer *Buffer) eraseCharacters(n int) {
 	for i := int(buffer.cursorPosition.Col); i < max; i++ {
 		line.cells[i].erase(buffer.cursorAttr.bgColour)
 	}
+	line.touch()
 }
 
 func (buffer *Buffer) eraseDisplayFromCursor() {
@@ -756,10 +863,11 @@ func (buffer *Buffer) eraseDisplayFromCursor() {
 	}
 
 	line.cells = line.cells[:max]
+	line.touch()
 
 	for rawLine := buffer.cursorPosition.Line + 1; int(rawLine) < len(buffer.lines); rawLine++ {
 		buffer.clearSixelsAtRawLine(rawLine)
-		buffer.lines[int(rawLine)].cells = []Cell{}
+		buffer.lines[int(rawLine)].clear()
 	}
 }
 
@@ -772,6 +880,7 @@ func (buffer *Buffer) eraseDisplayToCursor() {
 		}
 		line.cells[i].erase(buffer.cursorAttr.bgColour)
 	}
+	line.touch()
 
 	cursorVY := buffer.convertRawLineToViewLine(buffer.cursorPosition.Line)
 
@@ -779,7 +888,7 @@ func (buffer *Buffer) eraseDisplayToCursor() {
 		rawLine := buffer.convertViewLineToRawLine(i)
 		buffer.clearSixelsAtRawLine(rawLine)
 		if int(rawLine) < len(buffer.lines) {
-			buffer.lines[int(rawLine)].cells = []Cell{}
+			buffer.lines[int(rawLine)].clear()
 		}
 	}
 }
@@ -870,6 +979,47 @@ func (buffer *Buffer) ScrollToEnd() {
 	buffer.scrollLinesFromBottom = 0
 }
 
+// touchAll records that the content of every line has changed, e.g. when the theme changes
+func (buffer *Buffer) touchAll() {
+	for i := range buffer.lines {
+		buffer.lines[i].touch()
+	}
+}
+
+// ClearScrollback discards every line which has scrolled out of the top of the view
+func (buffer *Buffer) ClearScrollback() {
+	excess := len(buffer.lines) - int(buffer.viewHeight)
+	if excess <= 0 {
+		return
+	}
+
+	buffer.lines = buffer.lines[excess:]
+	if buffer.cursorPosition.Line >= uint64(excess) {
+		buffer.cursorPosition.Line -= uint64(excess)
+	} else {
+		buffer.cursorPosition.Line = 0
+	}
+
+	var sixels []Sixel
+	for _, sixelImage := range buffer.sixels {
+		if sixelImage.Y+sixelImage.Height <= uint64(excess) {
+			continue
+		}
+		if sixelImage.Y >= uint64(excess) {
+			sixelImage.Y -= uint64(excess)
+		} else {
+			sixelImage.Y = 0
+		}
+		sixels = append(sixels, sixelImage)
+	}
+	buffer.sixels = sixels
+
+	buffer.ClearSelection()
+	buffer.ClearHighlight()
+	buffer.ClearSearch()
+	buffer.ScrollToEnd()
+}
+
 func (buffer *Buffer) ScrollUp(lines uint) {
 	if int(buffer.scrollLinesFromBottom)+int(lines) < len(buffer.lines)-int(buffer.viewHeight) {
 		buffer.scrollLinesFromBottom += lines
@@ -1,7 +1,6 @@
 package termutil
 
 import (
-	"image/color"
 	"strings"
 	"testing"
 
@@ -614,7 +613,7 @@ goodbyegoodbye`
 }
 
 func TestBufferMaxLines(t *testing.T) {
-	b := NewBuffer(80, 2, 2, color.White, color.Black)
+	b := NewBuffer(80, 2, 2)
 	b.modes.LineFeedMode = false
 
 	writeRaw(b, []rune("hello")...)
@@ -628,6 +627,26 @@ func TestBufferMaxLines(t *testing.T) {
 	assert.Equal(t, "world", b.lines[1].String())
 }
 
+func TestClearScrollback(t *testing.T) {
+	b := makeBufferForTesting(80, 2)
+	b.modes.LineFeedMode = false
+
+	writeRaw(b, []rune("hello")...)
+	b.newLine()
+	writeRaw(b, []rune("funny")...)
+	b.newLine()
+	writeRaw(b, []rune("world")...)
+	b.ScrollUp(1)
+
+	b.ClearScrollback()
+
+	assert.Equal(t, 2, len(b.lines))
+	assert.Equal(t, "funny", b.lines[0].String())
+	assert.Equal(t, "world", b.lines[1].String())
+	assert.Equal(t, uint64(1), b.cursorPosition.Line)
+	assert.Equal(t, uint(0), b.GetScrollOffset())
+}
+
 func TestShrinkingThenGrowing(t *testing.T) {
 	b := makeBufferForTesting(30, 100)
 	writeRaw(b, []rune("hellohellohellohellohello")...)
@@ -683,6 +702,131 @@ func TestShrinkingThenRestoring(t *testing.T) {
 	require.Equal(t, expected, strings.Join(strs, "\n"))
 }
 
+func writeMeasured(buf *Buffer, text string) {
+	for _, r := range text {
+		buf.write(MeasuredRune{Rune: r, Width: RuneWidth(r)})
+	}
+}
+
+func TestWideCharacters(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	writeMeasured(b, "a😀b漢")
+
+	assert.Equal(t, uint16(6), b.CursorColumn())
+	line := b.getCurrentLine()
+	require.Len(t, line.cells, 6)
+	assert.True(t, line.cells[1].Wide())
+	assert.True(t, line.cells[2].Continuation())
+	assert.Equal(t, 'b', line.cells[3].Rune().Rune)
+	assert.True(t, line.cells[4].Wide())
+	assert.True(t, line.cells[5].Continuation())
+	assert.Equal(t, "a😀b漢", line.String())
+	assert.Equal(t, "a😀b漢", CellsToString(line.cells))
+}
+
+func TestWideCharacterWrapsWhenOneColumnIsLeft(t *testing.T) {
+	b := makeBufferForTesting(5, 3)
+	writeMeasured(b, "abcd😀")
+
+	lines := b.GetVisibleLines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, "abcd", lines[0].String())
+	assert.Equal(t, "😀", lines[1].String())
+	assert.Equal(t, uint16(2), b.CursorColumn())
+}
+
+func TestWideCharacterWithoutAutoWrap(t *testing.T) {
+	b := makeBufferForTesting(5, 3)
+	b.modes.AutoWrap = false
+	writeMeasured(b, "abcd😀")
+
+	lines := b.GetVisibleLines()
+	require.Len(t, lines, 1)
+	assert.Equal(t, "abc😀", lines[0].String())
+}
+
+func TestZeroWidthCharacters(t *testing.T) {
+	assert.Equal(t, 0, RuneWidth('\u200d'))
+	assert.Equal(t, 0, RuneWidth('\ufe0f'))
+	assert.Equal(t, 0, RuneWidth('\ufe00'))
+	assert.Equal(t, 0, RuneWidth('\u0301'))
+	assert.Equal(t, 0, RuneWidth('\u20dd'))
+	assert.Equal(t, 0, RuneWidth('\u0e31'))
+	assert.Equal(t, 1, RuneWidth('\u2764'))
+	assert.Equal(t, 2, RuneWidth('😀'))
+
+	// the joined characters are drawn on their own, and the joiner and variation selector are kept with the
+	// character before them
+	b := makeBufferForTesting(10, 3)
+	writeMeasured(b, "👨\u200d👩 \u2764\ufe0f!")
+
+	assert.Equal(t, uint16(7), b.CursorColumn())
+	assert.Equal(t, "👨\u200d👩 \u2764\ufe0f!", CellsToString(b.getCurrentLine().cells))
+	assert.Equal(t, []rune{'\u200d'}, b.getCurrentLine().cells[0].Combining())
+	assert.Equal(t, []rune{'\ufe0f'}, b.getCurrentLine().cells[5].Combining())
+}
+
+func TestCombiningCharacters(t *testing.T) {
+	b := makeBufferForTesting(4, 3)
+	writeMeasured(b, "e\u0301x日\u0302")
+
+	line := b.getCurrentLine()
+	assert.Equal(t, uint16(4), b.CursorColumn())
+	assert.Equal(t, 'e', line.cells[0].Rune().Rune)
+	assert.Equal(t, []rune{'\u0301'}, line.cells[0].Combining())
+	assert.Empty(t, line.cells[1].Combining())
+	assert.Equal(t, []rune{'\u0302'}, line.cells[2].Combining(), "marks after a wide character are kept with it")
+	assert.Equal(t, "e\u0301x日\u0302", line.String())
+
+	// the line is full, but the mark still belongs to the last character rather than the next line
+	b.cursorPosition.Col = 0
+	writeMeasured(b, "abcd\u0303")
+	assert.Equal(t, "abcd\u0303", CellsToString(b.getCurrentLine().cells))
+
+	// overwriting a character removes its marks, and marks with nothing before them are dropped
+	b.carriageReturn()
+	writeMeasured(b, "\u0304ab")
+	assert.Equal(t, "abcd\u0303", CellsToString(b.getCurrentLine().cells))
+	b.carriageReturn()
+	writeMeasured(b, "abcd")
+	assert.Equal(t, "abcd", CellsToString(b.getCurrentLine().cells))
+}
+
+func TestCombiningCharactersAreLimited(t *testing.T) {
+	b := makeBufferForTesting(4, 3)
+	writeMeasured(b, "a"+strings.Repeat("\u0301", maxCombining*2))
+	assert.Len(t, b.getCurrentLine().cells[0].Combining(), maxCombining)
+}
+
+func TestOverwritingHalfOfWideCharacter(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	writeMeasured(b, "😀😀")
+
+	// overwrite the second half of the first character and the first half of the second
+	b.cursorPosition.Col = 1
+	writeMeasured(b, "xy")
+
+	line := b.getCurrentLine()
+	for i, cell := range line.cells {
+		assert.False(t, cell.Wide(), "cell %d", i)
+		assert.False(t, cell.Continuation(), "cell %d", i)
+	}
+	assert.Equal(t, " xy ", CellsToString(line.cells))
+}
+
+func TestShrinkKeepsWideCharacters(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	writeMeasured(b, "😀")
+	b.getCurrentLine().append(b.defaultCell(false), b.defaultCell(false))
+
+	b.resizeView(2, 3)
+
+	line := b.getCurrentLine()
+	require.Len(t, line.cells, 2)
+	assert.True(t, line.cells[0].Wide())
+	assert.True(t, line.cells[1].Continuation())
+}
+
 func makeBufferForTesting(cols, rows uint16) *Buffer {
-	return NewBuffer(cols, rows, 100, color.White, color.Black)
+	return NewBuffer(cols, rows, 100)
 }
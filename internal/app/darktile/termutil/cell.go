@@ -1,10 +1,11 @@
 package termutil
 
-import "image/color"
-
 type Cell struct {
-	r    MeasuredRune
-	attr CellAttributes
+	r            MeasuredRune
+	attr         CellAttributes
+	continuation bool   // covered by the wide character in the cell before
+	padding      bool   // left blank at the end of a line by a wide character which was wrapped onto the next line
+	combining    []rune // zero width characters written after the rune, such as combining accents
 }
 
 func (cell *Cell) Attr() CellAttributes {
@@ -15,11 +16,28 @@ func (cell *Cell) Rune() MeasuredRune {
 	return cell.r
 }
 
-func (cell *Cell) Fg() color.Color {
+// Combining returns the zero width characters which modify the cell's rune, such as combining accents, joiners
+// and variation selectors, in the order they were written
+func (cell *Cell) Combining() []rune {
+	return cell.combining
+}
+
+// Wide returns true if the cell holds a wide character, which also covers the cell after it
+func (cell *Cell) Wide() bool {
+	return cell.r.Width == 2
+}
+
+// Continuation returns true if the cell is covered by the wide character in the cell before it
+func (cell *Cell) Continuation() bool {
+	return cell.continuation
+}
+
+// Fg returns the colour of the cell's text, which can be drawn with Theme.Resolve
+func (cell *Cell) Fg() CellColour {
 	if cell.Attr().inverse {
-		return cell.attr.bgColour
+		return cell.attr.bgColour.or(DefaultBackgroundColour)
 	}
-	return cell.attr.fgColour
+	return cell.attr.fgColour.or(DefaultForegroundColour)
 }
 
 func (cell *Cell) Bold() bool {
@@ -42,18 +60,22 @@ func (cell *Cell) Strikethrough() bool {
 	return cell.attr.strikethrough
 }
 
-func (cell *Cell) Bg() color.Color {
+// Bg returns the colour of the cell's background, which can be drawn with Theme.Resolve
+func (cell *Cell) Bg() CellColour {
 	if cell.Attr().inverse {
-		return cell.attr.fgColour
+		return cell.attr.fgColour.or(DefaultForegroundColour)
 	}
-	return cell.attr.bgColour
+	return cell.attr.bgColour.or(DefaultBackgroundColour)
 }
 
-func (cell *Cell) erase(bgColour color.Color) {
+func (cell *Cell) erase(bgColour CellColour) {
 	cell.setRune(MeasuredRune{Rune: 0})
 	cell.attr.bgColour = bgColour
 }
 
 func (cell *Cell) setRune(r MeasuredRune) {
 	cell.r = r
+	cell.combining = nil
+	cell.continuation = false
+	cell.padding = false
 }
@@ -1,12 +1,8 @@
 package termutil
 
-import (
-	"image/color"
-)
-
 type CellAttributes struct {
-	fgColour      color.Color
-	bgColour      color.Color
+	fgColour      CellColour
+	bgColour      CellColour
 	bold          bool
 	italic        bool
 	dim           bool
@@ -0,0 +1,93 @@
+package termutil
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+)
+
+// CellColour is the colour of a cell as it was set by the application - the default foreground or background, an
+// entry of the 256 colour palette, or a true colour. Default and palette colours are looked up in the theme when the
+// cell is drawn, so that existing cells follow the theme when it changes.
+type CellColour struct {
+	kind  cellColourKind
+	index uint8
+	rgb   color.RGBA
+}
+
+type cellColourKind uint8
+
+const (
+	colourUnset cellColourKind = iota // the default colour for where it is stored, i.e. foreground or background
+	colourDefaultForeground
+	colourDefaultBackground
+	colourPalette
+	colourRGB
+)
+
+var (
+	DefaultForegroundColour = CellColour{kind: colourDefaultForeground}
+	DefaultBackgroundColour = CellColour{kind: colourDefaultBackground}
+)
+
+// PaletteColour returns the colour at the given index of the 256 colour palette, where the first 16 are the named
+// ANSI colours
+func PaletteColour(index uint8) CellColour {
+	return CellColour{kind: colourPalette, index: index}
+}
+
+// RGBColour returns a true colour, which is drawn the same whatever the theme
+func RGBColour(c color.Color) CellColour {
+	return CellColour{kind: colourRGB, rgb: color.RGBAModel.Convert(c).(color.RGBA)}
+}
+
+// PaletteIndex returns the index of the colour in the 256 colour palette, if it is a palette colour
+func (c CellColour) PaletteIndex() (uint8, bool) {
+	return c.index, c.kind == colourPalette
+}
+
+// or returns the given colour if this one is unset
+func (c CellColour) or(fallback CellColour) CellColour {
+	if c.kind == colourUnset {
+		return fallback
+	}
+	return c
+}
+
+// cellColourFromAnsi parses the parameters of an extended colour, e.g. "5;208" or "2;10;200;250"
+func cellColourFromAnsi(ansi []string) (CellColour, error) {
+
+	if len(ansi) == 0 {
+		return CellColour{}, fmt.Errorf("invalid ansi colour code")
+	}
+
+	switch ansi[0] {
+	case "2":
+		if len(ansi) != 4 {
+			return CellColour{}, fmt.Errorf("invalid 24-bit ansi colour code")
+		}
+		var rgb [3]uint8
+		for i := range rgb {
+			value, err := strconv.Atoi(ansi[i+1])
+			if err != nil {
+				return CellColour{}, err
+			}
+			rgb[i] = byte(value)
+		}
+		return RGBColour(color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 0xff}), nil
+	case "5":
+		if len(ansi) != 2 {
+			return CellColour{}, fmt.Errorf("invalid 8-bit ansi colour code")
+		}
+		index, err := strconv.Atoi(ansi[1])
+		if err != nil {
+			return CellColour{}, err
+		}
+		if index < 0 || index > 255 {
+			return CellColour{}, fmt.Errorf("invalid 8-bit colour index %d", index)
+		}
+		return PaletteColour(uint8(index)), nil
+	default:
+		return CellColour{}, fmt.Errorf("invalid ansi colour code")
+	}
+}
@@ -0,0 +1,68 @@
+package termutil
+
+import (
+	"image/color"
+	"math"
+)
+
+// relativeLuminance calculates the luminance of a colour as defined by WCAG 2.0
+func relativeLuminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	linear := func(channel uint32) float64 {
+		v := float64(channel) / 0xffff
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+// ContrastRatio calculates the WCAG contrast ratio between two colours, from 1 (no contrast) to 21 (black on white)
+func ContrastRatio(a color.Color, b color.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// EnsureContrast returns the foreground colour, lightened or darkened as little as possible so that it reaches the
+// minimum contrast ratio against the background. If the ratio cannot be reached, the best available colour is returned.
+func EnsureContrast(fg color.Color, bg color.Color, minimum float64) color.Color {
+	if minimum <= 1 || ContrastRatio(fg, bg) >= minimum {
+		return fg
+	}
+
+	// move towards whichever of black and white contrasts most with the background
+	var target color.Color = color.White
+	if ContrastRatio(color.Black, bg) > ContrastRatio(color.White, bg) {
+		target = color.Black
+	}
+
+	low, high := 0.0, 1.0
+	for i := 0; i < 16; i++ {
+		mid := (low + high) / 2
+		if ContrastRatio(mix(fg, target, mid), bg) >= minimum {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return mix(fg, target, high)
+}
+
+// mix blends from a towards b by the given amount, from 0 (a) to 1 (b)
+func mix(a color.Color, b color.Color, amount float64) color.Color {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+	blend := func(x uint32, y uint32) uint8 {
+		return uint8(math.Round((float64(x) + (float64(y)-float64(x))*amount) / 0xffff * 0xff))
+	}
+	return color.RGBA{
+		R: blend(ar, br),
+		G: blend(ag, bg),
+		B: blend(ab, bb),
+		A: uint8(aa >> 8),
+	}
+}
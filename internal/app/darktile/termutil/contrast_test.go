@@ -0,0 +1,32 @@
+package termutil
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContrastRatio(t *testing.T) {
+	assert.InDelta(t, 21, ContrastRatio(color.Black, color.White), 0.001)
+	assert.InDelta(t, 21, ContrastRatio(color.White, color.Black), 0.001)
+	assert.InDelta(t, 1, ContrastRatio(color.RGBA{R: 0x80, A: 0xff}, color.RGBA{R: 0x80, A: 0xff}), 0.001)
+}
+
+func TestEnsureContrast(t *testing.T) {
+	black := color.RGBA{A: 0xff}
+	blue := color.RGBA{B: 0x80, A: 0xff}
+
+	adjusted := EnsureContrast(blue, black, 4.5)
+	assert.GreaterOrEqual(t, ContrastRatio(adjusted, black), 4.5)
+	assert.Less(t, ContrastRatio(adjusted, black), 4.7, "colour should only change as much as needed")
+
+	grey := color.RGBA{R: 0x90, G: 0x90, B: 0x90, A: 0xff}
+	lightGrey := color.RGBA{R: 0xa0, G: 0xa0, B: 0xa0, A: 0xff}
+	adjusted = EnsureContrast(grey, lightGrey, 3)
+	assert.GreaterOrEqual(t, ContrastRatio(adjusted, lightGrey), 3.0)
+
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	assert.Equal(t, white, EnsureContrast(white, black, 4.5))
+	assert.Equal(t, blue, EnsureContrast(blue, black, 1))
+}
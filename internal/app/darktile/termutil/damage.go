@@ -0,0 +1,43 @@
+package termutil
+
+// Damage tracks which rows of the view have changed since they were last drawn, so that a renderer only needs to
+// redraw those rows. It must only be used while the terminal is locked.
+type Damage struct {
+	buffer   *Buffer
+	width    uint16
+	versions []uint64 // version of the line drawn in each row of the view
+	rows     []uint16
+}
+
+// Invalidate causes every row to be reported as changed by the next call to Collect, e.g. after the theme or font
+// has changed
+func (d *Damage) Invalidate() {
+	d.versions = d.versions[:0]
+}
+
+// Collect returns the rows of the view which have changed since the previous call, and records them as drawn.
+// Every row is returned after the buffer is switched, the view is resized or Invalidate is called. The returned
+// slice is reused by the next call.
+func (d *Damage) Collect(buffer *Buffer) []uint16 {
+
+	height := buffer.ViewHeight()
+	full := d.buffer != buffer || d.width != buffer.ViewWidth() || len(d.versions) != int(height)
+	if full {
+		d.buffer = buffer
+		d.width = buffer.ViewWidth()
+		if cap(d.versions) < int(height) {
+			d.versions = make([]uint64, height)
+		}
+		d.versions = d.versions[:height]
+	}
+
+	d.rows = d.rows[:0]
+	for row := uint16(0); row < height; row++ {
+		version := buffer.lineVersion(row)
+		if full || d.versions[row] != version {
+			d.versions[row] = version
+			d.rows = append(d.rows, row)
+		}
+	}
+	return d.rows
+}
@@ -0,0 +1,164 @@
+package termutil
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeToTerminal(term *Terminal, text string) {
+	runes := make([]MeasuredRune, 0, len(text))
+	for _, r := range text {
+		runes = append(runes, MeasuredRune{Rune: r, Width: 1})
+	}
+	term.processRunes(runes...)
+}
+
+func TestDamageReportsEveryRowInitially(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	var damage Damage
+	assert.Equal(t, []uint16{0, 1, 2}, damage.Collect(b))
+	assert.Empty(t, damage.Collect(b))
+}
+
+func TestDamageReportsChangedRows(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	writeRaw(b, 'a')
+	b.newLine()
+	writeRaw(b, 'b')
+	var damage Damage
+	damage.Collect(b)
+
+	writeRaw(b, 'c')
+	assert.Equal(t, []uint16{1}, damage.Collect(b))
+
+	b.carriageReturn()
+	b.eraseLine()
+	assert.Equal(t, []uint16{1}, damage.Collect(b))
+
+	b.movePosition(0, -1)
+	b.deleteChars(1)
+	assert.Equal(t, []uint16{0}, damage.Collect(b))
+}
+
+func TestDamageReportsMovedRows(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	writeRaw(b, 'a')
+	b.newLine()
+	writeRaw(b, 'b')
+	b.newLine()
+	writeRaw(b, 'c')
+	var damage Damage
+	damage.Collect(b)
+
+	// scrolling moves every line up a row
+	b.newLine()
+	assert.Equal(t, []uint16{0, 1, 2}, damage.Collect(b))
+
+	b.ScrollUp(1)
+	assert.Equal(t, []uint16{0, 1, 2}, damage.Collect(b))
+	b.ScrollDown(1)
+	assert.Equal(t, []uint16{0, 1, 2}, damage.Collect(b))
+	assert.Empty(t, damage.Collect(b))
+}
+
+func TestDamageReportsEveryRowAfterResizeOrInvalidate(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	writeRaw(b, 'a')
+	var damage Damage
+	damage.Collect(b)
+
+	damage.Invalidate()
+	assert.Equal(t, []uint16{0, 1, 2}, damage.Collect(b))
+
+	b.resizeView(5, 2)
+	assert.Equal(t, []uint16{0, 1}, damage.Collect(b))
+}
+
+func TestDamageReportsEveryRowForAnotherBuffer(t *testing.T) {
+	term := New()
+	term.GetActiveBuffer().resizeView(10, 3)
+	var damage Damage
+	damage.Collect(term.GetActiveBuffer())
+
+	term.useAltBuffer()
+	term.GetActiveBuffer().resizeView(10, 3)
+	assert.Len(t, damage.Collect(term.GetActiveBuffer()), 3)
+}
+
+func TestDamageReportsRecolouredRows(t *testing.T) {
+	term := New()
+	buffer := term.GetActiveBuffer()
+	buffer.resizeView(10, 3)
+	writeToTerminal(term, "\x1b[31mred\x1b[0m")
+	var damage Damage
+	damage.Collect(buffer)
+
+	term.SetTheme(NewThemeFactory().WithColour(ColourRed, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}).Build())
+	assert.Equal(t, []uint16{0}, damage.Collect(buffer))
+}
+
+// BenchmarkDamageIdle measures the work done on a frame where nothing has changed
+func BenchmarkDamageIdle(b *testing.B) {
+	term := New()
+	buffer := term.GetActiveBuffer()
+	buffer.resizeView(200, 60)
+	for i := 0; i < 60; i++ {
+		writeToTerminal(term, fmt.Sprintf("line %d of output\r\n", i))
+	}
+	var damage Damage
+	damage.Collect(buffer)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var rows int
+	for i := 0; i < b.N; i++ {
+		term.Lock()
+		rows += len(damage.Collect(buffer))
+		term.Unlock()
+	}
+	b.ReportMetric(float64(rows)/float64(b.N), "rows/frame")
+}
+
+// BenchmarkDamageScrolling measures the work done on a frame after a line of output has scrolled the view
+func BenchmarkDamageScrolling(b *testing.B) {
+	term := New()
+	buffer := term.GetActiveBuffer()
+	buffer.resizeView(200, 60)
+	var damage Damage
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var rows int
+	for i := 0; i < b.N; i++ {
+		writeToTerminal(term, "a line of scrolling output\r\n")
+		term.Lock()
+		rows += len(damage.Collect(buffer))
+		term.Unlock()
+	}
+	b.ReportMetric(float64(rows)/float64(b.N), "rows/frame")
+}
+
+// BenchmarkDamageTyping measures the work done on a frame after a character has been typed at a prompt
+func BenchmarkDamageTyping(b *testing.B) {
+	term := New()
+	buffer := term.GetActiveBuffer()
+	buffer.resizeView(200, 60)
+	var damage Damage
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var rows int
+	for i := 0; i < b.N; i++ {
+		if i%100 == 0 {
+			writeToTerminal(term, "\r\n$ ")
+		}
+		writeToTerminal(term, "x")
+		term.Lock()
+		rows += len(damage.Collect(buffer))
+		term.Unlock()
+	}
+	b.ReportMetric(float64(rows)/float64(b.N), "rows/frame")
+}
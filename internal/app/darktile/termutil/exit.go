@@ -0,0 +1,45 @@
+package termutil
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ExitStatus describes how the program running in the terminal exited
+type ExitStatus struct {
+	Code   int            // the exit code, or -1 if the program was killed by a signal
+	Signal syscall.Signal // the signal which killed the program, or 0
+}
+
+func exitStatusOf(state *os.ProcessState) ExitStatus {
+	if state == nil {
+		return ExitStatus{Code: -1}
+	}
+	status := ExitStatus{Code: state.ExitCode()}
+	if waitStatus, ok := state.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+		status.Signal = waitStatus.Signal()
+	}
+	return status
+}
+
+// Success returns true if the program exited normally with a zero exit code
+func (s ExitStatus) Success() bool {
+	return s.Code == 0 && s.Signal == 0
+}
+
+// ShellCode returns the exit status as a shell reports it, where a program killed by a signal has a status of
+// 128 plus the signal number
+func (s ExitStatus) ShellCode() int {
+	if s.Signal != 0 {
+		return 128 + int(s.Signal)
+	}
+	return s.Code
+}
+
+func (s ExitStatus) String() string {
+	if s.Signal != 0 {
+		return fmt.Sprintf("killed by signal %d (%s)", int(s.Signal), s.Signal)
+	}
+	return fmt.Sprintf("exited with code %d", s.Code)
+}
@@ -1,19 +1,41 @@
 package termutil
 
-import "strings"
+import (
+	"strings"
+	"sync/atomic"
+)
 
 type Line struct {
-	wrapped bool // whether line was wrapped onto from the previous one
-	cells   []Cell
+	wrapped     bool   // whether line was wrapped onto from the previous one
+	softWrapped bool   // whether the cursor wrapped onto the line from the end of the previous one while writing
+	version     uint64 // changes whenever the content of the line changes, see Damage
+	cells       []Cell
 }
 
+// lineVersions is the last version given to a line. Versions are unique across all buffers, so a line's version
+// identifies its content even after it has moved to another row.
+var lineVersions uint64
+
 func newLine() Line {
 	return Line{
 		wrapped: false,
+		version: atomic.AddUint64(&lineVersions, 1),
 		cells:   []Cell{},
 	}
 }
 
+// clear removes the content of the line, which no longer continues the line above it
+func (line *Line) clear() {
+	line.cells = []Cell{}
+	line.softWrapped = false
+	line.touch()
+}
+
+// touch records that the content of the line has changed
+func (line *Line) touch() {
+	line.version = atomic.AddUint64(&lineVersions, 1)
+}
+
 func (line *Line) Len() uint16 {
 	return uint16(len(line.cells))
 }
@@ -21,13 +43,34 @@ func (line *Line) Len() uint16 {
 func (line *Line) String() string {
 	runes := []rune{}
 	for _, cell := range line.cells {
+		if cell.continuation {
+			continue
+		}
 		runes = append(runes, cell.r.Rune)
+		runes = append(runes, cell.combining...)
 	}
 	return strings.TrimRight(string(runes), "\x00")
 }
 
 func (line *Line) append(cells ...Cell) {
 	line.cells = append(line.cells, cells...)
+	line.touch()
+}
+
+// clearWide removes the wide character covering the cell at the given column, if there is one, so that the cell
+// can be overwritten without leaving half of the character behind
+func (line *Line) clearWide(col int) {
+	if col >= len(line.cells) {
+		return
+	}
+	cell := &line.cells[col]
+	switch {
+	case cell.continuation && col > 0:
+		line.cells[col-1].setRune(MeasuredRune{Rune: 0})
+		cell.continuation = false
+	case cell.Wide() && col+1 < len(line.cells):
+		line.cells[col+1].continuation = false
+	}
 }
 
 func (line *Line) shrink(width uint16) {
@@ -37,21 +80,23 @@ func (line *Line) shrink(width uint16) {
 	remove := line.Len() - width
 	var cells []Cell
 	for _, cell := range line.cells {
-		if cell.r.Rune == 0 && remove > 0 {
+		if cell.r.Rune == 0 && !cell.continuation && remove > 0 {
 			remove--
 		} else {
 			cells = append(cells, cell)
 		}
 	}
 	line.cells = cells
+	line.touch()
 }
 
 func (line *Line) wrap(width uint16) []Line {
 
 	var output []Line
-	var current Line
+	current := newLine()
 
 	current.wrapped = line.wrapped
+	current.softWrapped = line.softWrapped
 
 	for _, cell := range line.cells {
 		if len(current.cells) == int(width) {
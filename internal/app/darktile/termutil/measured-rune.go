@@ -1,6 +1,54 @@
 package termutil
 
+import (
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+const (
+	zeroWidthJoiner = '\u200d'
+	// maxCombining is the most zero width characters kept with a cell, so that a stream of them can't grow it forever
+	maxCombining = 16
+)
+
+// MeasuredRune is a rune along with the number of cells it occupies
 type MeasuredRune struct {
 	Rune  rune
 	Width int
 }
+
+// RuneWidth returns the number of cells a rune occupies - two for wide characters such as CJK ideographs and emoji,
+// none for the combining marks, joiners and variation selectors which modify the character before them, and one
+// for everything else
+func RuneWidth(r rune) int {
+	if r < 0x300 {
+		return 1
+	}
+	if r == zeroWidthJoiner || unicode.In(r, unicode.Mn, unicode.Me) {
+		return 0
+	}
+	if r < 0x1100 {
+		return 1
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// StringWidth returns the number of cells a string occupies when written to the terminal
+func StringWidth(s string) int {
+	var cells int
+	for _, r := range s {
+		cells += RuneWidth(r)
+	}
+	return cells
+}
+
+// IsCombiningMark returns true if the rune is drawn over the character before it, such as an accent. Other zero
+// width characters, such as joiners and variation selectors, aren't drawn at all.
+func IsCombiningMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me) && !unicode.Is(unicode.Variation_Selector, r)
+}
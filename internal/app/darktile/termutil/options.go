@@ -39,3 +39,47 @@ func WithWindowManipulator(m WindowManipulator) Option {
 		t.windowManipulator = m
 	}
 }
+
+// WithEnv adds environment variables, each written as KEY=VALUE, to the environment of the shell
+func WithEnv(env ...string) Option {
+	return func(t *Terminal) {
+		t.env = append(t.env, env...)
+	}
+}
+
+// WithWorkingDirectory sets the directory the shell is started in
+func WithWorkingDirectory(dir string) Option {
+	return func(t *Terminal) {
+		t.workingDirectory = dir
+	}
+}
+
+// WithCommand runs the given program with arguments in the terminal instead of a shell
+func WithCommand(name string, args ...string) Option {
+	return func(t *Terminal) {
+		t.args = append([]string{name}, args...)
+	}
+}
+
+// WithLoginShell starts the shell as a login shell
+func WithLoginShell(enable bool) Option {
+	return func(t *Terminal) {
+		t.loginShell = enable
+	}
+}
+
+// WithCleanEnv starts the shell or command with a minimal environment instead of inheriting darktile's.
+// Variables added with WithEnv are still set.
+func WithCleanEnv(enable bool) Option {
+	return func(t *Terminal) {
+		t.cleanEnv = enable
+	}
+}
+
+// WithHeadless runs the program without putting darktile's own standard input into raw mode, for terminals which
+// aren't shown in a window, such as those rendered to an image from the command line
+func WithHeadless(enable bool) Option {
+	return func(t *Terminal) {
+		t.headless = enable
+	}
+}
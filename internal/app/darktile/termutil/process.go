@@ -0,0 +1,38 @@
+package termutil
+
+import "fmt"
+
+// Process is a process running in the terminal
+type Process struct {
+	PID  int
+	Name string
+	Cwd  string // working directory, if it could be found
+}
+
+// ForegroundProcess returns the leader of the foreground process group of the terminal, which is the shell
+// itself when it is waiting for input. The terminal must be locked by the caller.
+func (t *Terminal) ForegroundProcess() (Process, error) {
+	if !t.running {
+		return Process{}, fmt.Errorf("terminal is not running")
+	}
+	pid, err := foregroundProcessGroup(t.pty)
+	if err != nil {
+		return Process{}, err
+	}
+	name, err := processName(pid)
+	if err != nil {
+		return Process{}, err
+	}
+	cwd, _ := processCwd(pid)
+	return Process{PID: pid, Name: name, Cwd: cwd}, nil
+}
+
+// ForegroundJob returns the foreground process if it is something other than the program started by the
+// terminal, e.g. an editor started from the shell. The terminal must be locked by the caller.
+func (t *Terminal) ForegroundJob() (Process, bool) {
+	process, err := t.ForegroundProcess()
+	if err != nil || process.PID == t.pid {
+		return Process{}, false
+	}
+	return process, true
+}
@@ -0,0 +1,42 @@
+package termutil
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/creack/pty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForegroundProcess(t *testing.T) {
+	c := exec.Command("sleep", "5")
+	c.Dir = os.TempDir()
+	f, err := pty.Start(c)
+	require.NoError(t, err)
+	defer func() {
+		_ = c.Process.Kill()
+		_ = c.Wait()
+		_ = f.Close()
+	}()
+
+	term := New()
+	term.pty = f
+	term.running = true
+	term.pid = c.Process.Pid
+
+	process, err := term.ForegroundProcess()
+	require.NoError(t, err)
+	assert.Equal(t, c.Process.Pid, process.PID)
+	assert.Equal(t, "sleep", process.Name)
+	assert.Equal(t, os.TempDir(), process.Cwd)
+
+	_, ok := term.ForegroundJob()
+	assert.False(t, ok, "the program started by the terminal is not a job")
+
+	term.pid = 1
+	job, ok := term.ForegroundJob()
+	require.True(t, ok)
+	assert.Equal(t, "sleep", job.Name)
+}
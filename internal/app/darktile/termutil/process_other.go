@@ -0,0 +1,20 @@
+//go:build !linux
+
+package termutil
+
+import (
+	"fmt"
+	"os"
+)
+
+func foregroundProcessGroup(pty *os.File) (int, error) {
+	return 0, fmt.Errorf("finding the foreground process is not supported on this platform")
+}
+
+func processName(pid int) (string, error) {
+	return "", fmt.Errorf("finding process names is not supported on this platform")
+}
+
+func processCwd(pid int) (string, error) {
+	return "", fmt.Errorf("finding process working directories is not supported on this platform")
+}
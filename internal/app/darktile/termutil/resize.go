@@ -34,7 +34,7 @@ func (buffer *Buffer) shrink(width uint16) {
 func (buffer *Buffer) grow(width uint16) {
 
 	var replace []Line
-	var current Line
+	current := newLine()
 
 	prevCursor := int(buffer.cursorPosition.Line)
 
@@ -45,6 +45,7 @@ func (buffer *Buffer) grow(width uint16) {
 				replace = append(replace, current)
 			}
 			current = newLine()
+			current.softWrapped = line.softWrapped
 		}
 
 		if i == prevCursor {
@@ -0,0 +1,147 @@
+package termutil
+
+import (
+	"unicode"
+)
+
+// SearchMatch is a match of a search, from its first cell to its last. A match of a wide character ends on the cell
+// which the character covers.
+type SearchMatch struct {
+	Start Position
+	End   Position
+}
+
+// Search finds every match of the query in the buffer, in order from the top of the scrollback, and keeps them so
+// that they can be drawn. Matches can span soft-wrapped lines. The search ignores case unless the query contains an
+// upper case letter. Positions are raw.
+func (buffer *Buffer) Search(query string) []SearchMatch {
+	buffer.ClearSearch()
+
+	needle := []rune(query)
+	if len(needle) == 0 {
+		return nil
+	}
+	ignoreCase := true
+	for _, r := range needle {
+		if unicode.IsUpper(r) {
+			ignoreCase = false
+			break
+		}
+	}
+	if ignoreCase {
+		for i, r := range needle {
+			needle[i] = unicode.ToLower(r)
+		}
+	}
+
+	var matches []SearchMatch
+	for first := uint64(0); first < uint64(len(buffer.lines)); {
+		_, last := buffer.logicalLineRange(first)
+
+		// the characters of the logical line and the position of each
+		var text []rune
+		var positions []Position
+		for y := first; y <= last; y++ {
+			for x, cell := range buffer.lines[y].cells {
+				if cell.continuation || cell.padding {
+					continue
+				}
+				r := cell.r.Rune
+				if r == 0 {
+					r = ' '
+				} else if ignoreCase {
+					r = unicode.ToLower(r)
+				}
+				text = append(text, r)
+				positions = append(positions, Position{Line: y, Col: uint16(x)})
+			}
+		}
+
+		for i := 0; i+len(needle) <= len(text); {
+			if !runesEqual(text[i:i+len(needle)], needle) {
+				i++
+				continue
+			}
+			matches = append(matches, SearchMatch{Start: positions[i], End: buffer.coverWide(positions[i+len(needle)-1])})
+			i += len(needle)
+		}
+
+		first = last + 1
+	}
+
+	buffer.searchMatches = matches
+	buffer.searchCurrent = -1
+	return matches
+}
+
+func runesEqual(a []rune, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ClearSearch removes the matches of the last search
+func (buffer *Buffer) ClearSearch() {
+	buffer.searchMatches = nil
+	buffer.searchCurrent = -1
+}
+
+// SetCurrentSearchMatch marks one of the matches of the last search as the current match, and scrolls the view so
+// that it is visible
+func (buffer *Buffer) SetCurrentSearchMatch(index int) {
+	if index < 0 || index >= len(buffer.searchMatches) {
+		return
+	}
+	buffer.searchCurrent = index
+	buffer.scrollToRawLine(buffer.searchMatches[index].Start.Line)
+}
+
+// GetViewSearchMatches returns the matches of the last search which are at least partly visible, in view
+// coordinates, and the index of the current match among them, or -1 if it is not visible
+func (buffer *Buffer) GetViewSearchMatches() ([]SearchMatch, int) {
+	current := -1
+	if len(buffer.searchMatches) == 0 {
+		return nil, current
+	}
+
+	top := buffer.convertViewLineToRawLine(0)
+	bottom := top + uint64(buffer.viewHeight) - 1
+
+	var matches []SearchMatch
+	for i, match := range buffer.searchMatches {
+		if match.End.Line < top || match.Start.Line > bottom || match.End.Line >= uint64(len(buffer.lines)) {
+			continue
+		}
+		// matches which start above the view are drawn from its first cell, and those which end below it to its last
+		if match.Start.Line < top {
+			match.Start = Position{Line: top}
+		}
+		if match.End.Line > bottom {
+			match.End = Position{Line: bottom, Col: buffer.viewWidth - 1}
+		}
+		match.Start.Line = uint64(buffer.convertRawLineToViewLine(match.Start.Line))
+		match.End.Line = uint64(buffer.convertRawLineToViewLine(match.End.Line))
+		if i == buffer.searchCurrent {
+			current = len(matches)
+		}
+		matches = append(matches, match)
+	}
+	return matches, current
+}
+
+// scrollToRawLine scrolls the view as little as possible so that the given raw line is visible
+func (buffer *Buffer) scrollToRawLine(rawLine uint64) {
+	if rawLine >= uint64(len(buffer.lines)) {
+		return
+	}
+	top := buffer.convertViewLineToRawLine(0)
+	switch {
+	case rawLine < top:
+		buffer.ScrollUp(uint(top - rawLine))
+	case rawLine >= top+uint64(buffer.viewHeight):
+		buffer.ScrollDown(uint(rawLine - top - uint64(buffer.viewHeight) + 1))
+	}
+}
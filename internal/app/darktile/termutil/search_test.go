@@ -0,0 +1,108 @@
+package termutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLines(b *Buffer, lines ...string) {
+	for i, line := range lines {
+		if i > 0 {
+			b.carriageReturn()
+			b.newLine()
+		}
+		writeMeasured(b, line)
+	}
+}
+
+func TestSearchIgnoresCaseOfLowerCaseQuery(t *testing.T) {
+	b := makeBufferForTesting(20, 5)
+	writeLines(b, "Error: disk", "no error here", "ERROR")
+
+	assert.Equal(t, []SearchMatch{
+		{Start: Position{Line: 0, Col: 0}, End: Position{Line: 0, Col: 4}},
+		{Start: Position{Line: 1, Col: 3}, End: Position{Line: 1, Col: 7}},
+		{Start: Position{Line: 2, Col: 0}, End: Position{Line: 2, Col: 4}},
+	}, b.Search("error"))
+
+	// upper case letters in the query make the search case-sensitive
+	assert.Equal(t, []SearchMatch{
+		{Start: Position{Line: 2, Col: 0}, End: Position{Line: 2, Col: 4}},
+	}, b.Search("ERROR"))
+}
+
+func TestSearchDoesNotOverlapMatches(t *testing.T) {
+	b := makeBufferForTesting(20, 5)
+	writeLines(b, "aaaa")
+
+	assert.Equal(t, []SearchMatch{
+		{Start: Position{Line: 0, Col: 0}, End: Position{Line: 0, Col: 1}},
+		{Start: Position{Line: 0, Col: 2}, End: Position{Line: 0, Col: 3}},
+	}, b.Search("aa"))
+}
+
+func TestSearchAcrossWrappedLines(t *testing.T) {
+	b := makeBufferForTesting(5, 5)
+	writeLines(b, "abc needle", "need")
+
+	assert.Equal(t, []SearchMatch{
+		{Start: Position{Line: 0, Col: 4}, End: Position{Line: 1, Col: 4}},
+	}, b.Search("needle"))
+}
+
+func TestSearchWideCharacters(t *testing.T) {
+	b := makeBufferForTesting(20, 5)
+	writeLines(b, "a日本b")
+
+	assert.Equal(t, []SearchMatch{
+		{Start: Position{Line: 0, Col: 1}, End: Position{Line: 0, Col: 4}},
+	}, b.Search("日本"))
+	assert.Equal(t, []SearchMatch{
+		{Start: Position{Line: 0, Col: 3}, End: Position{Line: 0, Col: 5}},
+	}, b.Search("本b"))
+}
+
+func TestSearchWithEmptyQuery(t *testing.T) {
+	b := makeBufferForTesting(20, 5)
+	writeLines(b, "text")
+
+	require.Len(t, b.Search("t"), 2)
+	assert.Empty(t, b.Search(""))
+	matches, _ := b.GetViewSearchMatches()
+	assert.Empty(t, matches)
+}
+
+func TestSetCurrentSearchMatchScrollsToMatch(t *testing.T) {
+	b := makeBufferForTesting(10, 3)
+	writeLines(b, "match", "1", "2", "3", "4", "match", "5")
+
+	matches := b.Search("match")
+	require.Len(t, matches, 2)
+
+	// only the last match is visible at first
+	visible, current := b.GetViewSearchMatches()
+	assert.Equal(t, []SearchMatch{{Start: Position{Line: 1, Col: 0}, End: Position{Line: 1, Col: 4}}}, visible)
+	assert.Equal(t, -1, current)
+
+	b.SetCurrentSearchMatch(0)
+	visible, current = b.GetViewSearchMatches()
+	assert.Equal(t, []SearchMatch{{Start: Position{Line: 0, Col: 0}, End: Position{Line: 0, Col: 4}}}, visible)
+	assert.Equal(t, 0, current)
+
+	b.SetCurrentSearchMatch(1)
+	visible, current = b.GetViewSearchMatches()
+	assert.Equal(t, []SearchMatch{{Start: Position{Line: 2, Col: 0}, End: Position{Line: 2, Col: 4}}}, visible)
+	assert.Equal(t, 0, current)
+}
+
+func TestClearScrollbackClearsSearch(t *testing.T) {
+	b := makeBufferForTesting(10, 2)
+	writeLines(b, "match", "1", "2")
+
+	require.Len(t, b.Search("match"), 1)
+	b.ClearScrollback()
+	matches, _ := b.GetViewSearchMatches()
+	assert.Empty(t, matches)
+}
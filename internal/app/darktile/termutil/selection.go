@@ -1,5 +1,15 @@
 package termutil
 
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultWordSeparators are the runes which delimit words when selecting by double-click
+const DefaultWordSeparators = ",│`|:\"' ()[]{}<>\t"
+
 func (buffer *Buffer) ClearSelection() {
 	buffer.selectionMu.Lock()
 	defer buffer.selectionMu.Unlock()
@@ -58,6 +68,13 @@ func (buffer *Buffer) ExtendSelectionToEntireLines() {
 
 type RuneMatcher func(r rune) bool
 
+// WordMatcher creates a RuneMatcher which matches any visible rune that is not whitespace or one of the given separators
+func WordMatcher(separators string) RuneMatcher {
+	return func(r rune) bool {
+		return r != 0 && !unicode.IsSpace(r) && !strings.ContainsRune(separators, r)
+	}
+}
+
 func (buffer *Buffer) SelectWordAt(pos Position, runeMatcher RuneMatcher) {
 	start, end, _, _, found := buffer.FindWordAt(pos, runeMatcher)
 	if !found {
@@ -67,6 +84,111 @@ func (buffer *Buffer) SelectWordAt(pos Position, runeMatcher RuneMatcher) {
 	buffer.setRawSelectionEnd(end)
 }
 
+// SelectPatternAt selects the first match of the given patterns which covers the given position, trying each pattern in order.
+// Patterns are matched against the text surrounding the position, which can span soft-wrapped lines.
+func (buffer *Buffer) SelectPatternAt(pos Position, patterns ...*regexp.Regexp) bool {
+	return buffer.selectMatchAt(pos, false, patterns)
+}
+
+// SelectDelimitedAt selects the first match of the given patterns which starts or ends at the given position, such as
+// a quoted string when one of its quotes is double-clicked. Matches which only cover the position are ignored, so
+// that the words inside them can still be selected.
+func (buffer *Buffer) SelectDelimitedAt(pos Position, patterns ...*regexp.Regexp) bool {
+	return buffer.selectMatchAt(pos, true, patterns)
+}
+
+func (buffer *Buffer) selectMatchAt(pos Position, delimited bool, patterns []*regexp.Regexp) bool {
+	start, _, text, textIndex, found := buffer.FindWordAt(pos, func(r rune) bool {
+		return r != 0
+	})
+	if !found {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		for _, match := range pattern.FindAllStringIndex(text, -1) {
+			// convert byte offsets to rune (cell) offsets
+			from := utf8.RuneCountInString(text[:match[0]])
+			to := utf8.RuneCountInString(text[:match[1]])
+			if from > textIndex || to <= textIndex {
+				continue
+			}
+			if delimited && textIndex != from && textIndex != to-1 {
+				continue
+			}
+			buffer.setRawSelectionStart(buffer.offsetRawPosition(start, from))
+			buffer.setRawSelectionEnd(buffer.coverWide(buffer.offsetRawPosition(start, to-1)))
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectLineAt selects the entire logical line at the given position, including any lines it is soft-wrapped onto
+func (buffer *Buffer) SelectLineAt(pos Position) {
+	rawLine := buffer.convertViewLineToRawLine(uint16(pos.Line))
+	if rawLine >= uint64(len(buffer.lines)) {
+		return
+	}
+
+	first, last := buffer.logicalLineRange(rawLine)
+
+	end := Position{Line: last}
+	if count := len(buffer.lines[last].cells); count > 0 {
+		end.Col = uint16(count - 1)
+	}
+
+	buffer.setRawSelectionStart(Position{Line: first})
+	buffer.setRawSelectionEnd(end)
+}
+
+// returns the first and last raw lines of the logical line which contains the given raw line
+func (buffer *Buffer) logicalLineRange(rawLine uint64) (first uint64, last uint64) {
+	first, last = rawLine, rawLine
+	for first > 0 && buffer.isContinuation(first) {
+		first--
+	}
+	for last+1 < uint64(len(buffer.lines)) && buffer.isContinuation(last+1) {
+		last++
+	}
+	return first, last
+}
+
+// whether the given raw line continues the line above it - either it was wrapped during a resize, or the cursor
+// wrapped onto it from the end of the line above while writing
+func (buffer *Buffer) isContinuation(rawLine uint64) bool {
+	if rawLine == 0 || rawLine >= uint64(len(buffer.lines)) {
+		return false
+	}
+	return buffer.lines[rawLine].wrapped || buffer.lines[rawLine].softWrapped
+}
+
+// moves a raw position forward by the given number of characters, continuing onto subsequent lines. Cells covered
+// by wide characters and padding are not counted, as they are left out of the text of FindWordAt.
+func (buffer *Buffer) offsetRawPosition(pos Position, runes int) Position {
+	for runes > 0 && pos.Line < uint64(len(buffer.lines)) {
+		pos.Col++
+		if int(pos.Col) >= len(buffer.lines[pos.Line].cells) {
+			pos = Position{Line: pos.Line + 1}
+		}
+		if cell := buffer.getRawCell(pos.Col, pos.Line); cell == nil || cell.continuation || cell.padding {
+			continue
+		}
+		runes--
+	}
+	return pos
+}
+
+// coverWide moves a raw position which is on a wide character onto the cell it covers, so that a selection which
+// ends at the position includes the whole character
+func (buffer *Buffer) coverWide(pos Position) Position {
+	if next := buffer.getRawCell(pos.Col+1, pos.Line); next != nil && next.continuation {
+		pos.Col++
+	}
+	return pos
+}
+
 // takes raw coords
 func (buffer *Buffer) Highlight(start Position, end Position, annotation *Annotation) {
 	buffer.highlightStart = &start
@@ -79,7 +201,7 @@ func (buffer *Buffer) ClearHighlight() {
 	buffer.highlightEnd = nil
 }
 
-// returns raw lines
+// returns raw lines. Clicking either half of a wide character finds the word containing it.
 func (buffer *Buffer) FindWordAt(pos Position, runeMatcher RuneMatcher) (start Position, end Position, text string, textIndex int, found bool) {
 	line := buffer.convertViewLineToRawLine(uint16(pos.Line))
 	col := pos.Col
@@ -90,6 +212,15 @@ func (buffer *Buffer) FindWordAt(pos Position, runeMatcher RuneMatcher) (start P
 	if col >= uint16(len(buffer.lines[line].cells)) {
 		return
 	}
+	if buffer.lines[line].cells[col].continuation {
+		if col > 0 {
+			col--
+		} else if buffer.isContinuation(line) && len(buffer.lines[line-1].cells) > 0 {
+			// the character was split across the lines by a resize
+			line--
+			col = uint16(len(buffer.lines[line].cells) - 1)
+		}
+	}
 
 	if !runeMatcher(buffer.lines[line].cells[col].r.Rune) {
 		return
@@ -106,24 +237,28 @@ func (buffer *Buffer) FindWordAt(pos Position, runeMatcher RuneMatcher) (start P
 		Col:  col,
 	}
 
-	var startCol uint16
+	var startCol int
 BACK:
 	for y := int(line); y >= 0; y-- {
 		if y == int(line) {
-			startCol = col
+			startCol = int(col)
 		} else {
-			if len(buffer.lines[y].cells) < int(buffer.viewWidth) {
+			if !buffer.isContinuation(uint64(y + 1)) {
 				break
 			}
-			startCol = uint16(len(buffer.lines[y].cells) - 1)
+			startCol = len(buffer.lines[y].cells) - 1
 		}
-		for x := int(startCol); x >= 0; x-- {
-			if runeMatcher(buffer.lines[y].cells[x].r.Rune) {
+		for x := startCol; x >= 0; x-- {
+			cell := buffer.lines[y].cells[x]
+			if cell.continuation || cell.padding {
+				continue
+			}
+			if runeMatcher(cell.r.Rune) {
 				start = Position{
 					Line: uint64(y),
 					Col:  uint16(x),
 				}
-				text = string(buffer.lines[y].cells[x].r.Rune) + text
+				text = string(cell.r.Rune) + text
 			} else {
 				break BACK
 			}
@@ -134,24 +269,31 @@ BACK:
 FORWARD:
 	for y := uint64(line); y < uint64(len(buffer.lines)); y++ {
 		if y == line {
-			startCol = col + 1
+			startCol = int(col) + 1
 		} else {
+			if !buffer.isContinuation(y) {
+				break
+			}
 			startCol = 0
 		}
-		for x := int(startCol); x < len(buffer.lines[y].cells); x++ {
-			if runeMatcher(buffer.lines[y].cells[x].r.Rune) {
+		for x := startCol; x < len(buffer.lines[y].cells); x++ {
+			cell := buffer.lines[y].cells[x]
+			if cell.padding {
+				continue
+			}
+			// the cell is covered by the character before it, which has matched
+			if cell.continuation || runeMatcher(cell.r.Rune) {
 				end = Position{
 					Line: y,
 					Col:  uint16(x),
 				}
-				text = text + string(buffer.lines[y].cells[x].r.Rune)
+				if !cell.continuation {
+					text = text + string(cell.r.Rune)
+				}
 			} else {
 				break FORWARD
 			}
 		}
-		if len(buffer.lines[y].cells) < int(buffer.viewWidth) {
-			break
-		}
 	}
 
 	return
@@ -188,10 +330,26 @@ func (buffer *Buffer) setRawSelectionEnd(pos Position) {
 }
 
 func (buffer *Buffer) GetSelection() (string, *Selection) {
-	if !buffer.fixSelection() {
+	lines, selection := buffer.GetSelectedCells()
+	if selection == nil {
 		return "", nil
 	}
 
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		texts = append(texts, CellsToString(line))
+	}
+
+	return strings.Join(texts, "\n"), selection
+}
+
+// GetSelectedCells returns the selected cells grouped into logical lines - lines which were soft-wrapped are joined
+// together and trailing blank cells are removed from the end of each line
+func (buffer *Buffer) GetSelectedCells() ([][]Cell, *Selection) {
+	if !buffer.fixSelection() {
+		return nil, nil
+	}
+
 	buffer.selectionMu.Lock()
 	defer buffer.selectionMu.Unlock()
 
@@ -204,35 +362,30 @@ func (buffer *Buffer) GetSelection() (string, *Selection) {
 		start = swap
 	}
 
-	var text string
+	var lines [][]Cell
+	var current []Cell
 	for y := start.Line; y <= end.Line; y++ {
 		if y >= uint64(len(buffer.lines)) {
 			break
 		}
-		line := buffer.lines[y]
+		if y > start.Line && !buffer.isContinuation(y) {
+			lines = append(lines, trimTrailingBlanks(current))
+			current = nil
+		}
+		cells := buffer.lines[y].cells
 		startX := 0
-		endX := len(line.cells) - 1
+		endX := len(cells) - 1
 		if y == start.Line {
 			startX = int(start.Col)
 		}
-		if y == end.Line {
+		if y == end.Line && int(end.Col) < endX {
 			endX = int(end.Col)
 		}
-		if y > start.Line {
-			text += "\n"
-		}
 		for x := startX; x <= endX; x++ {
-			if x >= len(line.cells) {
-				break
-			}
-			mr := line.cells[x].Rune()
-			if mr.Width == 0 {
-				continue
-			}
-			x += mr.Width - 1
-			text += string(mr.Rune)
+			current = append(current, cells[x])
 		}
 	}
+	lines = append(lines, trimTrailingBlanks(current))
 
 	viewSelection := Selection{
 		Start: start,
@@ -241,7 +394,36 @@ func (buffer *Buffer) GetSelection() (string, *Selection) {
 
 	viewSelection.Start.Line = uint64(buffer.convertRawLineToViewLine(viewSelection.Start.Line))
 	viewSelection.End.Line = uint64(buffer.convertRawLineToViewLine(viewSelection.End.Line))
-	return text, &viewSelection
+	return lines, &viewSelection
+}
+
+// CellsToString converts a line of cells to text, replacing empty cells with spaces and keeping any combining
+// characters after the rune they modify. Cells covered by wide characters, and the padding left by wide characters
+// which were wrapped, are skipped.
+func CellsToString(cells []Cell) string {
+	runes := make([]rune, 0, len(cells))
+	for _, cell := range cells {
+		if cell.continuation || cell.padding {
+			continue
+		}
+		if cell.r.Rune == 0 {
+			runes = append(runes, ' ')
+			continue
+		}
+		runes = append(runes, cell.r.Rune)
+		runes = append(runes, cell.combining...)
+	}
+	return string(runes)
+}
+
+func trimTrailingBlanks(cells []Cell) []Cell {
+	for len(cells) > 0 {
+		if r := cells[len(cells)-1].r.Rune; r != 0 && r != ' ' {
+			break
+		}
+		cells = cells[:len(cells)-1]
+	}
+	return cells
 }
 
 func (buffer *Buffer) InSelection(pos Position) bool {
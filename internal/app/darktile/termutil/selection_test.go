@@ -0,0 +1,257 @@
+package termutil
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectWordAtWithSeparators(t *testing.T) {
+	b := makeBufferForTesting(80, 5)
+	writeRaw(b, []rune("cat ./some-file.txt|grep x")...)
+
+	b.SelectWordAt(Position{Col: 8, Line: 0}, WordMatcher(DefaultWordSeparators))
+	text, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, "./some-file.txt", text)
+
+	b.SelectWordAt(Position{Col: 8, Line: 0}, WordMatcher(DefaultWordSeparators+"-./"))
+	text, _ = b.GetSelection()
+	assert.Equal(t, "some", text)
+}
+
+func TestSelectPatternAtPrefersFirstMatchingPattern(t *testing.T) {
+	b := makeBufferForTesting(80, 5)
+	writeRaw(b, []rune(`curl "https://example.com/a?b=c" -v`)...)
+
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`https?://[^\s"]+`),
+		regexp.MustCompile(`"[^"]*"`),
+	}
+
+	require.True(t, b.SelectPatternAt(Position{Col: 15, Line: 0}, patterns...))
+	text, _ := b.GetSelection()
+	assert.Equal(t, "https://example.com/a?b=c", text)
+
+	// the opening quote is only covered by the second pattern
+	require.True(t, b.SelectPatternAt(Position{Col: 5, Line: 0}, patterns...))
+	text, _ = b.GetSelection()
+	assert.Equal(t, `"https://example.com/a?b=c"`, text)
+
+	assert.False(t, b.SelectPatternAt(Position{Col: 1, Line: 0}, patterns...))
+}
+
+func TestSelectDelimitedAtOnlyMatchesAtEdges(t *testing.T) {
+	b := makeBufferForTesting(80, 5)
+	writeRaw(b, []rune(`echo "hello world" ok`)...)
+	quoted := regexp.MustCompile(`"[^"]*"`)
+
+	assert.False(t, b.SelectDelimitedAt(Position{Col: 7, Line: 0}, quoted))
+	assert.False(t, b.SelectDelimitedAt(Position{Col: 11, Line: 0}, quoted))
+
+	for _, col := range []uint16{5, 17} {
+		b.ClearSelection()
+		require.True(t, b.SelectDelimitedAt(Position{Col: col, Line: 0}, quoted))
+		text, _ := b.GetSelection()
+		assert.Equal(t, `"hello world"`, text)
+	}
+}
+
+// TestDoubleClickPrecedence selects text in the same order as a double-click in the GUI - smart selection patterns,
+// then delimited patterns, then the word
+func TestDoubleClickPrecedence(t *testing.T) {
+	b := makeBufferForTesting(80, 5)
+	writeRaw(b, []rune(`cat "notes about /tmp/a.txt" 'x y' ok`)...)
+
+	patterns := []*regexp.Regexp{regexp.MustCompile(`(?:~|\.{1,2})?(?:/[\w.@%+~-]+)+/?`)}
+	delimited := []*regexp.Regexp{regexp.MustCompile(`"[^"]*"`), regexp.MustCompile(`'[^']*'`)}
+	doubleClick := func(col uint16) string {
+		b.ClearSelection()
+		pos := Position{Col: col, Line: 0}
+		if !b.SelectPatternAt(pos, patterns...) && !b.SelectDelimitedAt(pos, delimited...) {
+			b.SelectWordAt(pos, WordMatcher(DefaultWordSeparators))
+		}
+		text, _ := b.GetSelection()
+		return text
+	}
+
+	assert.Equal(t, "notes", doubleClick(6), "a word inside quotes")
+	assert.Equal(t, "/tmp/a.txt", doubleClick(19), "a path inside quotes")
+	assert.Equal(t, `"notes about /tmp/a.txt"`, doubleClick(4), "the opening quote")
+	assert.Equal(t, `"notes about /tmp/a.txt"`, doubleClick(27), "the closing quote")
+	assert.Equal(t, `'x y'`, doubleClick(29), "a single quote")
+	assert.Equal(t, "x", doubleClick(30), "a word inside single quotes")
+}
+
+func TestSelectPatternAtAcrossWrappedLines(t *testing.T) {
+	b := makeBufferForTesting(10, 5)
+	writeRaw(b, []rune("id 123e4567-e89b-12d3-a456-426614174000 ok")...)
+
+	uuid := regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+	require.True(t, b.SelectPatternAt(Position{Col: 4, Line: 2}, uuid))
+	text, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, Position{Line: 0, Col: 3}, selection.Start)
+	assert.Equal(t, Position{Line: 3, Col: 8}, selection.End)
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", text)
+}
+
+func TestSelectLineAtSelectsLogicalLine(t *testing.T) {
+	b := makeBufferForTesting(5, 10)
+	writeRaw(b, []rune("one")...)
+	b.carriageReturn()
+	b.newLine()
+	writeRaw(b, []rune("second line")...)
+	b.carriageReturn()
+	b.newLine()
+	writeRaw(b, []rune("third")...)
+
+	b.SelectLineAt(Position{Col: 0, Line: 2})
+	_, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, Position{Line: 1, Col: 0}, selection.Start)
+	assert.Equal(t, Position{Line: 3, Col: 0}, selection.End)
+}
+
+func TestFilledLineFollowedByNewLineIsNotJoined(t *testing.T) {
+	b := makeBufferForTesting(5, 10)
+	writeRaw(b, []rune("abcde")...)
+	b.carriageReturn()
+	b.newLine()
+	writeRaw(b, []rune("fghij")...)
+	writeRaw(b, []rune("k")...)
+
+	b.SelectLineAt(Position{Col: 0, Line: 0})
+	text, _ := b.GetSelection()
+	assert.Equal(t, "abcde", text)
+
+	// the second line wrapped onto the third as it was written
+	b.SelectLineAt(Position{Col: 0, Line: 2})
+	text, _ = b.GetSelection()
+	assert.Equal(t, "fghijk", text)
+
+	b.SetSelectionStart(Position{Col: 0, Line: 0})
+	b.SetSelectionEnd(Position{Col: 4, Line: 1})
+	text, _ = b.GetSelection()
+	assert.Equal(t, "abcde\nfghij", text)
+}
+
+func TestErasedLineIsNotJoined(t *testing.T) {
+	b := makeBufferForTesting(5, 10)
+	writeRaw(b, []rune("abcdefg")...)
+	b.eraseDisplay()
+	b.setPosition(0, 1)
+	writeRaw(b, []rune("xy")...)
+
+	b.SelectLineAt(Position{Col: 0, Line: 1})
+	text, _ := b.GetSelection()
+	assert.Equal(t, "xy", text)
+}
+
+func TestGetSelectionJoinsWrappedLinesAndTrimsBlanks(t *testing.T) {
+	b := makeBufferForTesting(5, 10)
+	writeRaw(b, []rune("abcdefg")...)
+	b.carriageReturn()
+	b.newLine()
+	writeRaw(b, []rune("x  ")...)
+	b.carriageReturn()
+	b.newLine()
+	b.newLine()
+	writeRaw(b, []rune("y")...)
+
+	b.setRawSelectionStart(Position{Line: 0, Col: 0})
+	b.setRawSelectionEnd(Position{Line: 4, Col: 4})
+
+	text, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, "abcdefg\nx\n\ny", text)
+}
+
+func TestGetSelectionWithMultiByteRunes(t *testing.T) {
+	b := makeBufferForTesting(20, 5)
+	for _, r := range "naïve café" {
+		b.write(MeasuredRune{Rune: r, Width: RuneWidth(r)})
+	}
+
+	b.SelectLineAt(Position{Line: 0})
+	text, _ := b.GetSelection()
+	assert.Equal(t, "naïve café", text)
+}
+
+func TestSelectWordAtRightHalfOfWideCharacter(t *testing.T) {
+	b := makeBufferForTesting(20, 5)
+	writeLines(b, "abc 日本語 x")
+
+	b.SelectWordAt(Position{Col: 5, Line: 0}, WordMatcher(DefaultWordSeparators))
+	text, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, "日本語", text)
+	assert.Equal(t, Position{Line: 0, Col: 4}, selection.Start)
+	assert.Equal(t, Position{Line: 0, Col: 9}, selection.End)
+}
+
+func TestSelectPatternAtWithWideCharacters(t *testing.T) {
+	b := makeBufferForTesting(40, 5)
+	writeLines(b, "名前 https://例え.jp/パス ok")
+
+	url := regexp.MustCompile(`https?://\S+`)
+	require.True(t, b.SelectPatternAt(Position{Col: 14, Line: 0}, url))
+	text, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, "https://例え.jp/パス", text)
+	assert.Equal(t, Position{Line: 0, Col: 5}, selection.Start)
+	assert.Equal(t, Position{Line: 0, Col: 24}, selection.End)
+}
+
+func TestSelectAcrossLineEndingInWideCharacter(t *testing.T) {
+	b := makeBufferForTesting(4, 5)
+	writeLines(b, "ab日cd")
+
+	b.SelectLineAt(Position{Col: 0, Line: 1})
+	text, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, Position{Line: 0, Col: 0}, selection.Start)
+	assert.Equal(t, "ab日cd", text)
+
+	b.SelectWordAt(Position{Col: 3, Line: 0}, WordMatcher(DefaultWordSeparators))
+	text, _ = b.GetSelection()
+	assert.Equal(t, "ab日cd", text)
+}
+
+func TestSelectAcrossWrappedWideCharacter(t *testing.T) {
+	b := makeBufferForTesting(5, 5)
+	writeLines(b, "abcd日x")
+	require.Len(t, b.lines[0].cells, 5)
+	assert.True(t, b.lines[0].cells[4].padding)
+
+	b.SelectLineAt(Position{Col: 0, Line: 1})
+	text, selection := b.GetSelection()
+	require.NotNil(t, selection)
+	assert.Equal(t, Position{Line: 0, Col: 0}, selection.Start)
+	assert.Equal(t, "abcd日x", text)
+
+	b.SelectWordAt(Position{Col: 1, Line: 0}, WordMatcher(DefaultWordSeparators))
+	text, selection = b.GetSelection()
+	assert.Equal(t, "abcd日x", text)
+	assert.Equal(t, Position{Line: 1, Col: 2}, selection.End)
+
+	require.True(t, b.SelectPatternAt(Position{Col: 1, Line: 1}, regexp.MustCompile(`d日`)))
+	_, selection = b.GetSelection()
+	assert.Equal(t, Position{Line: 0, Col: 3}, selection.Start)
+	assert.Equal(t, Position{Line: 1, Col: 1}, selection.End)
+}
+
+func TestSelectWordAtWideCharacterSplitByResize(t *testing.T) {
+	b := makeBufferForTesting(5, 5)
+	writeLines(b, "abcd日")
+	b.lines[0].cells = append(b.lines[0].cells[:4], b.lines[1].cells[0])
+	b.lines[1].cells = b.lines[1].cells[1:]
+	b.lines[1].wrapped = true
+
+	b.SelectWordAt(Position{Col: 0, Line: 1}, WordMatcher(DefaultWordSeparators))
+	text, _ := b.GetSelection()
+	assert.Equal(t, "abcd日", text)
+}
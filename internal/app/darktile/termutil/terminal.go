@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/creack/pty"
@@ -26,34 +28,47 @@ type Terminal struct {
 	pty               *os.File
 	updateChan        chan struct{}
 	processChan       chan MeasuredRune
-	closeChan         chan struct{}
+	processOnce       sync.Once
+	flushChan         chan chan struct{}
 	buffers           []*Buffer
 	activeBuffer      *Buffer
 	mouseMode         MouseMode
 	mouseExtMode      MouseExtMode
 	logFile           *os.File
 	theme             *Theme
-	running           bool
+	ptyMu             sync.Mutex // guards running for WriteToPty, which is called with and without mu held
+	running           bool       // written with both mu and ptyMu held, so either is enough to read it
 	shell             string
 	initialCommand    string
+	env               []string
+	workingDirectory  string
+	args              []string // program and arguments to run instead of the shell
+	loginShell        bool
+	cleanEnv          bool
+	headless          bool
+	pid               int // process id of the shell or command
+}
+
+// cleanEnvVars are inherited by the shell even when a clean environment is requested
+var cleanEnvVars = []string{
+	"HOME", "USER", "LOGNAME", "SHELL", "PATH", "LANG", "TERM",
+	"DISPLAY", "WAYLAND_DISPLAY", "XDG_RUNTIME_DIR",
 }
 
 // NewTerminal creates a new terminal instance
 func New(options ...Option) *Terminal {
 	term := &Terminal{
 		processChan: make(chan MeasuredRune, 0xffff),
-		closeChan:   make(chan struct{}),
+		flushChan:   make(chan chan struct{}),
 		theme:       &Theme{},
 	}
 	for _, opt := range options {
 		opt(term)
 	}
-	fg := term.theme.DefaultForeground()
-	bg := term.theme.DefaultBackground()
 	term.buffers = []*Buffer{
-		NewBuffer(1, 1, 0xffff, fg, bg),
-		NewBuffer(1, 1, 0xffff, fg, bg),
-		NewBuffer(1, 1, 0xffff, fg, bg),
+		NewBuffer(1, 1, 0xffff),
+		NewBuffer(1, 1, 0xffff),
+		NewBuffer(1, 1, 0xffff),
 	}
 	term.activeBuffer = term.buffers[0]
 	return term
@@ -70,12 +85,10 @@ func (t *Terminal) log(line string, params ...interface{}) {
 }
 
 func (t *Terminal) reset() {
-	fg := t.theme.DefaultForeground()
-	bg := t.theme.DefaultBackground()
 	t.buffers = []*Buffer{
-		NewBuffer(1, 1, 0xffff, fg, bg),
-		NewBuffer(1, 1, 0xffff, fg, bg),
-		NewBuffer(1, 1, 0xffff, fg, bg),
+		NewBuffer(1, 1, 0xffff),
+		NewBuffer(1, 1, 0xffff),
+		NewBuffer(1, 1, 0xffff),
 	}
 	t.useMainBuffer()
 }
@@ -85,11 +98,39 @@ func (t *Terminal) Pty() *os.File {
 	return t.pty
 }
 
+// WriteToPty sends input to the running program. Input is discarded once the program has exited.
 func (t *Terminal) WriteToPty(data []byte) error {
-	_, err := t.pty.Write(data)
+	t.ptyMu.Lock()
+	if !t.running {
+		t.ptyMu.Unlock()
+		return nil
+	}
+	ptmx := t.pty
+	t.ptyMu.Unlock()
+	_, err := ptmx.Write(data)
 	return err
 }
 
+// Paste writes the given text to the pty, wrapping it in bracketed paste markers if the application has enabled them
+func (t *Terminal) Paste(text string) error {
+	if t.activeBuffer.modes.BracketedPasteMode {
+		// don't allow the pasted text to end the bracketed paste early, or to send any other escape sequence
+		text = "\x1b[200~" + stripControls(text) + "\x1b[201~"
+	}
+	return t.WriteToPty([]byte(text))
+}
+
+// stripControls removes C0 control characters other than tab, line feed and carriage return, including the escape
+// which starts the sequence ending a bracketed paste
+func stripControls(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, text)
+}
+
 func (t *Terminal) GetTitle() string {
 	return t.windowManipulator.GetTitle()
 }
@@ -98,15 +139,24 @@ func (t *Terminal) Theme() *Theme {
 	return t.theme
 }
 
+// SetTheme switches to a new theme. Existing cells which use the default or palette colours are drawn in the
+// colours of the new theme. The terminal must be locked by the caller.
+func (t *Terminal) SetTheme(theme *Theme) {
+	for _, buffer := range t.buffers {
+		buffer.touchAll()
+	}
+	t.theme = theme
+}
+
 // write takes data from StdOut of the child shell and processes it
 func (t *Terminal) Write(data []byte) (n int, err error) {
 	reader := bufio.NewReader(bytes.NewBuffer(data))
 	for {
-		r, size, err := reader.ReadRune()
+		r, _, err := reader.ReadRune()
 		if err == io.EOF {
 			break
 		}
-		t.processChan <- MeasuredRune{Rune: r, Width: size}
+		t.processChan <- MeasuredRune{Rune: r, Width: RuneWidth(r)}
 	}
 	return len(data), nil
 }
@@ -130,39 +180,61 @@ func (t *Terminal) SetSize(rows, cols uint16) error {
 	return nil
 }
 
-// Run starts the terminal/shell proxying process
-func (t *Terminal) Run(updateChan chan struct{}, rows uint16, cols uint16) error {
+// Resize sets the size of the view of a terminal which isn't running a program, such as one used to render
+// recorded output
+func (t *Terminal) Resize(rows, cols uint16) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.activeBuffer.resizeView(cols, rows)
+}
+
+// Process handles output as though it had been written by a program running in the terminal, for terminals which
+// aren't running one. It returns once all of the output has been processed, so the output must not end part way
+// through an escape sequence.
+func (t *Terminal) Process(data []byte) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = t.Write(data)
+	}()
+	for {
+		select {
+		case mr := <-t.processChan:
+			t.processSequence(mr)
+		case <-done:
+			if len(t.processChan) == 0 {
+				return
+			}
+		}
+	}
+}
+
+// Run starts the terminal/shell proxying process, and returns how the program exited once it has done so.
+// It can be called again after the program has exited to restart it.
+func (t *Terminal) Run(updateChan chan struct{}, rows uint16, cols uint16) (ExitStatus, error) {
 
 	os.Setenv("TERM", "xterm-256color")
 
 	t.updateChan = updateChan
 
-	if t.shell == "" {
-		t.shell = os.Getenv("SHELL")
-		if t.shell == "" {
-			t.shell = "/bin/sh"
-		}
-	}
-
-	// Create arbitrary command.
-	c := exec.Command(t.shell)
+	c := t.buildCommand()
 
 	// Start the command with a pty.
 	var err error
 	t.pty, err = pty.Start(c)
 	if err != nil {
-		return err
+		return ExitStatus{}, err
 	}
 	// Make sure to close the pty at the end.
 	defer func() { _ = t.pty.Close() }() // Best effort.
 
 	if err := t.SetSize(rows, cols); err != nil {
-		return err
+		return ExitStatus{}, err
 	}
 
 	// Set stdin in raw mode.
 
-	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+	if fd := int(os.Stdin.Fd()); !t.headless && term.IsTerminal(fd) {
 		oldState, err := term.MakeRaw(fd)
 		if err != nil {
 			t.windowManipulator.ReportError(err)
@@ -170,27 +242,94 @@ func (t *Terminal) Run(updateChan chan struct{}, rows uint16, cols uint16) error
 		defer func() { _ = term.Restore(fd, oldState) }() // Best effort.
 	}
 
-	go t.process()
+	// output is processed by a single goroutine for the lifetime of the terminal, so that it survives restarts
+	t.processOnce.Do(func() {
+		go t.process()
+	})
 
-	t.running = true
+	t.mu.Lock()
+	t.setRunning(true)
+	t.pid = c.Process.Pid
+	t.mu.Unlock()
 
 	t.windowManipulator.SetTitle("darktile")
 
 	if t.initialCommand != "" {
 		if err := t.WriteToPty([]byte(t.initialCommand)); err != nil {
-			return err
+			return ExitStatus{}, err
 		}
 	}
 
 	_, _ = io.Copy(t, t.pty)
-	close(t.closeChan)
-	return nil
+
+	// the error is reflected in the process state, which is all we need
+	_ = c.Wait()
+
+	t.mu.Lock()
+	t.setRunning(false)
+	t.mu.Unlock()
+
+	return exitStatusOf(c.ProcessState), nil
+}
+
+// buildCommand creates the process to run in the terminal - the command given with WithCommand if there is
+// one, otherwise the shell
+func (t *Terminal) buildCommand() *exec.Cmd {
+
+	if t.shell == "" {
+		t.shell = os.Getenv("SHELL")
+		if t.shell == "" {
+			t.shell = "/bin/sh"
+		}
+	}
+
+	var c *exec.Cmd
+	if len(t.args) > 0 {
+		c = exec.Command(t.args[0], t.args[1:]...)
+	} else {
+		c = exec.Command(t.shell)
+		if t.loginShell {
+			// a leading dash in argv[0] tells the shell to run as a login shell
+			c.Args[0] = "-" + filepath.Base(t.shell)
+		}
+	}
+
+	env := os.Environ()
+	if t.cleanEnv {
+		env = nil
+		for _, key := range cleanEnvVars {
+			if value, ok := os.LookupEnv(key); ok {
+				env = append(env, key+"="+value)
+			}
+		}
+	}
+	c.Env = append(env, t.env...)
+	c.Dir = t.workingDirectory
+
+	return c
+}
+
+// setRunning records whether the program is running. The terminal must be locked.
+func (t *Terminal) setRunning(running bool) {
+	t.ptyMu.Lock()
+	defer t.ptyMu.Unlock()
+	t.running = running
 }
 
 func (t *Terminal) IsRunning() bool {
 	return t.running
 }
 
+// Hangup closes the pty, so that the program is sent SIGHUP as though the window had been closed
+func (t *Terminal) Hangup() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.running {
+		return nil
+	}
+	return t.pty.Close()
+}
+
 func (t *Terminal) requestRender() {
 	select {
 	case t.updateChan <- struct{}{}:
@@ -206,18 +345,34 @@ func (t *Terminal) processSequence(mr MeasuredRune) (render bool) {
 }
 
 func (t *Terminal) process() {
+	var flushes []chan struct{}
 	for {
 		select {
-		case <-t.closeChan:
-			return
 		case mr := <-t.processChan:
 			if t.processSequence(mr) {
 				t.requestRender()
 			}
+		case done := <-t.flushChan:
+			flushes = append(flushes, done)
+		}
+		if len(flushes) > 0 && len(t.processChan) == 0 {
+			for _, done := range flushes {
+				close(done)
+			}
+			flushes = nil
 		}
 	}
 }
 
+// Flush waits until the output read from the program has been processed, such as once the program has exited.
+// Output which keeps arriving delays it, so it should only be relied upon when the program has stopped writing.
+// It must only be used once Run has been called.
+func (t *Terminal) Flush() {
+	done := make(chan struct{})
+	t.flushChan <- done
+	<-done
+}
+
 func (t *Terminal) processRunes(runes ...MeasuredRune) (renderRequired bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
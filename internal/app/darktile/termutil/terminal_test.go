@@ -0,0 +1,156 @@
+package termutil
+
+import (
+	"image/color"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readPaste(t *testing.T, bracketed bool, text string) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+
+	term := New()
+	term.pty = w
+	term.running = true
+	term.activeBuffer.modes.BracketedPasteMode = bracketed
+
+	require.NoError(t, term.Paste(text))
+	require.NoError(t, w.Close())
+
+	output, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestPaste(t *testing.T) {
+	assert.Equal(t, "echo hi\n", readPaste(t, false, "echo hi\n"))
+}
+
+func TestBracketedPaste(t *testing.T) {
+	assert.Equal(t, "\x1b[200~echo hi\n\x1b[201~", readPaste(t, true, "echo hi\n"))
+}
+
+func TestBracketedPasteCannotBeEndedEarly(t *testing.T) {
+	assert.Equal(t, "\x1b[200~ab[201~cd\x1b[201~", readPaste(t, true, "ab\x1b[201~cd"))
+}
+
+func TestBracketedPasteCannotBeEndedByNestedMarker(t *testing.T) {
+	assert.Equal(t, "\x1b[200~[2[201~01~\x1b[201~", readPaste(t, true, "\x1b[2\x1b[201~01~"))
+}
+
+func TestBracketedPasteKeepsWhitespaceControls(t *testing.T) {
+	assert.Equal(t, "\x1b[200~a\tb\r\nc\x1b[201~", readPaste(t, true, "a\tb\x07\r\nc\x03"))
+}
+
+func TestSetThemeRecoloursExistingCells(t *testing.T) {
+	oldTheme := NewThemeFactory().
+		WithColour(ColourForeground, color.RGBA{R: 1, A: 0xff}).
+		WithColour(ColourBackground, color.RGBA{G: 1, A: 0xff}).
+		WithColour(ColourRed, color.RGBA{B: 1, A: 0xff}).
+		Build()
+	newTheme := NewThemeFactory().
+		WithColour(ColourForeground, color.RGBA{R: 2, A: 0xff}).
+		WithColour(ColourBackground, color.RGBA{G: 2, A: 0xff}).
+		WithColour(ColourRed, color.RGBA{B: 2, A: 0xff}).
+		WithPaletteColour(200, color.RGBA{R: 2, G: 2, A: 0xff}).
+		Build()
+
+	term := New(WithTheme(oldTheme))
+	term.Resize(5, 10)
+	// default colours, red, a true colour which happens to match red in the old theme, and a palette colour
+	term.Process([]byte("a\x1b[31mb\x1b[38;2;0;0;1mc\x1b[0;48;5;200md"))
+
+	term.SetTheme(newTheme)
+
+	theme := term.Theme()
+	cells := term.GetActiveBuffer().GetViewCells()[0]
+	assert.Equal(t, newTheme.DefaultForeground(), theme.Resolve(cells[0].Fg()))
+	assert.Equal(t, newTheme.DefaultBackground(), theme.Resolve(cells[0].Bg()))
+	assert.Equal(t, newTheme.ColourFrom4Bit(31), theme.Resolve(cells[1].Fg()))
+	assert.Equal(t, color.RGBA{B: 1, A: 0xff}, theme.Resolve(cells[2].Fg()))
+	assert.Equal(t, color.RGBA{R: 2, G: 2, A: 0xff}, theme.Resolve(cells[3].Bg()))
+	assert.Equal(t, newTheme, term.Theme())
+}
+
+func TestBuildCommandRunsShell(t *testing.T) {
+	term := New(WithShell("/bin/zsh"), WithLoginShell(true), WithWorkingDirectory("/tmp"), WithEnv("FOO=bar"))
+	c := term.buildCommand()
+	assert.Equal(t, "/bin/zsh", c.Path)
+	assert.Equal(t, []string{"-zsh"}, c.Args)
+	assert.Equal(t, "/tmp", c.Dir)
+	assert.Equal(t, "FOO=bar", c.Env[len(c.Env)-1])
+}
+
+func TestBuildCommandRunsArgs(t *testing.T) {
+	term := New(WithShell("/bin/zsh"), WithLoginShell(true), WithCommand("/usr/bin/htop", "-d", "5"))
+	c := term.buildCommand()
+	assert.Equal(t, "/usr/bin/htop", c.Path)
+	assert.Equal(t, []string{"/usr/bin/htop", "-d", "5"}, c.Args)
+}
+
+func TestBuildCommandWithCleanEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("DARKTILE_TEST_SECRET", "1"))
+	defer os.Unsetenv("DARKTILE_TEST_SECRET")
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	require.NoError(t, os.Setenv("HOME", "/home/test"))
+
+	term := New(WithShell("/bin/sh"), WithCleanEnv(true), WithEnv("FOO=bar"))
+	c := term.buildCommand()
+	assert.Contains(t, c.Env, "HOME=/home/test")
+	assert.Contains(t, c.Env, "FOO=bar")
+	assert.NotContains(t, c.Env, "DARKTILE_TEST_SECRET=1")
+}
+
+func TestExitStatus(t *testing.T) {
+	c := exec.Command("/bin/sh", "-c", "exit 3")
+	_ = c.Run()
+	status := exitStatusOf(c.ProcessState)
+	assert.Equal(t, 3, status.ShellCode())
+	assert.False(t, status.Success())
+	assert.Equal(t, "exited with code 3", status.String())
+
+	c = exec.Command("/bin/sh", "-c", "kill -9 $$")
+	_ = c.Run()
+	status = exitStatusOf(c.ProcessState)
+	assert.Equal(t, syscall.SIGKILL, status.Signal)
+	assert.Equal(t, 137, status.ShellCode())
+	assert.Equal(t, "killed by signal 9 (killed)", status.String())
+}
+
+func TestProcessWithoutRunningProgram(t *testing.T) {
+	term := New()
+	term.Resize(3, 10)
+	term.Process([]byte("hello\r\n\x1b[31mworld\x1b[0m"))
+
+	buffer := term.GetActiveBuffer()
+	assert.Equal(t, uint16(10), buffer.ViewWidth())
+	assert.Equal(t, uint16(3), buffer.ViewHeight())
+	lines := buffer.GetVisibleLines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, "hello", lines[0].String())
+	assert.Equal(t, "world", lines[1].String())
+	assert.Equal(t, term.Theme().ColourFrom4Bit(31), term.Theme().Resolve(buffer.GetCell(0, 1).Fg()))
+}
+
+func TestProcessWideCharacters(t *testing.T) {
+	term := New()
+	term.Resize(3, 10)
+	term.Process([]byte("é漢😀x"))
+
+	buffer := term.GetActiveBuffer()
+	assert.Equal(t, 'é', buffer.GetCell(0, 0).Rune().Rune)
+	assert.True(t, buffer.GetCell(1, 0).Wide())
+	assert.True(t, buffer.GetCell(2, 0).Continuation())
+	assert.True(t, buffer.GetCell(3, 0).Wide())
+	assert.Equal(t, 'x', buffer.GetCell(5, 0).Rune().Rune)
+	assert.Equal(t, uint16(6), buffer.CursorColumn())
+}
@@ -32,10 +32,16 @@ const (
 	ColourSelectionForeground
 	ColourCursorForeground
 	ColourCursorBackground
+	ColourBold                  // optional foreground for bold text which uses the default foreground
+	ColourDim                   // optional foreground for dim text which uses the default foreground
+	ColourLink                  // optional colour for links
+	ColourSearchMatchBackground // optional background for search matches
+	ColourSearchMatchForeground // optional foreground for search matches
 )
 
 type Theme struct {
 	colourMap map[Colour]color.Color
+	palette   map[uint8]color.Color // overrides for the 256 colour palette
 }
 
 var (
@@ -83,6 +89,22 @@ func (t *Theme) ColourFrom4Bit(code uint8) color.Color {
 	return t.colourMap[colour]
 }
 
+// BrightVersion returns the bright version of a colour if it is one of the 8 normal ANSI colours
+func (t *Theme) BrightVersion(c CellColour) (color.Color, bool) {
+	index, ok := c.PaletteIndex()
+	if !ok || index > uint8(ColourWhite) {
+		return nil, false
+	}
+	bright, ok := t.colourMap[Colour(index)+8]
+	return bright, ok
+}
+
+// Colour returns the colour for the given role, if the theme defines one
+func (t *Theme) Colour(key Colour) (color.Color, bool) {
+	c, ok := t.colourMap[key]
+	return c, ok
+}
+
 func (t *Theme) DefaultBackground() color.Color {
 	c, ok := t.colourMap[ColourBackground]
 	if !ok {
@@ -115,6 +137,22 @@ func (t *Theme) SelectionForeground() color.Color {
 	return c
 }
 
+func (t *Theme) SearchMatchBackground() color.Color {
+	c, ok := t.colourMap[ColourSearchMatchBackground]
+	if !ok {
+		return color.RGBA{0xf0, 0xc6, 0x74, 0xff}
+	}
+	return c
+}
+
+func (t *Theme) SearchMatchForeground() color.Color {
+	c, ok := t.colourMap[ColourSearchMatchForeground]
+	if !ok {
+		return color.RGBA{0x1d, 0x1f, 0x21, 0xff}
+	}
+	return c
+}
+
 func (t *Theme) CursorBackground() color.Color {
 	c, ok := t.colourMap[ColourCursorBackground]
 	if !ok {
@@ -138,8 +176,39 @@ func (t *Theme) ColourFrom8Bit(n string) (color.Color, error) {
 		return nil, err
 	}
 
+	if index < 0 || index > 255 {
+		return nil, fmt.Errorf("invalid 8-bit colour index %d", index)
+	}
+
+	return t.paletteColour(uint8(index)), nil
+}
+
+// Resolve returns the colour a cell colour is drawn in with this theme
+func (t *Theme) Resolve(c CellColour) color.Color {
+	switch c.kind {
+	case colourDefaultForeground:
+		return t.DefaultForeground()
+	case colourDefaultBackground:
+		return t.DefaultBackground()
+	case colourPalette:
+		return t.paletteColour(c.index)
+	case colourRGB:
+		return c.rgb
+	}
+	return nil
+}
+
+// paletteColour returns the colour at the given index of the 256 colour palette
+func (t *Theme) paletteColour(i uint8) color.Color {
+
+	index := int(i)
+
+	if colour, ok := t.palette[i]; ok {
+		return colour
+	}
+
 	if index < 16 {
-		return t.colourMap[Colour(index)], nil
+		return t.colourMap[Colour(index)]
 	}
 
 	if index >= 232 {
@@ -149,7 +218,7 @@ func (t *Theme) ColourFrom8Bit(n string) (color.Color, error) {
 			G: byte(c),
 			B: byte(c),
 			A: 0xff,
-		}, nil
+		}
 	}
 
 	var colour color.RGBA
@@ -167,7 +236,7 @@ func (t *Theme) ColourFrom8Bit(n string) (color.Color, error) {
 		colour.B = uint8(55 + indexB*40)
 	}
 
-	return colour, nil
+	return colour
 }
 
 func (t *Theme) ColourFrom24Bit(r, g, b string) (color.Color, error) {
@@ -5,30 +5,51 @@ import "image/color"
 type ThemeFactory struct {
 	theme     *Theme
 	colourMap map[Colour]color.Color
+	palette   map[uint8]color.Color
 }
 
 func NewThemeFactory() *ThemeFactory {
 	return &ThemeFactory{
 		theme: &Theme{
 			colourMap: map[Colour]color.Color{},
+			palette:   map[uint8]color.Color{},
 		},
 		colourMap: make(map[Colour]color.Color),
+		palette:   make(map[uint8]color.Color),
 	}
 }
 
 func (t *ThemeFactory) Build() *Theme {
 	for id, col := range t.colourMap {
-		r, g, b, _ := col.RGBA()
-		t.theme.colourMap[id] = color.RGBA{
-			R: uint8(r / 0xff),
-			G: uint8(g / 0xff),
-			B: uint8(b / 0xff),
-			A: 0xff,
+		t.theme.colourMap[id] = opaque(col)
+	}
+	for index, col := range t.palette {
+		if index < 16 {
+			// the first 16 palette entries are the named ANSI colours
+			t.theme.colourMap[Colour(index)] = opaque(col)
+			continue
 		}
+		t.theme.palette[index] = opaque(col)
 	}
 	return t.theme
 }
 
+func opaque(col color.Color) color.Color {
+	r, g, b, _ := col.RGBA()
+	return color.RGBA{
+		R: uint8(r >> 8),
+		G: uint8(g >> 8),
+		B: uint8(b >> 8),
+		A: 0xff,
+	}
+}
+
+// WithPaletteColour overrides an entry in the 256 colour palette
+func (t *ThemeFactory) WithPaletteColour(index uint8, colour color.Color) *ThemeFactory {
+	t.palette[index] = colour
+	return t
+}
+
 func (t *ThemeFactory) WithColour(key Colour, colour color.Color) *ThemeFactory {
 	t.colourMap[key] = colour
 	return t
@@ -0,0 +1,52 @@
+package termutil
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaletteOverrides(t *testing.T) {
+	theme := NewThemeFactory().
+		WithColour(ColourRed, color.RGBA{R: 0xcc, A: 0xff}).
+		WithPaletteColour(1, color.RGBA{R: 0xff, A: 0xff}).
+		WithPaletteColour(200, color.RGBA{B: 0x10, A: 0xff}).
+		Build()
+
+	red, err := theme.ColourFrom8Bit("1")
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, red)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, theme.ColourFrom4Bit(31))
+
+	overridden, err := theme.ColourFrom8Bit("200")
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{B: 0x10, A: 0xff}, overridden)
+
+	computed, err := theme.ColourFrom8Bit("16")
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{A: 0xff}, computed)
+
+	_, err = theme.ColourFrom8Bit("256")
+	assert.Error(t, err)
+}
+
+func TestBrightVersion(t *testing.T) {
+	theme := NewThemeFactory().
+		WithColour(ColourRed, color.RGBA{R: 0xcc, A: 0xff}).
+		WithColour(ColourBrightRed, color.RGBA{R: 0xff, A: 0xff}).
+		Build()
+
+	bright, ok := theme.BrightVersion(PaletteColour(uint8(ColourRed)))
+	require.True(t, ok)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, bright)
+
+	// colours are brightened by their index rather than their value
+	_, ok = theme.BrightVersion(RGBColour(color.RGBA{R: 0xcc, A: 0xff}))
+	assert.False(t, ok)
+	_, ok = theme.BrightVersion(PaletteColour(uint8(ColourBrightRed)))
+	assert.False(t, ok)
+	_, ok = theme.BrightVersion(DefaultForegroundColour)
+	assert.False(t, ok)
+}